@@ -29,6 +29,7 @@ type CommandArgs struct {
 	SortField string
 	SortOrder string
 	Output    string
+	Query     string
 }
 
 // 主函数
@@ -46,7 +47,7 @@ func main() {
 	// 加载HAR文件
 	harFile, err := har.ParseFile(args.HarFile, har.WithMemoryOptimized())
 	if err != nil {
-		log.Fatalf("无法解析HAR文件: %v", err)
+		log.Fatalf("无法解析HAR文件: %s", har.FormatError(err))
 	}
 
 	// 执行命令
@@ -63,6 +64,8 @@ func main() {
 		showTiming(harFile, args)
 	case "extract":
 		extractContent(harFile, args)
+	case "query":
+		runQuery(harFile, args)
 	default:
 		fmt.Printf("未知命令: %s\n", args.Command)
 		printUsage()
@@ -74,13 +77,14 @@ func main() {
 func parseArgs() CommandArgs {
 	// 定义命令行参数
 	harFilePtr := flag.String("file", "", "HAR文件路径")
-	commandPtr := flag.String("cmd", "info", "要执行的命令 (info, list, find, headers, timing, extract)")
+	commandPtr := flag.String("cmd", "info", "要执行的命令 (info, list, find, headers, timing, extract, query)")
 	filterPtr := flag.String("filter", "", "筛选条件 (URL正则表达式、状态码、类型等)")
 	formatPtr := flag.String("format", "text", "输出格式 (text, json, csv)")
 	limitPtr := flag.Int("limit", 10, "结果数量限制")
 	sortFieldPtr := flag.String("sort", "time", "排序字段 (time, size, url, status)")
 	sortOrderPtr := flag.String("order", "desc", "排序顺序 (asc, desc)")
 	outputPtr := flag.String("output", "", "输出文件路径")
+	queryPtr := flag.String("query", "", "查询语句 (用于query命令)")
 
 	// 自定义使用说明
 	flag.Usage = printUsage
@@ -97,6 +101,7 @@ func parseArgs() CommandArgs {
 		SortField: *sortFieldPtr,
 		SortOrder: *sortOrderPtr,
 		Output:    *outputPtr,
+		Query:     *queryPtr,
 	}
 }
 
@@ -111,6 +116,7 @@ func printUsage() {
 	fmt.Println("  headers   - 显示请求或响应头")
 	fmt.Println("  timing    - 显示请求时间分析")
 	fmt.Println("  extract   - 提取响应内容")
+	fmt.Println("  query     - 执行SQL风格的查询语句")
 	fmt.Println("\n选项:")
 	flag.PrintDefaults()
 	fmt.Println("\n示例:")
@@ -118,6 +124,8 @@ func printUsage() {
 	fmt.Println("  har-cli -file example.har -cmd list -limit 20")
 	fmt.Println("  har-cli -file example.har -cmd find -filter \"api/users\"")
 	fmt.Println("  har-cli -file example.har -cmd timing -sort time -order desc")
+	fmt.Println("  har-cli -file example.har -cmd query -query \"status >= 400 AND response_header('x-cache') ~ 'MISS'\"")
+	fmt.Println("  har-cli -file example.har -cmd query -query \"SELECT host, count(*) AS n, p95(time) GROUP BY host ORDER BY n DESC\" -format csv")
 }
 
 // 显示HAR文件基本信息
@@ -649,3 +657,64 @@ func extractContent(harFile har.HARProvider, args CommandArgs) {
 		fmt.Println("未找到匹配的请求")
 	}
 }
+
+// 执行查询语句
+func runQuery(harFile har.HARProvider, args CommandArgs) {
+	queryText := args.Query
+	if queryText == "" {
+		queryText = args.Filter
+	}
+	if queryText == "" {
+		fmt.Println("错误: 请使用 -query 参数提供查询语句")
+		return
+	}
+
+	q, err := har.ParseQuery(queryText)
+	if err != nil {
+		fmt.Printf("查询语句错误: %v\n", err)
+		return
+	}
+
+	// 转换为标准格式
+	h := &har.Har{}
+	for _, entryProvider := range harFile.GetEntries() {
+		h.Log.Entries = append(h.Log.Entries, entryProvider.ToStandard())
+	}
+
+	result, err := q.Execute(h)
+	if err != nil {
+		fmt.Printf("查询执行失败: %v\n", err)
+		return
+	}
+
+	// 创建输出流
+	output := os.Stdout
+	if args.Output != "" {
+		file, err := os.Create(args.Output)
+		if err != nil {
+			fmt.Printf("无法创建输出文件: %v\n", err)
+		} else {
+			defer file.Close()
+			output = file
+		}
+	}
+
+	switch args.Format {
+	case "json":
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(result.Records())
+	case "csv":
+		err = result.WriteCSV(output)
+	default: // text
+		err = result.WriteTable(output)
+	}
+	if err != nil {
+		fmt.Printf("输出失败: %v\n", err)
+		return
+	}
+
+	if output != os.Stdout {
+		fmt.Printf("已写入 %d 行结果到 %s\n", result.Count(), args.Output)
+	}
+}
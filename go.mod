@@ -1,11 +1,17 @@
 module github.com/cyberspacesec/go-har
 
-go 1.19
+go 1.20
+
+require (
+	github.com/stretchr/testify v1.8.1
+	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.33.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/stretchr/objx v0.5.0 // indirect
-	github.com/stretchr/testify v1.8.1 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
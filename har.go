@@ -95,6 +95,14 @@ const (
 	FormatMarkdown = har.FormatMarkdown
 	FormatHTML     = har.FormatHTML
 	FormatText     = har.FormatText
+	FormatK6       = har.FormatK6
+	FormatJMeter   = har.FormatJMeter
+	FormatLocust   = har.FormatLocust
+
+	FormatChromeTrace = har.FormatChromeTrace
+
+	TraceTrackConnection = har.TraceTrackConnection
+	TraceTrackHost       = har.TraceTrackHost
 )
 
 // Error types
@@ -104,6 +112,7 @@ type (
 	ParseOptions           = har.ParseOptions
 	FilterOptions          = har.FilterOptions
 	FilterResult           = har.FilterResult
+	CompiledFilter         = har.CompiledFilter
 	Result                 = har.Result
 	ConvertOptions         = har.ConvertOptions
 	OptimizedHar           = har.OptimizedHar
@@ -119,6 +128,32 @@ type (
 	LazyContent            = har.LazyContent
 	LazyResponse           = har.LazyResponse
 	LazyEntries            = har.LazyEntries
+	JSONSchema             = har.JSONSchema
+	SchemaInferenceOptions = har.SchemaInferenceOptions
+	OTLPOptions            = har.OTLPOptions
+	OTLPTraces             = har.OTLPTraces
+	TraceTrackMode         = har.TraceTrackMode
+	PcapOptions            = har.PcapOptions
+	Query                  = har.Query
+	QueryResult            = har.QueryResult
+	Predicate              = har.Predicate
+	Severity               = har.Severity
+	RuleInfo               = har.RuleInfo
+	Finding                = har.Finding
+	ValidationReport       = har.ValidationReport
+	Validator              = har.Validator
+	Rule                   = har.Rule
+	LogRule                = har.LogRule
+	RuleReporter           = har.RuleReporter
+	RepairAction           = har.RepairAction
+	RepairReport           = har.RepairReport
+	RecoveryResult         = har.RecoveryResult
+	DiffOptions            = har.DiffOptions
+	DiffReport             = har.DiffReport
+	EntryDiff              = har.EntryDiff
+	DiffChange             = har.DiffChange
+	DiffKind               = har.DiffKind
+	DiffFormat             = har.DiffFormat
 
 	// 接口类型
 	HARProvider         = har.HARProvider
@@ -134,6 +169,9 @@ type (
 
 	// 选项类型
 	Option = har.Option
+
+	// 消息语言
+	Language = har.Language
 )
 
 // Error code constants
@@ -146,6 +184,38 @@ const (
 	ErrCodeMissingField  = har.ErrCodeMissingField
 	ErrCodeInvalidValue  = har.ErrCodeInvalidValue
 	ErrCodeUnsupported   = har.ErrCodeUnsupported
+	ErrCodeCustom        = har.ErrCodeCustom
+)
+
+// Validation severity constants
+const (
+	SeverityInfo    = har.SeverityInfo
+	SeverityWarning = har.SeverityWarning
+	SeverityError   = har.SeverityError
+)
+
+// Diff kind and format constants
+const (
+	DiffStatus = har.DiffStatus
+	DiffHeader = har.DiffHeader
+	DiffBody   = har.DiffBody
+	DiffSize   = har.DiffSize
+	DiffTiming = har.DiffTiming
+
+	DiffFormatText = har.DiffFormatText
+	DiffFormatJSON = har.DiffFormatJSON
+	DiffFormatHTML = har.DiffFormatHTML
+)
+
+// Message language constants
+const (
+	LanguageChinese = har.LanguageChinese
+	LanguageEnglish = har.LanguageEnglish
+)
+
+// SQLite export constants
+const (
+	DefaultSQLiteDriverName = har.DefaultSQLiteDriverName
 )
 
 // Forward all functions
@@ -189,10 +259,134 @@ var (
 	NewInvalidValueError   = har.NewInvalidValueError
 	NewUnsupportedError    = har.NewUnsupportedError
 
+	// Sentinel errors, usable as errors.Is targets
+	ErrInvalidHar     = har.ErrInvalidHar
+	ErrInvalidURL     = har.ErrInvalidURL
+	ErrNotJsonContent = har.ErrNotJsonContent
+
 	// Utilities
 	ParseMethod           = har.ParseMethod
 	DefaultConvertOptions = har.DefaultConvertOptions
 
+	// HAR comparison
+	Diff               = har.Diff
+	DefaultDiffOptions = har.DefaultDiffOptions
+	URLTemplate        = har.URLTemplate
+
+	// Schema inference
+	DefaultSchemaInferenceOptions = har.DefaultSchemaInferenceOptions
+	WriteJSONSchema               = har.WriteJSONSchema
+
+	// OpenTelemetry export
+	DefaultOTLPOptions = har.DefaultOTLPOptions
+
+	// Packet capture import
+	ImportPcap         = har.ImportPcap
+	ImportPcapFile     = har.ImportPcapFile
+	DefaultPcapOptions = har.DefaultPcapOptions
+	LoadTLSKeyLog      = har.LoadTLSKeyLog
+
+	// Proxy session import
+	ImportMitmproxy      = har.ImportMitmproxy
+	ImportMitmproxyFile  = har.ImportMitmproxyFile
+	ImportCharles        = har.ImportCharles
+	ImportCharlesFile    = har.ImportCharlesFile
+	ImportFiddlerSAZ     = har.ImportFiddlerSAZ
+	ImportFiddlerSAZFile = har.ImportFiddlerSAZFile
+
+	// WARC archives
+	ImportWARC     = har.ImportWARC
+	ImportWARCFile = har.ImportWARCFile
+
+	// NDJSON entries
+	NewNDJSONReader         = har.NewNDJSONReader
+	NewNDJSONReaderFromFile = har.NewNDJSONReaderFromFile
+	NewNDJSONWriter         = har.NewNDJSONWriter
+	ReadNDJSON              = har.ReadNDJSON
+	ParseNDJSONFile         = har.ParseNDJSONFile
+	AppendNDJSONFile        = har.AppendNDJSONFile
+	IsNDJSONPath            = har.IsNDJSONPath
+
+	// Parquet export
+	WriteParquet          = har.WriteParquet
+	DefaultParquetOptions = har.DefaultParquetOptions
+
+	// SQLite export
+	ExportSQLite         = har.ExportSQLite
+	ExportSQLiteIterator = har.ExportSQLiteIterator
+	ExportSQLiteDB       = har.ExportSQLiteDB
+
+	// Query language
+	ParseQuery     = har.ParseQuery
+	QueryFields    = har.QueryFields
+	QueryFunctions = har.QueryFunctions
+
+	// Composable predicates
+	And                  = har.And
+	Or                   = har.Or
+	Not                  = har.Not
+	URLMatches           = har.URLMatches
+	MustURLMatches       = har.MustURLMatches
+	URLMatchesRegexp     = har.URLMatchesRegexp
+	URLContains          = har.URLContains
+	MethodIs             = har.MethodIs
+	StatusIn             = har.StatusIn
+	StatusBetween        = har.StatusBetween
+	HeaderEquals         = har.HeaderEquals
+	ResponseHeaderEquals = har.ResponseHeaderEquals
+	HasHeader            = har.HasHeader
+	BodyContains         = har.BodyContains
+	DurationAbove        = har.DurationAbove
+	DurationBelow        = har.DurationBelow
+	ResourceTypeIs       = har.ResourceTypeIs
+	MatchOptions         = har.MatchOptions
+	FilterIterator       = har.FilterIterator
+	JSONPathMatches      = har.JSONPathMatches
+	MustJSONPathMatches  = har.MustJSONPathMatches
+
+	// JSONPath
+	EvalJSONPath = har.EvalJSONPath
+
+	// Compiled filters
+	CompileFilter     = har.CompileFilter
+	MustCompileFilter = har.MustCompileFilter
+
+	// Validation
+	NewValidator    = har.NewValidator
+	ValidationRules = har.ValidationRules
+
+	// Rule registry
+	NewRule          = har.NewRule
+	RegisterRule     = har.RegisterRule
+	MustRegisterRule = har.MustRegisterRule
+	UnregisterRule   = har.UnregisterRule
+	RegisteredRules  = har.RegisteredRules
+
+	// Repair
+	Repair = har.Repair
+
+	// Recovery
+	RecoverHar     = har.RecoverHar
+	RecoverHarFile = har.RecoverHarFile
+
+	// JSON Schema validation
+	HarSchema             = har.HarSchema
+	HarSchemaJSON         = har.HarSchemaJSON
+	ValidateAgainstSchema = har.ValidateAgainstSchema
+
+	// Error formatting
+	FormatError = har.FormatError
+
+	// Error code parsing
+	ParseErrorCode = har.ParseErrorCode
+
+	// Message localization
+	SetLanguage      = har.SetLanguage
+	CurrentLanguage  = har.CurrentLanguage
+	RegisterMessages = har.RegisterMessages
+	Languages        = har.Languages
+	Message          = har.Message
+
 	// 新的函数选项模式API
 	Parse                      = har.Parse
 	ParseFile                  = har.ParseFile
@@ -204,6 +398,9 @@ var (
 	WithSkipValidation  = har.WithSkipValidation
 	WithCollectWarnings = har.WithCollectWarnings
 	WithMaxWarnings     = har.WithMaxWarnings
+	WithRecover         = har.WithRecover
+	WithLanguage        = har.WithLanguage
+	WithStrictRules     = har.WithStrictRules
 	WithMemoryOptimized = har.WithMemoryOptimized
 	WithLazyLoading     = har.WithLazyLoading
 	WithStreaming       = har.WithStreaming
@@ -0,0 +1,275 @@
+package har
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// charlesSession Charles JSON会话文件(.chlsj)中的单个请求
+type charlesSession struct {
+	Status          string           `json:"status"`
+	Method          string           `json:"method"`
+	ProtocolVersion string           `json:"protocolVersion"`
+	Scheme          string           `json:"scheme"`
+	Host            string           `json:"host"`
+	Port            int              `json:"actualPort"`
+	Path            string           `json:"path"`
+	Query           *string          `json:"query"`
+	RemoteAddress   string           `json:"remoteAddress"`
+	Times           charlesTimes     `json:"times"`
+	Durations       charlesDurations `json:"durations"`
+	Request         charlesMessage   `json:"request"`
+	Response        *charlesMessage  `json:"response"`
+}
+
+// charlesTimes 会话各阶段的时间点
+type charlesTimes struct {
+	Start           string `json:"start"`
+	RequestBegin    string `json:"requestBegin"`
+	RequestComplete string `json:"requestComplete"`
+	ResponseBegin   string `json:"responseBegin"`
+	End             string `json:"end"`
+}
+
+// charlesDurations 会话各阶段的耗时(毫秒)，未发生的阶段为null
+type charlesDurations struct {
+	Total    *float64 `json:"total"`
+	DNS      *float64 `json:"dns"`
+	Connect  *float64 `json:"connect"`
+	Ssl      *float64 `json:"ssl"`
+	Request  *float64 `json:"request"`
+	Response *float64 `json:"response"`
+	Latency  *float64 `json:"latency"`
+}
+
+// charlesMessage 请求或响应
+type charlesMessage struct {
+	Status          int    `json:"status"`
+	MimeType        string `json:"mimeType"`
+	Charset         string `json:"charset"`
+	ContentEncoding string `json:"contentEncoding"`
+	Sizes           struct {
+		Headers int `json:"headers"`
+		Body    int `json:"body"`
+	} `json:"sizes"`
+	Header *struct {
+		FirstLine string    `json:"firstLine"`
+		Headers   []Headers `json:"headers"`
+	} `json:"header"`
+	Body *struct {
+		Text    *string `json:"text"`
+		Encoded *string `json:"encoded"`
+		Decoded bool    `json:"decoded"`
+	} `json:"body"`
+}
+
+// ImportCharlesFile 从Charles导出的JSON会话文件(.chlsj)导入HTTP流量
+//
+// Charles的durations字段映射为Timings：dns、connect和ssl直接对应，
+// request对应send，latency对应wait，response对应receive；
+// 按HAR规范connect包含ssl时间。响应体优先使用文本形式，
+// 以encoded保存的二进制内容会按Content-Encoding解码后重新编码。
+//
+// 示例:
+//
+//	h, err := ImportCharlesFile("session.chlsj")
+//	if err != nil {
+//	    log.Fatalf("导入Charles会话失败: %v", err)
+//	}
+func ImportCharlesFile(filePath string) (*Har, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	h, err := ImportCharles(file)
+	if err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return nil, err
+	}
+	return h, nil
+}
+
+// ImportCharles 从Charles JSON会话数据导入HTTP流量
+func ImportCharles(r io.Reader) (*Har, error) {
+	var sessions []charlesSession
+	if err := json.NewDecoder(r).Decode(&sessions); err != nil {
+		return nil, WrapJSONUnmarshalError(err)
+	}
+
+	h := NewHar()
+	h.SetCreator("go-har charles importer", "1.0")
+	for _, session := range sessions {
+		h.Log.Entries = append(h.Log.Entries, charlesEntry(session))
+	}
+
+	sort.SliceStable(h.Log.Entries, func(i, j int) bool {
+		return h.Log.Entries[i].StartedDateTime.Before(h.Log.Entries[j].StartedDateTime)
+	})
+	return h, nil
+}
+
+// charlesEntry 将Charles会话转换为条目
+func charlesEntry(s charlesSession) Entries {
+	rawURL := charlesURL(s)
+	entry := Entries{
+		Request: Request{
+			Method:      s.Method,
+			URL:         rawURL,
+			HTTPVersion: s.ProtocolVersion,
+			Headers:     []Headers{},
+			Cookies:     []Cookie{},
+			QueryString: queryStringFromURL(rawURL),
+			HeadersSize: s.Request.Sizes.Headers,
+			BodySize:    s.Request.Sizes.Body,
+		},
+		Response: Response{
+			HTTPVersion: s.ProtocolVersion,
+			Headers:     []Headers{},
+			Cookies:     []Cookie{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		ServerIPAddress: charlesServerIP(s.RemoteAddress),
+	}
+	if s.Request.Header != nil && s.Request.Header.Headers != nil {
+		entry.Request.Headers = s.Request.Header.Headers
+		entry.Request.Cookies = cookiesFromHeaders(s.Request.Header.Headers, "cookie")
+	}
+	if body := charlesBody(s.Request); len(body) > 0 {
+		entry.Request.PostData = map[string]interface{}{
+			"mimeType": charlesMimeType(s.Request),
+			"text":     string(body),
+		}
+	}
+
+	if resp := s.Response; resp != nil {
+		entry.Response.Status = resp.Status
+		entry.Response.HeadersSize = resp.Sizes.Headers
+		entry.Response.BodySize = resp.Sizes.Body
+		entry.Response.TransferSize = resp.Sizes.Headers + resp.Sizes.Body
+		if resp.Header != nil {
+			if resp.Header.Headers != nil {
+				entry.Response.Headers = resp.Header.Headers
+			}
+			entry.Response.Cookies = cookiesFromHeaders(entry.Response.Headers, "set-cookie")
+			entry.Response.RedirectURL = headerValue(entry.Response.Headers, "location")
+			// 状态行形如 "HTTP/1.1 200 OK"
+			parts := strings.SplitN(resp.Header.FirstLine, " ", 3)
+			if len(parts) == 3 {
+				entry.Response.HTTPVersion = parts[0]
+				entry.Response.StatusText = parts[2]
+			}
+		}
+		encoding := resp.ContentEncoding
+		if resp.Body != nil && resp.Body.Decoded {
+			encoding = ""
+		}
+		entry.Response.Content = buildContent(charlesBody(*resp), charlesMimeType(*resp), encoding, 0)
+	}
+
+	d := s.Durations
+	timings := Timings{
+		Blocked: -1,
+		DNS:     charlesDuration(d.DNS),
+		Connect: charlesDuration(d.Connect),
+		Ssl:     charlesDuration(d.Ssl),
+		Send:    charlesDuration(d.Request),
+		Wait:    charlesDuration(d.Latency),
+		Receive: charlesDuration(d.Response),
+	}
+	if timings.Send < 0 {
+		timings.Send = 0
+	}
+	if timings.Ssl > 0 {
+		if timings.Connect < 0 {
+			timings.Connect = 0
+		}
+		timings.Connect += timings.Ssl
+	}
+
+	entry.StartedDateTime = charlesTime(s.Times.Start)
+	entry.Timings = timings
+	entry.Time = timingsTotal(timings)
+	if d.Total != nil && *d.Total > entry.Time {
+		entry.Time = *d.Total
+	}
+	return entry
+}
+
+// charlesURL 根据会话字段构造完整URL
+func charlesURL(s charlesSession) string {
+	scheme := s.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	host := s.Host
+	if s.Port != 0 && !(scheme == "http" && s.Port == 80) && !(scheme == "https" && s.Port == 443) {
+		host += ":" + strconv.Itoa(s.Port)
+	}
+	rawURL := scheme + "://" + host + s.Path
+	if s.Query != nil && *s.Query != "" {
+		rawURL += "?" + *s.Query
+	}
+	return rawURL
+}
+
+// charlesServerIP 从 "host/ip" 形式的远程地址中提取IP
+func charlesServerIP(remote string) string {
+	if i := strings.LastIndex(remote, "/"); i >= 0 {
+		return remote[i+1:]
+	}
+	return remote
+}
+
+// charlesBody 返回消息体的原始字节
+func charlesBody(m charlesMessage) []byte {
+	if m.Body == nil {
+		return nil
+	}
+	if m.Body.Text != nil {
+		return []byte(*m.Body.Text)
+	}
+	if m.Body.Encoded != nil {
+		if data, err := base64.StdEncoding.DecodeString(*m.Body.Encoded); err == nil {
+			return data
+		}
+	}
+	return nil
+}
+
+// charlesMimeType 组合MIME类型和字符集
+func charlesMimeType(m charlesMessage) string {
+	if m.MimeType != "" && m.Charset != "" && !strings.Contains(m.MimeType, "charset") {
+		return m.MimeType + "; charset=" + m.Charset
+	}
+	return m.MimeType
+}
+
+// charlesDuration 将可能为null的耗时转换为HAR计时，缺失时为-1
+func charlesDuration(v *float64) float64 {
+	if v == nil {
+		return -1
+	}
+	return *v
+}
+
+// charlesTime 解析Charles的ISO 8601时间
+func charlesTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999-0700"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,153 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// FormatChromeTrace Chrome Trace Event JSON格式，可在chrome://tracing或Perfetto UI中打开
+const FormatChromeTrace ConvertFormat = "chrometrace"
+
+// TraceTrackMode 决定条目在trace中如何分配到轨道
+type TraceTrackMode string
+
+const (
+	// TraceTrackConnection 按Entries.Connection分配轨道，缺失时退化为按主机
+	TraceTrackConnection TraceTrackMode = "connection"
+	// TraceTrackHost 按请求主机分配轨道
+	TraceTrackHost TraceTrackMode = "host"
+)
+
+// chromeTraceEvent 表示一个Trace Event
+type chromeTraceEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat,omitempty"`
+	Ph   string                 `json:"ph"`
+	Ts   int64                  `json:"ts"`
+	Dur  int64                  `json:"dur,omitempty"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// chromeTrace 表示Trace Event文件的JSON对象格式
+type chromeTrace struct {
+	TraceEvents     []chromeTraceEvent `json:"traceEvents"`
+	DisplayTimeUnit string             `json:"displayTimeUnit"`
+}
+
+// chromeTrack 轨道内的一条通道，用于避免同一轨道内的切片相互重叠
+type chromeTrack struct {
+	tid  int
+	name string
+	end  time.Time
+}
+
+// convertToChromeTrace 将条目转换为Chrome Trace Event JSON
+func convertToChromeTrace(entries []Entries, options ConvertOptions) (string, error) {
+	sorted := make([]Entries, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartedDateTime.Before(sorted[j].StartedDateTime)
+	})
+
+	trace := chromeTrace{
+		TraceEvents:     []chromeTraceEvent{},
+		DisplayTimeUnit: "ms",
+	}
+	trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+		Name: "process_name",
+		Ph:   "M",
+		Pid:  1,
+		Args: map[string]interface{}{"name": "HAR"},
+	})
+
+	var origin time.Time
+	if len(sorted) > 0 {
+		origin = sorted[0].StartedDateTime
+	}
+
+	lanes := make(map[string][]*chromeTrack)
+	nextTid := 1
+	for _, entry := range sorted {
+		start := entry.StartedDateTime
+		end := start.Add(msToDuration(entry.Time))
+
+		key := chromeTrackKey(entry, options.TraceTrack)
+		var track *chromeTrack
+		for _, lane := range lanes[key] {
+			if !lane.end.After(start) {
+				track = lane
+				break
+			}
+		}
+		if track == nil {
+			name := key
+			if n := len(lanes[key]); n > 0 {
+				name = fmt.Sprintf("%s #%d", key, n+1)
+			}
+			track = &chromeTrack{tid: nextTid, name: name}
+			nextTid++
+			lanes[key] = append(lanes[key], track)
+			trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+				Name: "thread_name",
+				Ph:   "M",
+				Pid:  1,
+				Tid:  track.tid,
+				Args: map[string]interface{}{"name": track.name},
+			})
+		}
+		track.end = end
+
+		ts := start.Sub(origin).Microseconds()
+		trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+			Name: entry.Request.Method + " " + entry.Request.URL,
+			Cat:  "request",
+			Ph:   "X",
+			Ts:   ts,
+			Dur:  end.Sub(start).Microseconds(),
+			Pid:  1,
+			Tid:  track.tid,
+			Args: map[string]interface{}{
+				"url":        entry.Request.URL,
+				"status":     entry.Response.Status,
+				"mimeType":   entry.Response.Content.MimeType,
+				"size":       entry.Response.Content.Size,
+				"connection": entry.Connection,
+				"serverIP":   entry.ServerIPAddress,
+			},
+		})
+
+		for _, phase := range timingPhases(entry.Timings) {
+			trace.TraceEvents = append(trace.TraceEvents, chromeTraceEvent{
+				Name: phase.Name,
+				Cat:  "phase",
+				Ph:   "X",
+				Ts:   ts + phase.Offset.Microseconds(),
+				Dur:  phase.Length.Microseconds(),
+				Pid:  1,
+				Tid:  track.tid,
+			})
+		}
+	}
+
+	data, err := json.Marshal(trace)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// chromeTrackKey 返回条目所属轨道的名称
+func chromeTrackKey(entry Entries, mode TraceTrackMode) string {
+	if mode != TraceTrackHost && entry.Connection != "" {
+		return "connection " + entry.Connection
+	}
+	if u, err := url.Parse(entry.Request.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "unknown"
+}
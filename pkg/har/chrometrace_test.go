@@ -0,0 +1,86 @@
+package har
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertToChromeTrace(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHar()
+	add := func(url, connection string, offset time.Duration, total float64) *Entries {
+		e := h.AddEntry("GET", url, "HTTP/1.1", "")
+		e.StartedDateTime = start.Add(offset)
+		e.Connection = connection
+		e.Time = total
+		return e
+	}
+	first := add("https://x.com/a", "1", 0, 12.5)
+	first.SetTimings(-1, 2, 3, 0.5, 5, 2, -1)
+	first.Time = 12.5
+	add("https://x.com/b", "2", 5*time.Millisecond, 10)
+	// 与第一个请求复用连接，开始于其结束之后
+	add("https://x.com/c", "1", 20*time.Millisecond, 1)
+
+	data, err := h.Convert(FormatChromeTrace, ConvertOptions{})
+	assert.NoError(t, err)
+
+	var trace chromeTrace
+	assert.NoError(t, json.Unmarshal([]byte(data), &trace))
+	assert.Equal(t, "ms", trace.DisplayTimeUnit)
+
+	threads := make(map[int]string)
+	requests := make(map[string]chromeTraceEvent)
+	var phases []chromeTraceEvent
+	for _, e := range trace.TraceEvents {
+		switch {
+		case e.Ph == "M" && e.Name == "thread_name":
+			threads[e.Tid] = e.Args["name"].(string)
+		case e.Ph == "X" && e.Cat == "request":
+			requests[e.Args["url"].(string)] = e
+		case e.Ph == "X" && e.Cat == "phase":
+			phases = append(phases, e)
+		default:
+			assert.Equal(t, "M", e.Ph, e.Name)
+		}
+	}
+
+	// 每个连接一个轨道
+	assert.Len(t, threads, 2)
+	a, b, c := requests["https://x.com/a"], requests["https://x.com/b"], requests["https://x.com/c"]
+	assert.Equal(t, "connection 1", threads[a.Tid])
+	assert.Equal(t, "connection 2", threads[b.Tid])
+	assert.Equal(t, a.Tid, c.Tid)
+
+	// ts和dur以微秒为单位，ts相对第一个请求
+	assert.Equal(t, int64(0), a.Ts)
+	assert.Equal(t, int64(12500), a.Dur)
+	assert.Equal(t, int64(5000), b.Ts)
+	assert.Equal(t, int64(20000), c.Ts)
+
+	// 计时阶段位于请求所在轨道，-1的阶段被跳过
+	var names []string
+	for _, p := range phases {
+		assert.Equal(t, a.Tid, p.Tid)
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"dns", "connect", "send", "wait", "receive"}, names)
+	assert.Equal(t, int64(2000), phases[1].Ts)
+	assert.Equal(t, int64(3000), phases[1].Dur)
+
+	// 按主机分配轨道时，同一时间重叠的请求拆分到不同通道
+	data, err = h.Convert(FormatChromeTrace, ConvertOptions{TraceTrack: TraceTrackHost})
+	assert.NoError(t, err)
+	trace = chromeTrace{}
+	assert.NoError(t, json.Unmarshal([]byte(data), &trace))
+	threads = make(map[int]string)
+	for _, e := range trace.TraceEvents {
+		if e.Name == "thread_name" {
+			threads[e.Tid] = e.Args["name"].(string)
+		}
+	}
+	assert.Equal(t, map[int]string{1: "x.com", 2: "x.com #2"}, threads)
+}
@@ -0,0 +1,279 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// coercionTimeLayouts 除RFC3339外可接受的日期格式，没有时区的日期按UTC处理
+var coercionTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.RFC1123,
+	time.RFC1123Z,
+	time.RFC850,
+	time.ANSIC,
+	"Mon, 02-Jan-2006 15:04:05 MST",
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// decodeLenient 将JSON解码到v，类型不符的值会被转换
+//
+// 支持字符串与数字、布尔值之间的转换，null替换为默认值，
+// 非RFC3339格式的日期(无时区、空格分隔、HTTP日期、Unix时间戳)转换为time.Time。
+// 每次转换都会生成一条警告，path为数据在HAR中的JSON路径。
+// 无法转换时返回严格解码data的错误，其中的偏移相对于data。
+func decodeLenient(data []byte, v interface{}, path string) ([]*HarError, error) {
+	// 快速路径：严格解码成功且没有null时无需转换
+	strictErr := json.Unmarshal(data, v)
+	if strictErr == nil && !bytes.Contains(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var raw interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	c := &coercer{}
+	target := reflect.ValueOf(v).Elem()
+	coerced := c.coerce(raw, target.Type(), path)
+	fixed, err := json.Marshal(coerced)
+	if err != nil {
+		return c.warnings, err
+	}
+	// 解码到新值，避免快速路径中的部分结果残留
+	fresh := reflect.New(target.Type())
+	if err := json.Unmarshal(fixed, fresh.Interface()); err != nil {
+		if strictErr != nil {
+			// 严格解码的错误中的偏移指向原始数据
+			err = strictErr
+		}
+		return c.warnings, err
+	}
+	target.Set(fresh.Elem())
+	return c.warnings, nil
+}
+
+// coercer 按目标类型转换通用JSON值
+type coercer struct {
+	warnings []*HarError
+}
+
+// warn 记录一次转换，key为消息目录中的键
+func (c *coercer) warn(path string, value interface{}, key string, params ...interface{}) {
+	c.warnings = append(c.warnings,
+		newLocalizedError(ErrCodeInvalidValue, key, nil, params...).
+			WithField(path).
+			WithMetadata("value", value).
+			WithMetadata("coerced", true))
+}
+
+func (c *coercer) coerce(v interface{}, t reflect.Type, path string) interface{} {
+	if t == timeType {
+		return c.coerceTime(v, path)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v == nil {
+			return nil
+		}
+		return c.coerce(v, t.Elem(), path)
+	case reflect.Interface:
+		return v
+	case reflect.Slice, reflect.Map:
+		// 与encoding/json一致，null数组解码为nil，本库自身输出的空数组也是null
+		if v == nil {
+			return nil
+		}
+	}
+
+	if v == nil {
+		c.warn(path, nil, "coerce.null")
+		return reflect.Zero(t).Interface()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if obj, ok := v.(map[string]interface{}); ok {
+			c.coerceStruct(obj, t, path)
+		}
+		return v
+	case reflect.Slice:
+		if arr, ok := v.([]interface{}); ok {
+			for i, item := range arr {
+				arr[i] = c.coerce(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+		return v
+	case reflect.String:
+		switch val := v.(type) {
+		case json.Number:
+			c.warn(path, val, "coerce.number_to_string", val)
+			return val.String()
+		case bool:
+			c.warn(path, val, "coerce.bool_to_string", val)
+			return strconv.FormatBool(val)
+		}
+		return v
+	case reflect.Bool:
+		switch val := v.(type) {
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(val)); err == nil {
+				c.warn(path, val, "coerce.string_to_bool", val)
+				return b
+			}
+		case json.Number:
+			f, err := val.Float64()
+			if err == nil {
+				c.warn(path, val, "coerce.number_to_bool", val)
+				return f != 0
+			}
+		}
+		return v
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return c.coerceNumber(v, path, true)
+	case reflect.Float32, reflect.Float64:
+		return c.coerceNumber(v, path, false)
+	}
+	return v
+}
+
+// coerceStruct 按JSON标签转换对象中的字段
+func (c *coercer) coerceStruct(obj map[string]interface{}, t reflect.Type, path string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		key, ok := name, false
+		if _, ok = obj[name]; !ok {
+			// 与encoding/json一致，字段名不区分大小写
+			for k := range obj {
+				if strings.EqualFold(k, name) {
+					key, ok = k, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		obj[key] = c.coerce(obj[key], field.Type, fieldPath)
+	}
+}
+
+// coerceNumber 将字符串和布尔值转换为数字，整数字段的小数会四舍五入
+func (c *coercer) coerceNumber(v interface{}, path string, integer bool) interface{} {
+	var f float64
+	switch val := v.(type) {
+	case json.Number:
+		if !integer {
+			return v
+		}
+		if _, err := val.Int64(); err == nil {
+			return v
+		}
+		parsed, err := val.Float64()
+		if err != nil {
+			return v
+		}
+		f = parsed
+		c.warn(path, val, "coerce.round", val)
+	case string:
+		s := strings.TrimSpace(val)
+		if s == "" {
+			c.warn(path, val, "coerce.empty_number")
+			return 0
+		}
+		parsed, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return v
+		}
+		f = parsed
+		c.warn(path, val, "coerce.string_to_number", val)
+	case bool:
+		if val {
+			f = 1
+		}
+		c.warn(path, val, "coerce.bool_to_number", val)
+	default:
+		return v
+	}
+	if integer {
+		return int64(math.Round(f))
+	}
+	return f
+}
+
+// coerceTime 将非RFC3339格式的日期转换为RFC3339字符串
+func (c *coercer) coerceTime(v interface{}, path string) interface{} {
+	switch val := v.(type) {
+	case nil:
+		c.warn(path, nil, "coerce.null")
+		return v
+	case string:
+		s := strings.TrimSpace(val)
+		if s == "" {
+			c.warn(path, val, "coerce.empty_date")
+			return time.Time{}
+		}
+		if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return v
+		}
+		for _, layout := range coercionTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				c.warn(path, val, "coerce.date_layout", val, layout)
+				return t
+			}
+		}
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			c.warn(path, val, "coerce.timestamp_string", val)
+			return unixTime(n)
+		}
+	case json.Number:
+		if n, err := val.Float64(); err == nil {
+			c.warn(path, val, "coerce.timestamp", val)
+			return unixTime(n)
+		}
+	}
+	return v
+}
+
+// unixTime 将Unix时间戳转换为时间，大于1e12的值视为毫秒
+func unixTime(n float64) time.Time {
+	if math.Abs(n) > 1e12 {
+		return time.UnixMilli(int64(n)).UTC()
+	}
+	sec, frac := math.Modf(n)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
@@ -0,0 +1,77 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHarWithWarningsCoercion(t *testing.T) {
+	data := []byte(`{"log":{"version":1.2,"creator":{"name":"tool","version":"1"},"entries":[
+		{"startedDateTime":"2024-01-02 03:04:05","time":null,
+		 "request":{"method":"GET","url":"https://x.com/","httpVersion":"HTTP/1.1","cookies":null,"headers":[],"queryString":[],"headersSize":"120","bodySize":-1},
+		 "response":{"status":"200","statusText":"OK","httpVersion":"HTTP/1.1","cookies":[{"name":"a","value":1,"expires":"Wed, 21 Oct 2026 07:28:00 GMT","secure":"true"}],"headers":[],
+		  "content":{"size":"12.6","mimeType":"text/plain"},"redirectURL":"","headersSize":-1,"bodySize":12},
+		 "cache":{},"timings":{"send":"1","wait":2,"receive":1,"blocked":-1,"dns":-1,"connect":-1,"ssl":-1}},
+		{"startedDateTime":1704164645000,"time":1,"request":{"method":"GET","url":"https://x.com/b","httpVersion":"HTTP/1.1","cookies":[],"headers":[],"queryString":[],"headersSize":-1,"bodySize":-1},
+		 "response":{"status":{"code":200},"statusText":"","httpVersion":"HTTP/1.1","cookies":[],"headers":[],"content":{"size":0,"mimeType":"x"},"redirectURL":"","headersSize":-1,"bodySize":-1},
+		 "cache":{},"timings":{"send":0,"wait":1,"receive":0}}
+	]}}`)
+
+	result, err := ParseHarWithWarnings(data)
+	assert.NoError(t, err)
+	h := result.Har
+	assert.Equal(t, "1.2", h.Log.Version)
+
+	// 第二个条目的status无法转换，被跳过
+	if assert.Len(t, h.Log.Entries, 1) {
+		e := h.Log.Entries[0]
+		assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), e.StartedDateTime)
+		assert.Equal(t, 0.0, e.Time)
+		assert.Equal(t, 200, e.Response.Status)
+		assert.Equal(t, 120, e.Request.HeadersSize)
+		assert.Nil(t, e.Request.Cookies)
+		assert.Equal(t, 13, e.Response.Content.Size)
+		assert.Equal(t, 1.0, e.Timings.Send)
+		if assert.Len(t, e.Response.Cookies, 1) {
+			c := e.Response.Cookies[0]
+			assert.Equal(t, "1", c.Value)
+			assert.True(t, c.Secure)
+			assert.Equal(t, 2026, c.Expires.Year())
+		}
+	}
+
+	fields := map[string]bool{}
+	for _, w := range result.Warnings {
+		if w.Metadata["coerced"] == true {
+			fields[w.Field] = true
+		}
+	}
+	for _, field := range []string{
+		"log.version",
+		"log.entries[0].startedDateTime",
+		"log.entries[0].time",
+		"log.entries[0].request.headersSize",
+		"log.entries[0].response.status",
+		"log.entries[0].response.cookies[0].expires",
+		"log.entries[0].response.content.size",
+		"log.entries[0].timings.send",
+	} {
+		assert.True(t, fields[field], field)
+	}
+}
+
+func TestDecodeLenientTimestamps(t *testing.T) {
+	var e Entries
+	warnings, err := decodeLenient([]byte(`{"startedDateTime":"1704164645.5"}`), &e, "entry")
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC), e.StartedDateTime)
+
+	// 无需转换时没有警告
+	warnings, err = decodeLenient([]byte(`{"startedDateTime":"2024-01-02T02:24:05Z","time":3}`), &e, "entry")
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, 3.0, e.Time)
+}
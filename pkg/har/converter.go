@@ -36,6 +36,9 @@ type ConvertOptions struct {
 
 	// 过滤选项（可选，用于在转换前先过滤数据）
 	Filter *FilterOptions
+
+	// Chrome Trace格式的轨道分配方式（默认按连接）
+	TraceTrack TraceTrackMode
 }
 
 // DefaultConvertOptions 默认的转换选项
@@ -71,6 +74,14 @@ func (h *Har) Convert(format ConvertFormat, options ConvertOptions) (string, err
 		return convertToHTML(entries, options)
 	case FormatText:
 		return convertToText(entries, options)
+	case FormatChromeTrace:
+		return convertToChromeTrace(entries, options)
+	case FormatK6:
+		return convertToK6(h, entries)
+	case FormatJMeter:
+		return convertToJMeter(h, entries)
+	case FormatLocust:
+		return convertToLocust(h, entries)
 	default:
 		return "", fmt.Errorf("不支持的转换格式: %s", format)
 	}
@@ -0,0 +1,752 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DiffKind 配对请求之间的差异类型
+type DiffKind string
+
+const (
+	DiffStatus DiffKind = "status" // 状态码变化
+	DiffHeader DiffKind = "header" // 请求头或响应头变化
+	DiffBody   DiffKind = "body"   // 请求体或响应体变化
+	DiffSize   DiffKind = "size"   // 响应大小增长
+	DiffTiming DiffKind = "timing" // 耗时增长
+)
+
+// DiffFormat DiffReport.Render的输出格式
+type DiffFormat string
+
+const (
+	DiffFormatText DiffFormat = "text" // 纯文本，每个请求一行，变化逐条缩进列出
+	DiffFormatJSON DiffFormat = "json" // 与DiffReport结构对应的JSON
+	DiffFormatHTML DiffFormat = "html" // 独立的HTML页面
+)
+
+// diffValueLimit 差异说明中单个值保留的最大字符数
+const diffValueLimit = 80
+
+// DiffOptions 比较选项
+type DiffOptions struct {
+	// 条目的匹配键，键相同的条目才会被配对，默认为大写的方法加URLTemplate
+	KeyFunc func(entry *Entries) string
+	// 默认匹配键忽略查询参数名称
+	IgnoreQuery bool
+	// 键相同的条目按出现顺序配对；大于0时，两者在各自log.entries中的位置相差超过该值则不配对，
+	// 分别报告为删除和新增。0表示不限制
+	OrderTolerance int
+
+	// 不比较的头部名称，不区分大小写
+	IgnoreHeaders []string
+	// 不比较的JSON路径，如 "$.timestamp"，其下的子路径同样被忽略
+	IgnoreJSONPaths []string
+	// 不比较请求体和响应体
+	IgnoreBodies bool
+	// 每个请求体或响应体最多列出的差异数，0表示不限制
+	MaxBodyChanges int
+
+	// 响应大小增长超过该比例(0.1表示10%)时报告，负数表示不比较大小
+	SizeTolerance float64
+	// 耗时增长超过该比例且超过MinTimeDelta毫秒时报告，负数表示不比较耗时
+	TimeTolerance float64
+	MinTimeDelta  float64
+}
+
+// DefaultDiffOptions 默认的比较选项，忽略每次请求都会变化的头部
+func DefaultDiffOptions() DiffOptions {
+	return DiffOptions{
+		IgnoreHeaders: []string{
+			"Date", "Expires", "Last-Modified", "Age", "ETag", "Cookie", "Set-Cookie",
+			"Content-Length", "X-Request-ID", "Server-Timing",
+		},
+		MaxBodyChanges: 20,
+		SizeTolerance:  0.1,
+		TimeTolerance:  0.2,
+		MinTimeDelta:   50,
+	}
+}
+
+// DiffChange 配对请求之间的一处差异
+type DiffChange struct {
+	Kind     DiffKind    `json:"kind"`
+	Field    string      `json:"field"`              // 差异所在字段，如 "response.status"、"response.headers.Cache-Control"
+	JSONPath string      `json:"jsonPath,omitempty"` // JSON体中的差异位置，如 "$.items[0].id"
+	Before   interface{} `json:"before,omitempty"`   // a中的值，不存在时为nil
+	After    interface{} `json:"after,omitempty"`    // b中的值，不存在时为nil
+	Message  string      `json:"message"`            // 差异说明，使用生成报告时的默认语言(见SetLanguage)
+}
+
+// String 返回可读的描述
+func (c DiffChange) String() string {
+	field := c.Field
+	if c.JSONPath != "" {
+		field += " " + c.JSONPath
+	}
+	return fmt.Sprintf("%s %s: %s", c.Kind, field, c.Message)
+}
+
+// EntryDiff 一个请求的比较结果
+type EntryDiff struct {
+	Key     string       `json:"key"`    // 匹配键
+	Method  string       `json:"method"` // 请求方法
+	URL     string       `json:"url"`    // b中的URL，删除的请求为a中的URL
+	IndexA  int          `json:"indexA"` // 在a的log.entries中的位置，新增的请求为-1
+	IndexB  int          `json:"indexB"` // 在b的log.entries中的位置，删除的请求为-1
+	Changes []DiffChange `json:"changes,omitempty"`
+}
+
+// DiffReport 比较结果
+type DiffReport struct {
+	Added     []EntryDiff `json:"added"`     // 只在b中出现的请求
+	Removed   []EntryDiff `json:"removed"`   // 只在a中出现的请求
+	Changed   []EntryDiff `json:"changed"`   // 配对且存在差异的请求
+	Unchanged int         `json:"unchanged"` // 配对且没有差异的请求数
+}
+
+// HasChanges 是否存在任何差异
+func (r *DiffReport) HasChanges() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Changed) > 0
+}
+
+// ByKind 返回包含指定类型差异的请求，Changes只保留该类型的差异
+func (r *DiffReport) ByKind(kind DiffKind) []EntryDiff {
+	var entries []EntryDiff
+	for _, d := range r.Changed {
+		var changes []DiffChange
+		for _, c := range d.Changes {
+			if c.Kind == kind {
+				changes = append(changes, c)
+			}
+		}
+		if len(changes) > 0 {
+			d.Changes = changes
+			entries = append(entries, d)
+		}
+	}
+	return entries
+}
+
+// Render 将比较结果输出为指定格式，文本和HTML中的标题使用当前的默认语言
+func (r *DiffReport) Render(format DiffFormat) (string, error) {
+	buf := &bytes.Buffer{}
+	var err error
+	switch format {
+	case DiffFormatText:
+		r.writeText(buf)
+	case DiffFormatJSON:
+		err = r.writeJSON(buf)
+	case DiffFormatHTML:
+		r.writeHTML(buf)
+	default:
+		return "", newLocalizedError(ErrCodeUnsupported, "diff.format", nil, format)
+	}
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// summary 返回各类请求数量的概要
+func (r *DiffReport) summary() string {
+	return localize("diff.summary", len(r.Added), len(r.Removed), len(r.Changed), r.Unchanged)
+}
+
+func (r *DiffReport) writeText(w io.Writer) {
+	fmt.Fprintf(w, "%s: %s\n", localize("diff.title"), r.summary())
+	if r.HasChanges() {
+		fmt.Fprintln(w)
+	}
+	for _, d := range r.Added {
+		fmt.Fprintf(w, "+ %s %s\n", d.Method, d.URL)
+	}
+	for _, d := range r.Removed {
+		fmt.Fprintf(w, "- %s %s\n", d.Method, d.URL)
+	}
+	for _, d := range r.Changed {
+		fmt.Fprintf(w, "~ %s %s\n", d.Method, d.URL)
+		for _, c := range d.Changes {
+			fmt.Fprintf(w, "    %s\n", c)
+		}
+	}
+}
+
+func (r *DiffReport) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+func (r *DiffReport) writeHTML(w io.Writer) {
+	fmt.Fprintln(w, "<!DOCTYPE html>")
+	fmt.Fprintln(w, "<html>")
+	fmt.Fprintln(w, "<head>")
+	fmt.Fprintln(w, "  <meta charset=\"utf-8\">")
+	fmt.Fprintf(w, "  <title>%s</title>\n", escapeHTML(localize("diff.title")))
+	fmt.Fprintln(w, "  <style>")
+	fmt.Fprintln(w, "    .added { background: #e6ffed; }")
+	fmt.Fprintln(w, "    .removed { background: #ffeef0; }")
+	fmt.Fprintln(w, "    .changed { background: #fffbdd; }")
+	fmt.Fprintln(w, "  </style>")
+	fmt.Fprintln(w, "</head>")
+	fmt.Fprintln(w, "<body>")
+	fmt.Fprintf(w, "<p>%s</p>\n", escapeHTML(r.summary()))
+
+	fmt.Fprintln(w, "<table border=\"1\">")
+	fmt.Fprintln(w, "  <thead>")
+	fmt.Fprintf(w, "    <tr><th>%s</th><th>%s</th><th>URL</th><th>%s</th></tr>\n",
+		escapeHTML(localize("diff.column_change")), escapeHTML(localize("diff.column_method")), escapeHTML(localize("diff.column_changes")))
+	fmt.Fprintln(w, "  </thead>")
+	fmt.Fprintln(w, "  <tbody>")
+	writeRow := func(class, label string, d EntryDiff) {
+		fmt.Fprintf(w, "    <tr class=\"%s\">\n", class)
+		fmt.Fprintf(w, "      <td>%s</td>\n", escapeHTML(label))
+		fmt.Fprintf(w, "      <td>%s</td>\n", escapeHTML(d.Method))
+		fmt.Fprintf(w, "      <td>%s</td>\n", escapeHTML(d.URL))
+		if len(d.Changes) == 0 {
+			fmt.Fprintln(w, "      <td></td>")
+		} else {
+			fmt.Fprintln(w, "      <td><ul>")
+			for _, c := range d.Changes {
+				fmt.Fprintf(w, "        <li>%s</li>\n", escapeHTML(c.String()))
+			}
+			fmt.Fprintln(w, "      </ul></td>")
+		}
+		fmt.Fprintln(w, "    </tr>")
+	}
+	for _, d := range r.Added {
+		writeRow("added", localize("diff.added"), d)
+	}
+	for _, d := range r.Removed {
+		writeRow("removed", localize("diff.removed"), d)
+	}
+	for _, d := range r.Changed {
+		writeRow("changed", localize("diff.changed"), d)
+	}
+	fmt.Fprintln(w, "  </tbody>")
+	fmt.Fprintln(w, "</table>")
+	fmt.Fprintln(w, "</body>")
+	fmt.Fprintln(w, "</html>")
+}
+
+// Diff 比较两个HAR，a为基准(如部署前的抓包)，b为对比对象(如部署后的抓包)
+//
+// 条目按匹配键配对，键相同的多个条目按出现顺序配对，因此两次抓包中请求的先后顺序
+// 不同也能正确对齐。配对的条目比较状态码、头部、请求体和响应体(两者均为JSON对象或数组时
+// 进行结构化比较)，以及超过容差的响应大小和耗时增长。
+//
+// 示例:
+//
+//	report := har.Diff(before, after, har.DefaultDiffOptions())
+//	text, _ := report.Render(har.DiffFormatText)
+func Diff(a, b *Har, options DiffOptions) *DiffReport {
+	report := &DiffReport{Added: []EntryDiff{}, Removed: []EntryDiff{}, Changed: []EntryDiff{}}
+	var entriesA, entriesB []Entries
+	if a != nil {
+		entriesA = a.Log.Entries
+	}
+	if b != nil {
+		entriesB = b.Log.Entries
+	}
+
+	keysA := make([]string, len(entriesA))
+	candidates := make(map[string][]int)
+	for i := range entriesA {
+		keysA[i] = options.key(&entriesA[i])
+		candidates[keysA[i]] = append(candidates[keysA[i]], i)
+	}
+
+	matched := make([]bool, len(entriesA))
+	for j := range entriesB {
+		entry := &entriesB[j]
+		key := options.key(entry)
+		i := options.pair(candidates[key], matched, j)
+		if i < 0 {
+			report.Added = append(report.Added, newEntryDiff(key, entry, -1, j))
+			continue
+		}
+		matched[i] = true
+
+		d := newEntryDiff(key, entry, i, j)
+		d.Changes = options.compare(&entriesA[i], entry)
+		if len(d.Changes) == 0 {
+			report.Unchanged++
+		} else {
+			report.Changed = append(report.Changed, d)
+		}
+	}
+
+	for i := range entriesA {
+		if !matched[i] {
+			report.Removed = append(report.Removed, newEntryDiff(keysA[i], &entriesA[i], i, -1))
+		}
+	}
+	return report
+}
+
+func newEntryDiff(key string, entry *Entries, indexA, indexB int) EntryDiff {
+	return EntryDiff{Key: key, Method: entry.Request.Method, URL: entry.Request.URL, IndexA: indexA, IndexB: indexB}
+}
+
+// key 返回条目的匹配键
+func (o DiffOptions) key(entry *Entries) string {
+	if o.KeyFunc != nil {
+		return o.KeyFunc(entry)
+	}
+	template := URLTemplate(entry.Request.URL)
+	if o.IgnoreQuery {
+		if i := strings.IndexByte(template, '?'); i >= 0 {
+			template = template[:i]
+		}
+	}
+	return strings.ToUpper(entry.Request.Method) + " " + template
+}
+
+// pair 返回第一个未配对且位置在容差范围内的候选条目，没有时返回-1
+func (o DiffOptions) pair(candidates []int, matched []bool, position int) int {
+	for _, i := range candidates {
+		if matched[i] {
+			continue
+		}
+		if o.OrderTolerance > 0 && abs(i-position) > o.OrderTolerance {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// hexIDPattern 含数字的十六进制串，至少16位时被视为ID
+var hexIDPattern = regexp.MustCompile(`^[0-9a-fA-F]*[0-9][0-9a-fA-F]*$`)
+
+// URLTemplate 将URL中的可变部分替换为占位符，用于对齐不同抓包中的同类请求
+//
+// 纯数字、UUID和至少16位的十六进制路径段替换为{id}，查询参数只保留排序后的名称，
+// 片段被丢弃:
+//
+//	https://api.example.com/users/42/orders?sort=asc&page=2
+//	=> https://api.example.com/users/{id}/orders?page&sort
+func URLTemplate(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, s := range segments {
+		if isDigits(s) || uuidPattern.MatchString(s) || (len(s) >= 16 && hexIDPattern.MatchString(s)) {
+			segments[i] = "{id}"
+		}
+	}
+
+	var b strings.Builder
+	if u.Scheme != "" {
+		b.WriteString(strings.ToLower(u.Scheme) + "://")
+	}
+	b.WriteString(strings.ToLower(u.Host))
+	b.WriteString(strings.Join(segments, "/"))
+
+	query := u.Query()
+	if len(query) > 0 {
+		names := make([]string, 0, len(query))
+		for name := range query {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString("?" + strings.Join(names, "&"))
+	}
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// compare 比较一对条目
+func (o DiffOptions) compare(a, b *Entries) []DiffChange {
+	var changes []DiffChange
+	if a.Response.Status != b.Response.Status {
+		changes = append(changes, DiffChange{
+			Kind:    DiffStatus,
+			Field:   "response.status",
+			Before:  a.Response.Status,
+			After:   b.Response.Status,
+			Message: localize("diff.status", a.Response.Status, b.Response.Status),
+		})
+	}
+
+	ignored := make(map[string]bool, len(o.IgnoreHeaders))
+	for _, name := range o.IgnoreHeaders {
+		ignored[strings.ToLower(name)] = true
+	}
+	changes = diffHeaders(changes, "request.headers", a.Request.Headers, b.Request.Headers, ignored)
+	changes = diffHeaders(changes, "response.headers", a.Response.Headers, b.Response.Headers, ignored)
+
+	if !o.IgnoreBodies {
+		_, textA := postDataText(a.Request)
+		_, textB := postDataText(b.Request)
+		changes = o.diffBody(changes, "request.postData.text", []byte(textA), []byte(textB))
+		changes = o.diffBody(changes, "response.content.text", diffResponseBody(a.Response.Content), diffResponseBody(b.Response.Content))
+	}
+
+	changes = o.diffSize(changes, a, b)
+	return o.diffTimings(changes, a, b)
+}
+
+// diffHeaders 比较头部，名称不区分大小写，同名的多个值按顺序以", "连接
+func diffHeaders(changes []DiffChange, field string, a, b []Headers, ignored map[string]bool) []DiffChange {
+	valuesA, namesA := headerValues(a)
+	valuesB, namesB := headerValues(b)
+
+	keys := make([]string, 0, len(valuesA)+len(valuesB))
+	for key := range valuesA {
+		keys = append(keys, key)
+	}
+	for key := range valuesB {
+		if _, ok := valuesA[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if ignored[key] {
+			continue
+		}
+		before, okA := valuesA[key]
+		after, okB := valuesB[key]
+		change := DiffChange{Kind: DiffHeader}
+		switch {
+		case !okA:
+			change.Field = field + "." + namesB[key]
+			change.After = after
+			change.Message = localize("diff.header_added", abbreviate(after))
+		case !okB:
+			change.Field = field + "." + namesA[key]
+			change.Before = before
+			change.Message = localize("diff.header_removed", abbreviate(before))
+		case before != after:
+			change.Field = field + "." + namesB[key]
+			change.Before = before
+			change.After = after
+			change.Message = localize("diff.value_changed", strconv.Quote(abbreviate(before)), strconv.Quote(abbreviate(after)))
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// headerValues 返回以小写名称为键的头部值和头部首次出现时的名称
+func headerValues(headers []Headers) (map[string]string, map[string]string) {
+	values := make(map[string]string, len(headers))
+	names := make(map[string]string, len(headers))
+	for _, h := range headers {
+		key := strings.ToLower(h.Name)
+		if v, ok := values[key]; ok {
+			values[key] = v + ", " + h.Value
+			continue
+		}
+		values[key] = h.Value
+		names[key] = h.Name
+	}
+	return values, names
+}
+
+// diffResponseBody 返回解码后的响应体，无法解码时返回原始文本
+func diffResponseBody(content Content) []byte {
+	body, err := decodeContentText(content)
+	if err != nil {
+		return []byte(content.Text)
+	}
+	return body
+}
+
+// diffBody 比较请求体或响应体，两者均为JSON对象或数组时逐字段比较
+func (o DiffOptions) diffBody(changes []DiffChange, field string, a, b []byte) []DiffChange {
+	if bytes.Equal(a, b) {
+		return changes
+	}
+
+	valueA, okA := decodeJSONBody(a)
+	valueB, okB := decodeJSONBody(b)
+	if okA && okB {
+		d := &jsonDiff{field: field, ignore: o.IgnoreJSONPaths, limit: o.MaxBodyChanges}
+		d.compare("$", valueA, valueB)
+		changes = append(changes, d.changes...)
+		if d.skipped > 0 {
+			changes = append(changes, DiffChange{
+				Kind:    DiffBody,
+				Field:   field,
+				Message: localize("diff.body_skipped", d.skipped),
+			})
+		}
+		return changes
+	}
+
+	change := DiffChange{
+		Kind:    DiffBody,
+		Field:   field,
+		Message: localize("diff.body_size", len(a), len(b)),
+	}
+	if utf8.Valid(a) && utf8.Valid(b) {
+		if len(a) > 0 {
+			change.Before = abbreviate(string(a))
+		}
+		if len(b) > 0 {
+			change.After = abbreviate(string(b))
+		}
+	}
+	return append(changes, change)
+}
+
+// decodeJSONBody 将JSON对象或数组解码为通用值，数字保留为json.Number
+func decodeJSONBody(data []byte) (interface{}, bool) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, false
+	}
+	return value, true
+}
+
+// jsonDiff 收集两个JSON值之间的结构化差异
+type jsonDiff struct {
+	field   string
+	ignore  []string
+	limit   int
+	changes []DiffChange
+	skipped int
+}
+
+func (d *jsonDiff) compare(path string, a, b interface{}) {
+	if d.ignored(path) {
+		return
+	}
+
+	switch va := a.(type) {
+	case map[string]interface{}:
+		if vb, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(va)+len(vb))
+			for key := range va {
+				keys = append(keys, key)
+			}
+			for key := range vb {
+				if _, ok := va[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				d.compareChild(path+jsonPathKey(key), va, vb, key)
+			}
+			return
+		}
+	case []interface{}:
+		if vb, ok := b.([]interface{}); ok {
+			for i := 0; i < len(va) || i < len(vb); i++ {
+				child := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(va):
+					d.add(child, nil, vb[i], "diff.element_added", jsonText(vb[i]))
+				case i >= len(vb):
+					d.add(child, va[i], nil, "diff.element_removed", jsonText(va[i]))
+				default:
+					d.compare(child, va[i], vb[i])
+				}
+			}
+			return
+		}
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return
+	}
+	if typeA, typeB := jsonTypeName(a), jsonTypeName(b); typeA != typeB {
+		d.add(path, a, b, "diff.type_changed", typeA, typeB)
+		return
+	}
+	d.add(path, a, b, "diff.value_changed", jsonText(a), jsonText(b))
+}
+
+func (d *jsonDiff) compareChild(path string, a, b map[string]interface{}, key string) {
+	before, okA := a[key]
+	after, okB := b[key]
+	switch {
+	case !okA:
+		if !d.ignored(path) {
+			d.add(path, nil, after, "diff.field_added", jsonText(after))
+		}
+	case !okB:
+		if !d.ignored(path) {
+			d.add(path, before, nil, "diff.field_removed", jsonText(before))
+		}
+	default:
+		d.compare(path, before, after)
+	}
+}
+
+func (d *jsonDiff) add(path string, before, after interface{}, key string, params ...interface{}) {
+	if d.limit > 0 && len(d.changes) >= d.limit {
+		d.skipped++
+		return
+	}
+	d.changes = append(d.changes, DiffChange{
+		Kind:     DiffBody,
+		Field:    d.field,
+		JSONPath: path,
+		Before:   before,
+		After:    after,
+		Message:  localize(key, params...),
+	})
+}
+
+// ignored 判断路径是否为被忽略的路径或其子路径
+func (d *jsonDiff) ignored(path string) bool {
+	for _, p := range d.ignore {
+		if path == p || strings.HasPrefix(path, p+".") || strings.HasPrefix(path, p+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonPathKey 返回对象键在JSONPath中的写法
+func jsonPathKey(key string) string {
+	simple := key != ""
+	for i, c := range key {
+		if !(c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		return "." + key
+	}
+	return "['" + strings.ReplaceAll(strings.ReplaceAll(key, `\`, `\\`), "'", `\'`) + "']"
+}
+
+// jsonTypeName 返回JSON值的类型名称
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// jsonText 返回JSON值的紧凑文本，过长时截断
+func jsonText(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return abbreviate(string(data))
+}
+
+// abbreviate 将文本截断到diffValueLimit个字符
+func abbreviate(s string) string {
+	if utf8.RuneCountInString(s) <= diffValueLimit {
+		return s
+	}
+	return string([]rune(s)[:diffValueLimit]) + "..."
+}
+
+// diffSize 响应大小增长超过容差时报告
+func (o DiffOptions) diffSize(changes []DiffChange, a, b *Entries) []DiffChange {
+	if o.SizeTolerance < 0 {
+		return changes
+	}
+	before, after := a.Response.Content.Size, b.Response.Content.Size
+	if before < 0 || after <= before {
+		return changes
+	}
+	change := DiffChange{Kind: DiffSize, Field: "response.content.size", Before: before, After: after}
+	if before == 0 {
+		change.Message = localize("diff.size_from_zero", after)
+		return append(changes, change)
+	}
+	growth := float64(after-before) / float64(before)
+	if growth <= o.SizeTolerance {
+		return changes
+	}
+	change.Message = localize("diff.size_growth", before, after, growth*100)
+	return append(changes, change)
+}
+
+// diffTimings 总耗时或各计时阶段的增长超过容差时报告，不可用的计时(-1)不参与比较
+func (o DiffOptions) diffTimings(changes []DiffChange, a, b *Entries) []DiffChange {
+	if o.TimeTolerance < 0 {
+		return changes
+	}
+	timings := []struct {
+		field         string
+		before, after float64
+	}{
+		{"time", a.Time, b.Time},
+		{"timings.blocked", a.Timings.Blocked, b.Timings.Blocked},
+		{"timings.dns", a.Timings.DNS, b.Timings.DNS},
+		{"timings.connect", a.Timings.Connect, b.Timings.Connect},
+		{"timings.ssl", a.Timings.Ssl, b.Timings.Ssl},
+		{"timings.send", a.Timings.Send, b.Timings.Send},
+		{"timings.wait", a.Timings.Wait, b.Timings.Wait},
+		{"timings.receive", a.Timings.Receive, b.Timings.Receive},
+	}
+	for _, t := range timings {
+		if t.before < 0 || t.after < 0 {
+			continue
+		}
+		delta := t.after - t.before
+		if delta <= o.MinTimeDelta || (t.before > 0 && delta <= t.before*o.TimeTolerance) {
+			continue
+		}
+		change := DiffChange{Kind: DiffTiming, Field: t.field, Before: t.before, After: t.after}
+		if t.before > 0 {
+			change.Message = localize("diff.time_growth", t.before, t.after, delta/t.before*100)
+		} else {
+			change.Message = localize("diff.time_from_zero", t.before, t.after)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -0,0 +1,190 @@
+package har
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLTemplate(t *testing.T) {
+	assert.Equal(t, "https://api.example.com/users/{id}/orders?page&sort",
+		URLTemplate("https://API.example.com/users/42/orders?sort=asc&page=2#top"))
+	assert.Equal(t, "https://x.com/items/{id}/{id}",
+		URLTemplate("https://x.com/items/123e4567-e89b-12d3-a456-426614174000/5f2b9c8d7e6a4b3c"))
+	assert.Equal(t, "https://x.com/v2/cafe", URLTemplate("https://x.com/v2/cafe"))
+}
+
+func TestDiff(t *testing.T) {
+	before := NewHar()
+	a := before.AddEntry("GET", "https://x.com/api/users/1", "HTTP/1.1", "")
+	a.Response.Status = 200
+	a.AddResponseHeader("Cache-Control", "max-age=60")
+	a.AddResponseHeader("Date", "Mon, 01 Jan 2024 00:00:00 GMT")
+	a.Response.Content.Text = `{"id": 1, "name": "a", "tags": ["x"], "updatedAt": "t1"}`
+	a.Response.Content.Size = 100
+	a.Time = 100
+	before.AddEntry("GET", "https://x.com/static/app.js", "HTTP/1.1", "")
+	before.AddEntry("GET", "https://x.com/old", "HTTP/1.1", "")
+
+	after := NewHar()
+	after.AddEntry("GET", "https://x.com/static/app.js", "HTTP/1.1", "")
+	b := after.AddEntry("GET", "https://x.com/api/users/2", "HTTP/1.1", "")
+	b.Response.Status = 500
+	b.AddResponseHeader("cache-control", "no-cache")
+	b.AddResponseHeader("Date", "Tue, 02 Jan 2024 00:00:00 GMT")
+	b.Response.Content.Text = `{"id": 2, "name": "a", "tags": ["x", "y"], "updatedAt": "t2"}`
+	b.Response.Content.Size = 200
+	b.Time = 300
+	after.AddEntry("POST", "https://x.com/new", "HTTP/1.1", "")
+
+	options := DefaultDiffOptions()
+	options.IgnoreJSONPaths = []string{"$.updatedAt"}
+	report := Diff(before, after, options)
+
+	assert.True(t, report.HasChanges())
+	assert.Equal(t, 1, report.Unchanged)
+	if assert.Len(t, report.Added, 1) {
+		assert.Equal(t, "https://x.com/new", report.Added[0].URL)
+		assert.Equal(t, -1, report.Added[0].IndexA)
+	}
+	if assert.Len(t, report.Removed, 1) {
+		assert.Equal(t, "GET https://x.com/old", report.Removed[0].Key)
+	}
+	if !assert.Len(t, report.Changed, 1) {
+		return
+	}
+	changed := report.Changed[0]
+	assert.Equal(t, 0, changed.IndexA)
+	assert.Equal(t, 1, changed.IndexB)
+
+	status := report.ByKind(DiffStatus)
+	if assert.Len(t, status, 1) {
+		assert.Equal(t, 200, status[0].Changes[0].Before)
+		assert.Equal(t, 500, status[0].Changes[0].After)
+	}
+
+	headers := report.ByKind(DiffHeader)
+	if assert.Len(t, headers, 1) && assert.Len(t, headers[0].Changes, 1) {
+		assert.Equal(t, "response.headers.cache-control", headers[0].Changes[0].Field)
+	}
+
+	var paths []string
+	for _, c := range report.ByKind(DiffBody)[0].Changes {
+		paths = append(paths, c.JSONPath)
+	}
+	assert.Equal(t, []string{"$.id", "$.tags[1]"}, paths)
+
+	assert.Len(t, report.ByKind(DiffSize), 1)
+	timing := report.ByKind(DiffTiming)
+	if assert.Len(t, timing, 1) {
+		assert.Equal(t, "time", timing[0].Changes[0].Field)
+	}
+}
+
+func TestDiffMatching(t *testing.T) {
+	before := NewHar()
+	before.AddEntry("GET", "https://x.com/a?v=1", "HTTP/1.1", "")
+	before.AddEntry("GET", "https://x.com/b", "HTTP/1.1", "")
+	before.AddEntry("GET", "https://x.com/c", "HTTP/1.1", "")
+
+	after := NewHar()
+	after.AddEntry("GET", "https://x.com/c", "HTTP/1.1", "")
+	after.AddEntry("GET", "https://x.com/b", "HTTP/1.1", "")
+	after.AddEntry("GET", "https://x.com/a?v=2&cb=3", "HTTP/1.1", "")
+
+	options := DefaultDiffOptions()
+	report := Diff(before, after, options)
+	assert.Len(t, report.Added, 1, "查询参数名称不同")
+	assert.Equal(t, 2, report.Unchanged, "顺序变化不影响配对")
+
+	options.IgnoreQuery = true
+	report = Diff(before, after, options)
+	assert.False(t, report.HasChanges())
+
+	// 位置相差超过容差时不配对
+	options.OrderTolerance = 1
+	report = Diff(before, after, options)
+	assert.Len(t, report.Added, 2)
+	assert.Len(t, report.Removed, 2)
+	assert.Equal(t, 1, report.Unchanged)
+
+	options = DefaultDiffOptions()
+	options.KeyFunc = func(entry *Entries) string { return entry.Request.Method }
+	report = Diff(before, after, options)
+	assert.Equal(t, 3, report.Unchanged+len(report.Changed))
+	assert.Empty(t, report.Added)
+}
+
+func TestDiffRender(t *testing.T) {
+	before := NewHar()
+	before.AddEntry("GET", "https://x.com/a", "HTTP/1.1", "").Response.Status = 200
+	after := NewHar()
+	e := after.AddEntry("GET", "https://x.com/a", "HTTP/1.1", "")
+	e.Response.Status = 404
+	after.AddEntry("GET", "https://x.com/b?q=<script>", "HTTP/1.1", "")
+	report := Diff(before, after, DefaultDiffOptions())
+
+	text, err := report.Render(DiffFormatText)
+	assert.NoError(t, err)
+	assert.Contains(t, text, "+ GET https://x.com/b")
+	assert.Contains(t, text, "~ GET https://x.com/a\n    status response.status: 状态码由200变为404")
+
+	data, err := report.Render(DiffFormatJSON)
+	assert.NoError(t, err)
+	var decoded DiffReport
+	assert.NoError(t, json.Unmarshal([]byte(data), &decoded))
+	assert.Len(t, decoded.Changed, 1)
+	assert.Equal(t, DiffStatus, decoded.Changed[0].Changes[0].Kind)
+	assert.True(t, strings.Contains(data, `"removed": []`))
+
+	html, err := report.Render(DiffFormatHTML)
+	assert.NoError(t, err)
+	assert.Contains(t, html, `<tr class="added">`)
+	assert.Contains(t, html, "q=&lt;script&gt;")
+	assert.NotContains(t, html, "<script>")
+
+	_, err = report.Render("csv")
+	assert.Error(t, err)
+}
+
+func TestDiffRenderLanguage(t *testing.T) {
+	assert.NoError(t, SetLanguage(LanguageEnglish))
+	defer SetLanguage(LanguageChinese)
+
+	before := NewHar()
+	a := before.AddEntry("GET", "https://x.com/a", "HTTP/1.1", "")
+	a.Response.Status = 200
+	a.AddResponseHeader("Cache-Control", "max-age=60")
+	a.Response.Content.Text = `{"id": 1, "tags": ["x"], "old": true}`
+	a.Response.Content.Size = 100
+	a.Time = 100
+	before.AddEntry("GET", "https://x.com/old", "HTTP/1.1", "")
+	after := NewHar()
+	b := after.AddEntry("GET", "https://x.com/a", "HTTP/1.1", "")
+	b.Response.Status = 404
+	b.AddResponseHeader("ETag", "v2")
+	b.Response.Content.Text = `{"id": "1", "tags": ["x", "y"], "new": 1}`
+	b.Response.Content.Size = 200
+	b.Time = 300
+	after.AddEntry("GET", "https://x.com/new", "HTTP/1.1", "")
+	report := Diff(before, after, DefaultDiffOptions())
+
+	text, err := report.Render(DiffFormatText)
+	assert.NoError(t, err)
+	assert.Contains(t, text, "HAR diff: 1 requests added, 1 removed, 1 changed, 0 unchanged")
+	assert.Contains(t, text, "status response.status: status changed from 200 to 404")
+	assert.Contains(t, text, "response.content.text $.id: type changed from number to string")
+	html, err := report.Render(DiffFormatHTML)
+	assert.NoError(t, err)
+	assert.Contains(t, html, "<th>Change</th><th>Method</th><th>URL</th><th>Differences</th>")
+	for _, output := range []string{text, html} {
+		assert.NotRegexp(t, `\p{Han}`, output)
+	}
+
+	_, err = report.Render("csv")
+	if assert.Error(t, err) {
+		assert.Equal(t, "unsupported diff output format: csv", err.(*HarError).Message)
+	}
+}
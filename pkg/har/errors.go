@@ -2,6 +2,7 @@ package har
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -28,12 +29,98 @@ const (
 	ErrCodeUnsupported
 )
 
+// ErrCodeCustom 自定义错误代码的起始值，自定义校验规则应使用不小于此值的代码
+const ErrCodeCustom ErrorCode = 1000
+
+// errorCodeNames 错误代码的名称，用于String
+var errorCodeNames = map[ErrorCode]string{
+	ErrCodeUnknown:       "unknown",
+	ErrCodeFileSystem:    "filesystem",
+	ErrCodeJSONParse:     "json_parse",
+	ErrCodeInvalidFormat: "invalid_format",
+	ErrCodeValidation:    "validation",
+	ErrCodeMissingField:  "missing_field",
+	ErrCodeInvalidValue:  "invalid_value",
+	ErrCodeUnsupported:   "unsupported",
+}
+
+// String 返回错误代码的稳定名称，如 "json_parse"，自定义代码为 "custom(1001)"
+func (c ErrorCode) String() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return name
+	}
+	if c >= ErrCodeCustom {
+		return fmt.Sprintf("custom(%d)", int(c))
+	}
+	return fmt.Sprintf("ErrorCode(%d)", int(c))
+}
+
+// ParseErrorCode 将String返回的名称转换为错误代码
+func ParseErrorCode(name string) (ErrorCode, bool) {
+	for code, codeName := range errorCodeNames {
+		if codeName == name {
+			return code, true
+		}
+	}
+	var n int
+	if _, err := fmt.Sscanf(name, "custom(%d)", &n); err == nil && ErrorCode(n) >= ErrCodeCustom {
+		return ErrorCode(n), true
+	}
+	if _, err := fmt.Sscanf(name, "ErrorCode(%d)", &n); err == nil {
+		return ErrorCode(n), true
+	}
+	return ErrCodeUnknown, false
+}
+
+// Error 使错误代码可以作为errors.Is的目标，匹配该代码的所有HarError
+//
+// 示例:
+//
+//	if errors.Is(err, har.ErrCodeMissingField) {
+//	    // 错误本身或任意部分错误缺少必要字段
+//	}
+func (c ErrorCode) Error() string {
+	if name, ok := errorCodeNames[c]; ok {
+		return localize("code." + name)
+	}
+	if c >= ErrCodeCustom {
+		return localize("code.custom", int(c))
+	}
+	return localize("code.undefined", int(c))
+}
+
+// 错误分类
+//
+// 本包返回的错误都是*HarError，或包装了*HarError的错误，调用方可以不依赖错误信息文本区分错误:
+//   - errors.Is(err, ErrInvalidHar): 输入不是有效的HAR(JSON解析、格式、验证、缺少字段、字段值无效)
+//   - errors.Is(err, ErrNotJsonContent): 输入不是JSON或存在JSON语法错误
+//   - errors.Is(err, ErrInvalidURL): 条目中的URL无效
+//   - errors.Is(err, ErrCodeXxx): 错误代码为指定值
+//   - errors.Is(err, fs.ErrNotExist)等: 匹配HarError包装的原始错误
+//   - errors.As(err, &harErr): 获取*HarError以读取字段路径、位置等元数据
+//   - errors.As(err, &syntaxErr): 获取原始的*json.SyntaxError等错误
+//
+// 以上判断对根错误、包装的原始错误和所有部分错误生效。
+
+// invalidHarCodes 属于ErrInvalidHar的错误代码
+var invalidHarCodes = []ErrorCode{
+	ErrCodeJSONParse,
+	ErrCodeInvalidFormat,
+	ErrCodeValidation,
+	ErrCodeMissingField,
+	ErrCodeInvalidValue,
+}
+
 // HarError 自定义HAR错误类型
 type HarError struct {
 	// 错误代码
 	Code ErrorCode
 	// 错误信息
 	Message string
+	// 消息目录中的键，为空表示Message不可本地化，如 "parse.entry"
+	MessageKey string
+	// 格式化消息时使用的参数
+	Params []interface{}
 	// 原始错误（可选）
 	Err error
 	// 字段路径，用点号分隔，如 "log.entries[0].request.url"
@@ -42,13 +129,16 @@ type HarError struct {
 	Metadata map[string]interface{}
 	// 如果是部分解析错误，包含的其他错误
 	PartialErrors []*HarError
+
+	// lang 格式化Error()时使用的语言，为空时使用当前默认语言
+	lang Language
 }
 
 // 实现error接口
 func (e *HarError) Error() string {
 	msg := e.Message
 	if e.Field != "" {
-		msg = fmt.Sprintf("字段 '%s': %s", e.Field, msg)
+		msg = Message(e.lang, "error.field", e.Field, msg)
 	}
 
 	if e.Err != nil {
@@ -60,12 +150,67 @@ func (e *HarError) Error() string {
 		for _, pe := range e.PartialErrors {
 			partialMsgs = append(partialMsgs, pe.Error())
 		}
-		msg = fmt.Sprintf("%s (部分错误: %s)", msg, strings.Join(partialMsgs, "; "))
+		msg = Message(e.lang, "error.partial", msg, strings.Join(partialMsgs, "; "))
 	}
 
 	return msg
 }
 
+// Unwrap 返回包装的原始错误，使errors.Is和errors.As可以匹配如fs.ErrNotExist、*json.SyntaxError等错误
+func (e *HarError) Unwrap() error {
+	return e.Err
+}
+
+// Is 判断错误是否匹配target，target可以是ErrorCode或ErrInvalidHar等哨兵错误，
+// 部分错误中任意一个匹配即视为匹配
+func (e *HarError) Is(target error) bool {
+	switch t := target.(type) {
+	case ErrorCode:
+		if e.Code == t {
+			return true
+		}
+	case *HarError:
+		if e.matchesSentinel(t) {
+			return true
+		}
+	}
+	for _, pe := range e.PartialErrors {
+		if errors.Is(pe, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSentinel 判断错误本身是否属于哨兵错误表示的分类
+func (e *HarError) matchesSentinel(sentinel *HarError) bool {
+	switch sentinel {
+	case ErrInvalidHar:
+		for _, code := range invalidHarCodes {
+			if e.Code == code {
+				return true
+			}
+		}
+	case ErrInvalidURL:
+		return (e.Code == ErrCodeValidation || e.Code == ErrCodeInvalidValue) &&
+			(e.Field == "url" || strings.HasSuffix(e.Field, ".url"))
+	case ErrNotJsonContent:
+		var syntaxErr *json.SyntaxError
+		return (e.Code == sentinel.Code && e.MessageKey == sentinel.MessageKey) || errors.As(e.Err, &syntaxErr)
+	}
+	return false
+}
+
+// As 在部分错误中查找第一个可以赋值给target的错误
+func (e *HarError) As(target interface{}) bool {
+	for _, pe := range e.PartialErrors {
+		if errors.As(pe, target) {
+			return true
+		}
+	}
+	return false
+}
+
 // WithField 添加字段路径到错误
 func (e *HarError) WithField(field string) *HarError {
 	if e.Field == "" {
@@ -85,6 +230,101 @@ func (e *HarError) WithMetadata(key string, value interface{}) *HarError {
 	return e
 }
 
+// Localize 返回使用指定语言的错误副本，部分错误同样被转换，没有MessageKey的错误保留原信息
+//
+// 示例:
+//
+//	var harErr *har.HarError
+//	if errors.As(err, &harErr) {
+//	    fmt.Println(harErr.Localize(har.LanguageEnglish))
+//	}
+func (e *HarError) Localize(lang Language) *HarError {
+	if e == nil {
+		return nil
+	}
+	localized := *e
+	localized.lang = lang
+	if e.MessageKey != "" {
+		localized.Message = Message(lang, e.MessageKey, e.Params...)
+	}
+	if e.PartialErrors != nil {
+		localized.PartialErrors = make([]*HarError, len(e.PartialErrors))
+		for i, pe := range e.PartialErrors {
+			localized.PartialErrors[i] = pe.Localize(lang)
+		}
+	}
+	return &localized
+}
+
+// localizeError 将HarError转换为指定语言，lang为空或err不是HarError时原样返回
+func localizeError(err error, lang Language) error {
+	if harErr, ok := err.(*HarError); ok && lang != "" {
+		return harErr.Localize(lang)
+	}
+	return err
+}
+
+// harErrorJSON HarError的JSON表示
+type harErrorJSON struct {
+	Code          string                 `json:"code"`
+	Message       string                 `json:"message"`
+	MessageKey    string                 `json:"messageKey,omitempty"`
+	Params        []interface{}          `json:"params,omitempty"`
+	Field         string                 `json:"field,omitempty"`
+	Cause         string                 `json:"cause,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	PartialErrors []*HarError            `json:"partialErrors,omitempty"`
+}
+
+// MarshalJSON 将错误序列化为适合API响应的JSON
+//
+// code为错误代码的名称(如 "missing_field")，messageKey和params可用于客户端自行本地化，
+// cause为原始错误的信息，partialErrors递归使用相同的格式:
+//
+//	{"code":"validation","message":"HAR验证失败","messageKey":"validation.failed",
+//	 "partialErrors":[{"code":"missing_field","message":"必需字段缺失",
+//	 "messageKey":"field.missing","field":"log.version","metadata":{"ruleID":"log-version-required"}}]}
+func (e *HarError) MarshalJSON() ([]byte, error) {
+	out := harErrorJSON{
+		Code:          e.Code.String(),
+		Message:       e.Message,
+		MessageKey:    e.MessageKey,
+		Params:        e.Params,
+		Field:         e.Field,
+		Metadata:      e.Metadata,
+		PartialErrors: e.PartialErrors,
+	}
+	if e.Err != nil {
+		out.Cause = e.Err.Error()
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON 从MarshalJSON的输出还原错误，原始错误还原为只包含信息的错误
+func (e *HarError) UnmarshalJSON(data []byte) error {
+	var in harErrorJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	code, ok := ParseErrorCode(in.Code)
+	if !ok {
+		return newInvalidValueError("code", in.Code, "code.unknown_name")
+	}
+	*e = HarError{
+		Code:          code,
+		Message:       in.Message,
+		MessageKey:    in.MessageKey,
+		Params:        in.Params,
+		Field:         in.Field,
+		Metadata:      in.Metadata,
+		PartialErrors: in.PartialErrors,
+	}
+	if in.Cause != "" {
+		e.Err = errors.New(in.Cause)
+	}
+	return nil
+}
+
 // AddPartialError 添加部分解析错误
 func (e *HarError) AddPartialError(err *HarError) *HarError {
 	e.PartialErrors = append(e.PartialErrors, err)
@@ -135,6 +375,19 @@ func NewHarError(code ErrorCode, message string, err error) *HarError {
 	}
 }
 
+// newLocalizedError 使用当前默认语言的消息模板创建错误
+func newLocalizedError(code ErrorCode, key string, err error, params ...interface{}) *HarError {
+	e := NewHarError(code, localize(key, params...), err)
+	e.MessageKey = key
+	e.Params = params
+	return e
+}
+
+// newSentinelError 返回与哨兵错误匹配的新错误，避免调用方修改全局的哨兵错误
+func newSentinelError(sentinel *HarError) *HarError {
+	return newLocalizedError(sentinel.Code, sentinel.MessageKey, nil, sentinel.Params...).WithField(sentinel.Field)
+}
+
 // NewFileSystemError 创建文件系统错误
 func NewFileSystemError(message string, err error) *HarError {
 	return NewHarError(ErrCodeFileSystem, message, err)
@@ -157,30 +410,23 @@ func WrapJSONUnmarshalError(err error) *HarError {
 
 	if e, ok := err.(*json.UnmarshalTypeError); ok {
 		jsonErr = e
-		return NewJSONParseError(
-			fmt.Sprintf("类型不匹配: 预期 %s 类型，但得到 %s",
-				jsonErr.Type.String(), jsonErr.Value),
-			err,
+		return newLocalizedError(ErrCodeJSONParse, "json.type_mismatch", err,
+			jsonErr.Type.String(), jsonErr.Value,
 		).WithField(jsonErr.Field).WithMetadata("offset", jsonErr.Offset)
 	} else if e, ok := err.(*json.SyntaxError); ok {
 		syntaxErr = e
-		return NewJSONParseError(
-			fmt.Sprintf("JSON语法错误: %s", syntaxErr.Error()),
-			err,
-		).WithMetadata("offset", syntaxErr.Offset)
+		return newLocalizedError(ErrCodeJSONParse, "json.syntax", err, syntaxErr.Error()).
+			WithMetadata("offset", syntaxErr.Offset)
 	} else if strings.Contains(err.Error(), "cannot unmarshal") {
 		// 处理其他无法精确识别类型的JSON解析错误
 		parts := strings.Split(err.Error(), ":")
 		if len(parts) >= 2 {
-			return NewJSONParseError(
-				fmt.Sprintf("JSON解析错误: %s", strings.TrimSpace(parts[1])),
-				err,
-			)
+			return newLocalizedError(ErrCodeJSONParse, "json.error_detail", err, strings.TrimSpace(parts[1]))
 		}
 	}
 
 	// 默认JSON错误处理
-	return NewJSONParseError("JSON解析错误", err)
+	return newLocalizedError(ErrCodeJSONParse, "json.error", err)
 }
 
 // NewValidationError 创建验证错误
@@ -195,16 +441,22 @@ func NewInvalidFormatError(message string) *HarError {
 
 // NewMissingFieldError 创建缺少字段错误
 func NewMissingFieldError(field string) *HarError {
-	return NewHarError(ErrCodeMissingField, "必需字段缺失", nil).WithField(field)
+	return newLocalizedError(ErrCodeMissingField, "field.missing", nil).WithField(field)
 }
 
 // NewInvalidValueError 创建字段值无效错误
 func NewInvalidValueError(field string, value interface{}, reason string) *HarError {
-	msg := "字段值无效"
+	e := newLocalizedError(ErrCodeInvalidValue, "field.invalid", nil)
 	if reason != "" {
-		msg = msg + ": " + reason
+		e = newLocalizedError(ErrCodeInvalidValue, "field.invalid_reason", nil, reason)
 	}
-	return NewHarError(ErrCodeInvalidValue, msg, nil).
+	return e.WithField(field).
+		WithMetadata("value", value)
+}
+
+// newInvalidValueError 创建原因来自消息目录的字段值无效错误，key对应的消息包含完整的说明
+func newInvalidValueError(field string, value interface{}, key string, params ...interface{}) *HarError {
+	return newLocalizedError(ErrCodeInvalidValue, key, nil, params...).
 		WithField(field).
 		WithMetadata("value", value)
 }
@@ -224,6 +476,10 @@ type ParseOptions struct {
 	CollectWarnings bool
 	// 最大允许的警告数量，超过则停止解析
 	MaxWarnings int
+	// 宽松模式下是否从被截断或损坏的JSON中恢复损坏位置之前的内容
+	Recover bool
+	// 返回的错误使用的语言，为空时使用当前默认语言
+	Language Language
 }
 
 // DefaultParseOptions 默认解析选项
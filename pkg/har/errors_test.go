@@ -0,0 +1,64 @@
+package har
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorChain(t *testing.T) {
+	// 包装的原始错误
+	_, err := ParseHarFile(filepath.Join(t.TempDir(), "missing.har"))
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+	assert.True(t, errors.Is(err, ErrCodeFileSystem))
+	assert.False(t, errors.Is(err, ErrInvalidHar))
+
+	// JSON语法错误
+	_, err = ParseHar([]byte(`{"log": {,}}`))
+	var syntaxErr *json.SyntaxError
+	assert.True(t, errors.As(err, &syntaxErr))
+	assert.True(t, errors.Is(err, ErrNotJsonContent))
+	assert.True(t, errors.Is(err, ErrInvalidHar))
+	assert.True(t, errors.Is(err, ErrCodeJSONParse))
+
+	_, err = ParseHar([]byte("not json"))
+	assert.True(t, errors.Is(err, ErrNotJsonContent))
+	assert.NotSame(t, ErrNotJsonContent, err, "不应返回可被修改的全局错误")
+
+	// 部分错误参与匹配
+	h := NewHar()
+	e := h.AddEntry("GET", "http://[::1", "HTTP/1.1", "")
+	e.Request.Method = ""
+	data, _ := json.Marshal(h)
+	_, err = ParseHar(data)
+	assert.True(t, errors.Is(err, ErrInvalidHar))
+	assert.True(t, errors.Is(err, ErrCodeValidation))
+	assert.True(t, errors.Is(err, ErrInvalidURL))
+	assert.False(t, errors.Is(err, ErrCodeUnsupported))
+
+	var harErr *HarError
+	if assert.True(t, errors.As(err, &harErr)) {
+		assert.Equal(t, "HAR验证失败", harErr.Message)
+	}
+
+	// 恢复模式中的截断错误位于部分错误中
+	_, err = ParseHarWithOptions(data[:len(data)/2], ParseOptions{Lenient: true, Recover: true})
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, "missing_field", ErrCodeMissingField.String())
+	assert.Equal(t, "custom(1001)", (ErrCodeCustom + 1).String())
+	assert.Equal(t, "缺少必要字段", ErrCodeMissingField.Error())
+
+	err := NewHarError(ErrCodeCustom+1, "自定义", nil)
+	assert.True(t, errors.Is(err, ErrCodeCustom+1))
+	assert.False(t, errors.Is(err, ErrInvalidHar))
+	assert.True(t, strings.HasPrefix(err.Error(), "自定义"))
+}
@@ -0,0 +1,308 @@
+package har
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fiddlerSessionXML Fiddler会话元数据文件(NN_m.xml)
+type fiddlerSessionXML struct {
+	Timers struct {
+		ClientBeginRequest  string  `xml:"ClientBeginRequest,attr"`
+		ClientDoneRequest   string  `xml:"ClientDoneRequest,attr"`
+		DNSTime             float64 `xml:"DNSTime,attr"`
+		TCPConnectTime      float64 `xml:"TCPConnectTime,attr"`
+		HTTPSHandshakeTime  float64 `xml:"HTTPSHandshakeTime,attr"`
+		FiddlerBeginRequest string  `xml:"FiddlerBeginRequest,attr"`
+		ServerGotRequest    string  `xml:"ServerGotRequest,attr"`
+		ServerBeginResponse string  `xml:"ServerBeginResponse,attr"`
+		ServerDoneResponse  string  `xml:"ServerDoneResponse,attr"`
+	} `xml:"SessionTimers"`
+	PipeInfo struct {
+		Reused bool `xml:"Reused,attr"`
+	} `xml:"PipeInfo"`
+	Flags []struct {
+		Name  string `xml:"N,attr"`
+		Value string `xml:"V,attr"`
+	} `xml:"SessionFlags>SessionFlag"`
+}
+
+// fiddlerSessionFiles 一个会话在归档中的三个文件
+type fiddlerSessionFiles struct {
+	request  *zip.File
+	response *zip.File
+	metadata *zip.File
+}
+
+// ImportFiddlerSAZFile 从Fiddler会话归档(.saz)导入HTTP流量
+//
+// SAZ归档中raw目录下的NN_c.txt、NN_s.txt和NN_m.xml分别保存原始请求、
+// 原始响应和会话元数据。计时取自SessionTimers：dns、connect和ssl使用
+// DNSTime、TCPConnectTime和HTTPSHandshakeTime，复用连接的会话不计入建连时间；
+// send、wait和receive由服务端各阶段的时间点计算。CONNECT隧道会话会被跳过。
+//
+// 示例:
+//
+//	h, err := ImportFiddlerSAZFile("session.saz")
+//	if err != nil {
+//	    log.Fatalf("导入Fiddler会话失败: %v", err)
+//	}
+func ImportFiddlerSAZFile(filePath string) (*Har, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件信息 '%s'", filePath), err)
+	}
+
+	h, err := ImportFiddlerSAZ(file, info.Size())
+	if err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return nil, err
+	}
+	return h, nil
+}
+
+// ImportFiddlerSAZ 从SAZ归档数据导入HTTP流量
+func ImportFiddlerSAZ(r io.ReaderAt, size int64) (*Har, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, NewInvalidFormatError(fmt.Sprintf("不是有效的SAZ归档: %v", err))
+	}
+
+	sessions := make(map[string]*fiddlerSessionFiles)
+	for _, f := range archive.File {
+		name := path.Base(f.Name)
+		if !strings.HasPrefix(f.Name, "raw/") || len(name) < 6 {
+			continue
+		}
+		id, kind, ok := strings.Cut(name, "_")
+		if !ok {
+			continue
+		}
+		s := sessions[id]
+		if s == nil {
+			s = &fiddlerSessionFiles{}
+			sessions[id] = s
+		}
+		switch kind {
+		case "c.txt":
+			s.request = f
+		case "s.txt":
+			s.response = f
+		case "m.xml":
+			s.metadata = f
+		}
+	}
+
+	ids := make([]string, 0, len(sessions))
+	for id, s := range sessions {
+		if s.request != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+
+	h := NewHar()
+	h.SetCreator("go-har fiddler importer", "1.0")
+	for _, id := range ids {
+		entry, ok, err := fiddlerEntry(sessions[id])
+		if err != nil {
+			return nil, err.WithMetadata("session", id)
+		}
+		if ok {
+			h.Log.Entries = append(h.Log.Entries, entry)
+		}
+	}
+	return h, nil
+}
+
+// fiddlerEntry 将一个SAZ会话转换为条目
+func fiddlerEntry(files *fiddlerSessionFiles) (Entries, bool, *HarError) {
+	reqData, err := readZipFile(files.request)
+	if err != nil {
+		return Entries{}, false, err
+	}
+	respData, err := readZipFile(files.response)
+	if err != nil {
+		return Entries{}, false, err
+	}
+
+	var meta fiddlerSessionXML
+	if files.metadata != nil {
+		data, err := readZipFile(files.metadata)
+		if err != nil {
+			return Entries{}, false, err
+		}
+		if xmlErr := xml.Unmarshal(data, &meta); xmlErr != nil {
+			return Entries{}, false, NewInvalidFormatError(fmt.Sprintf("会话元数据解析失败: %v", xmlErr))
+		}
+	}
+
+	flags := make(map[string]string)
+	for _, flag := range meta.Flags {
+		flags[strings.ToLower(flag.Name)] = flag.Value
+	}
+	scheme := "http"
+	for name := range flags {
+		if strings.HasPrefix(name, "https-") {
+			scheme = "https"
+			break
+		}
+	}
+
+	if bytes.HasPrefix(reqData, []byte("CONNECT ")) {
+		return Entries{}, false, nil
+	}
+	entry, ok := rawHTTPEntry(reqData, respData, scheme, 0)
+	if !ok {
+		return Entries{}, false, nil
+	}
+	entry.ServerIPAddress = flags["x-hostip"]
+	if port := flags["x-clientport"]; port != "" {
+		entry.Connection = port
+	}
+
+	t := meta.Timers
+	timings := Timings{Blocked: -1, DNS: -1, Connect: -1, Ssl: -1, Send: 0, Wait: -1, Receive: -1}
+	if !meta.PipeInfo.Reused {
+		timings.DNS = t.DNSTime
+		timings.Connect = t.TCPConnectTime
+		if t.HTTPSHandshakeTime > 0 {
+			timings.Ssl = t.HTTPSHandshakeTime
+			timings.Connect += t.HTTPSHandshakeTime
+		}
+	}
+	beginRequest := fiddlerTime(t.FiddlerBeginRequest)
+	gotRequest := fiddlerTime(t.ServerGotRequest)
+	beginResponse := fiddlerTime(t.ServerBeginResponse)
+	doneResponse := fiddlerTime(t.ServerDoneResponse)
+	if !beginRequest.IsZero() && gotRequest.After(beginRequest) {
+		timings.Send = durationMs(gotRequest.Sub(beginRequest))
+	}
+	if !gotRequest.IsZero() && !beginResponse.IsZero() && !beginResponse.Before(gotRequest) {
+		timings.Wait = durationMs(beginResponse.Sub(gotRequest))
+	}
+	if !beginResponse.IsZero() && !doneResponse.Before(beginResponse) {
+		timings.Receive = durationMs(doneResponse.Sub(beginResponse))
+	}
+
+	entry.StartedDateTime = fiddlerTime(t.ClientBeginRequest)
+	entry.Timings = timings
+	entry.Time = timingsTotal(timings)
+	return entry, true, nil
+}
+
+// readZipFile 读取归档中的文件内容，文件不存在时返回nil
+func readZipFile(f *zip.File) ([]byte, *HarError) {
+	if f == nil {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法打开归档文件 '%s'", f.Name), err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取归档文件 '%s'", f.Name), err)
+	}
+	return data, nil
+}
+
+// fiddlerTime 解析Fiddler的时间，默认值0001-01-01表示未发生
+func fiddlerTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil || t.Year() <= 1 {
+		return time.Time{}
+	}
+	return t
+}
+
+// rawHTTPEntry 解析原始HTTP/1.x请求和响应报文并生成条目，
+// 不包含计时信息。请求行为相对路径时使用scheme和Host头部构造URL。
+func rawHTTPEntry(reqData, respData []byte, scheme string, maxBodySize int) (Entries, bool) {
+	reqReader := newOffsetReader(reqData)
+	req, err := http.ReadRequest(reqReader.br)
+	if err != nil {
+		return Entries{}, false
+	}
+	reqHeaderEnd := headerBlockLength(reqData)
+	reqBody, _ := io.ReadAll(req.Body)
+	req.Body.Close()
+
+	rawURL := req.RequestURI
+	if req.URL == nil || !req.URL.IsAbs() {
+		rawURL = scheme + "://" + req.Host + req.RequestURI
+	}
+
+	entry := Entries{
+		Request: Request{
+			Method:      req.Method,
+			URL:         rawURL,
+			HTTPVersion: req.Proto,
+			Headers:     rawHeaders(reqData[:reqHeaderEnd]),
+			Cookies:     requestCookies(req),
+			QueryString: queryStringFromURL(rawURL),
+			HeadersSize: reqHeaderEnd,
+			BodySize:    len(reqData) - reqHeaderEnd,
+		},
+		Response: Response{
+			HTTPVersion: req.Proto,
+			Headers:     []Headers{},
+			Cookies:     []Cookie{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = map[string]interface{}{
+			"mimeType": req.Header.Get("Content-Type"),
+			"text":     string(reqBody),
+		}
+	}
+
+	if len(respData) == 0 {
+		return entry, true
+	}
+	resp, err := http.ReadResponse(newOffsetReader(respData).br, req)
+	if err != nil {
+		return entry, true
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	respHeaderEnd := headerBlockLength(respData)
+
+	entry.Response.Status = resp.StatusCode
+	entry.Response.StatusText = strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)))
+	entry.Response.HTTPVersion = resp.Proto
+	entry.Response.Headers = rawHeaders(respData[:respHeaderEnd])
+	entry.Response.Cookies = responseCookies(resp)
+	entry.Response.RedirectURL = resp.Header.Get("Location")
+	entry.Response.HeadersSize = respHeaderEnd
+	entry.Response.BodySize = len(respData) - respHeaderEnd
+	entry.Response.TransferSize = len(respData)
+	entry.Response.Content = buildContent(body, resp.Header.Get("Content-Type"),
+		resp.Header.Get("Content-Encoding"), maxBodySize)
+	return entry, true
+}
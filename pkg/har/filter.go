@@ -30,39 +30,179 @@ type FilterOptions struct {
 	RespHeaderName  string    // 响应头名
 	RespHeaderValue string    // 响应头值
 	UseRegex        bool      // 使用正则表达式匹配
+	Predicate       Predicate // 附加的谓词，与其他条件为AND关系
+
+	// 以下字符串条件同样遵循UseRegex和IgnoreCase
+	ResponseBody    string // 响应体包含的字符串，base64编码的响应体会先解码
+	RequestBody     string // 请求体(PostData)包含的字符串
+	QueryParamName  string // 查询参数名
+	QueryParamValue string // 查询参数值
+	CookieName      string // 请求或响应Cookie名
+	CookieValue     string // Cookie值
+	ServerIPAddress string // 服务器IP地址
+	Pageref         string // 所属页面ID
+	Priority        string // 请求优先级
+	InitiatorType   string // 请求发起者类型
+	JSONPath        string // 对JSON响应体的JSONPath条件，例如 $.errors[*].code == "E42"
+	RequestJSONPath string // 对JSON请求体的JSONPath条件
+	IgnoreCase      bool   // 字符串匹配不区分大小写
 }
 
 // Filter 按条件过滤条目
+//
+// 过滤条件只编译一次。无效的正则表达式或JSONPath会被忽略，
+// 需要报告这类错误时请使用CompileFilter。
 func (h *Har) Filter(options FilterOptions) *FilterResult {
-	var result []Entries
+	return compileFilter(options).Filter(h)
+}
 
-	for _, entry := range h.Log.Entries {
-		if matchesFilter(entry, options) {
-			result = append(result, entry)
-		}
+// CompiledFilter 预编译的过滤条件
+//
+// 正则表达式、JSONPath和不区分大小写时的小写模式在编译时准备好，
+// 可以在Har、流式迭代器和OptimizedHar之间重复使用，适合处理大型HAR文件。
+type CompiledFilter struct {
+	options FilterOptions
+
+	url, method, contentType               *textMatcher
+	headerValue, respHeaderValue           *textMatcher
+	responseBody, requestBody              *textMatcher
+	queryParamName, queryParamValue        *textMatcher
+	cookieName, cookieValue                *textMatcher
+	serverIP, pageref, priority, initiator *textMatcher
+	jsonPath, requestJSONPath              *jsonPathExpr
+}
+
+// CompileFilter 校验并编译过滤条件
+//
+// 无效的正则表达式、JSONPath以及相互矛盾的范围(例如StatusCodeMin大于StatusCodeMax)
+// 会返回HarError，其Field为对应的FilterOptions字段名。
+//
+// 示例:
+//
+//	cf, err := CompileFilter(FilterOptions{URL: `/api/v\d+/`, UseRegex: true})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	it := cf.Iterator(streamingHar.Entries())
+func CompileFilter(options FilterOptions) (*CompiledFilter, error) {
+	cf, err := newCompiledFilter(options, true)
+	if err != nil {
+		return nil, err
 	}
+	return cf, nil
+}
 
-	return &FilterResult{
-		Entries: result,
+// MustCompileFilter 与CompileFilter相同，但在条件无效时panic
+func MustCompileFilter(options FilterOptions) *CompiledFilter {
+	cf, err := CompileFilter(options)
+	if err != nil {
+		panic(err)
 	}
+	return cf
 }
 
-// 检查条目是否符合过滤条件
-func matchesFilter(entry Entries, options FilterOptions) bool {
-	// URL过滤
-	if options.URL != "" {
-		if options.UseRegex {
-			re, err := regexp.Compile(options.URL)
-			if err == nil && !re.MatchString(entry.Request.URL) {
-				return false
+// compileFilter 宽松地编译过滤条件，忽略无效的正则表达式和JSONPath
+func compileFilter(options FilterOptions) *CompiledFilter {
+	cf, _ := newCompiledFilter(options, false)
+	return cf
+}
+
+func newCompiledFilter(options FilterOptions, strict bool) (*CompiledFilter, *HarError) {
+	cf := &CompiledFilter{options: options}
+
+	if strict {
+		if options.StatusCodeMin > 0 && options.StatusCodeMax > 0 && options.StatusCodeMin > options.StatusCodeMax {
+			return nil, newInvalidValueError("StatusCodeMin", options.StatusCodeMin, "filter.status_range")
+		}
+		if options.MinDuration > 0 && options.MaxDuration > 0 && options.MinDuration > options.MaxDuration {
+			return nil, newInvalidValueError("MinDuration", options.MinDuration, "filter.duration_range")
+		}
+		if !options.StartTime.IsZero() && !options.EndTime.IsZero() && options.StartTime.After(options.EndTime) {
+			return nil, newInvalidValueError("StartTime", options.StartTime, "filter.time_range")
+		}
+	}
+
+	// 方法、内容类型和头部值不受UseRegex影响，与早期版本保持一致
+	plain := options
+	plain.UseRegex = false
+
+	matchers := []struct {
+		target  **textMatcher
+		field   string
+		pattern string
+		exact   bool
+		options FilterOptions
+	}{
+		{&cf.url, "URL", options.URL, false, options},
+		{&cf.method, "Method", options.Method, true, plain},
+		{&cf.contentType, "ContentType", options.ContentType, false, plain},
+		{&cf.headerValue, "HeaderValue", options.HeaderValue, false, plain},
+		{&cf.respHeaderValue, "RespHeaderValue", options.RespHeaderValue, false, plain},
+		{&cf.responseBody, "ResponseBody", options.ResponseBody, false, options},
+		{&cf.requestBody, "RequestBody", options.RequestBody, false, options},
+		{&cf.queryParamName, "QueryParamName", options.QueryParamName, true, options},
+		{&cf.queryParamValue, "QueryParamValue", options.QueryParamValue, false, options},
+		{&cf.cookieName, "CookieName", options.CookieName, true, options},
+		{&cf.cookieValue, "CookieValue", options.CookieValue, false, options},
+		{&cf.serverIP, "ServerIPAddress", options.ServerIPAddress, true, options},
+		{&cf.pageref, "Pageref", options.Pageref, true, options},
+		{&cf.priority, "Priority", options.Priority, true, options},
+		{&cf.initiator, "InitiatorType", options.InitiatorType, true, options},
+	}
+	for _, m := range matchers {
+		if m.pattern == "" {
+			continue
+		}
+		matcher, err := newTextMatcher(m.pattern, m.exact, m.options)
+		if err != nil {
+			if strict {
+				return nil, newInvalidValueError(m.field, m.pattern, "filter.regexp", err.Error())
 			}
-		} else if !strings.Contains(entry.Request.URL, options.URL) {
-			return false
+			continue
 		}
+		*m.target = matcher
+	}
+
+	jsonPaths := []struct {
+		target **jsonPathExpr
+		field  string
+		expr   string
+	}{
+		{&cf.jsonPath, "JSONPath", options.JSONPath},
+		{&cf.requestJSONPath, "RequestJSONPath", options.RequestJSONPath},
+	}
+	for _, j := range jsonPaths {
+		if j.expr == "" {
+			continue
+		}
+		expr, err := compileJSONPath(j.expr, options.IgnoreCase)
+		if err != nil {
+			if strict {
+				return nil, err.(*HarError).WithField(j.field)
+			}
+			continue
+		}
+		*j.target = expr
+	}
+	return cf, nil
+}
+
+// Options 返回编译时使用的过滤选项
+func (cf *CompiledFilter) Options() FilterOptions {
+	return cf.options
+}
+
+// Match 检查条目是否符合过滤条件
+func (cf *CompiledFilter) Match(entry *Entries) bool {
+	options := cf.options
+
+	// URL过滤
+	if cf.url != nil && !cf.url.match(entry.Request.URL) {
+		return false
 	}
 
 	// 请求方法过滤
-	if options.Method != "" && entry.Request.Method != options.Method {
+	if cf.method != nil && !cf.method.match(entry.Request.Method) {
 		return false
 	}
 
@@ -80,17 +220,8 @@ func matchesFilter(entry Entries, options FilterOptions) bool {
 	}
 
 	// 内容类型过滤
-	if options.ContentType != "" {
-		matched := false
-		for _, header := range entry.Response.Headers {
-			if strings.EqualFold(header.Name, "Content-Type") && strings.Contains(header.Value, options.ContentType) {
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			return false
-		}
+	if cf.contentType != nil && !matchHeader(entry.Response.Headers, "Content-Type", cf.contentType) {
+		return false
 	}
 
 	// 时间范围过滤
@@ -120,40 +251,211 @@ func matchesFilter(entry Entries, options FilterOptions) bool {
 	}
 
 	// 请求头过滤
-	if options.HeaderName != "" {
-		matched := false
-		for _, header := range entry.Request.Headers {
-			if strings.EqualFold(header.Name, options.HeaderName) {
-				if options.HeaderValue == "" || strings.Contains(header.Value, options.HeaderValue) {
-					matched = true
-					break
-				}
-			}
+	if options.HeaderName != "" && !matchHeader(entry.Request.Headers, options.HeaderName, cf.headerValue) {
+		return false
+	}
+
+	// 响应头过滤
+	if options.RespHeaderName != "" && !matchHeader(entry.Response.Headers, options.RespHeaderName, cf.respHeaderValue) {
+		return false
+	}
+
+	// 请求体和响应体过滤
+	if cf.responseBody != nil && !cf.responseBody.match(responseBodyText(entry.Response.Content)) {
+		return false
+	}
+	if cf.requestBody != nil {
+		_, text := postDataText(entry.Request)
+		if !cf.requestBody.match(text) {
+			return false
 		}
-		if !matched {
+	}
+
+	// 查询参数过滤
+	if cf.queryParamName != nil || cf.queryParamValue != nil {
+		if !matchPairs(queryParams(entry.Request), cf.queryParamName, cf.queryParamValue) {
 			return false
 		}
 	}
 
-	// 响应头过滤
-	if options.RespHeaderName != "" {
-		matched := false
-		for _, header := range entry.Response.Headers {
-			if strings.EqualFold(header.Name, options.RespHeaderName) {
-				if options.RespHeaderValue == "" || strings.Contains(header.Value, options.RespHeaderValue) {
-					matched = true
-					break
-				}
-			}
+	// Cookie过滤，请求和响应Cookie任一匹配即可
+	if cf.cookieName != nil || cf.cookieValue != nil {
+		var pairs []Headers
+		for _, c := range entryCookies(*entry) {
+			pairs = append(pairs, Headers{Name: c.Name, Value: c.Value})
 		}
-		if !matched {
+		if !matchPairs(pairs, cf.cookieName, cf.cookieValue) {
 			return false
 		}
 	}
 
+	// 连接和元数据过滤
+	exactFields := []struct {
+		matcher *textMatcher
+		value   string
+	}{
+		{cf.serverIP, entry.ServerIPAddress},
+		{cf.pageref, entry.Pageref},
+		{cf.priority, entry.Priority},
+		{cf.initiator, entry.Initiator.Type},
+	}
+	for _, f := range exactFields {
+		if f.matcher != nil && !f.matcher.match(f.value) {
+			return false
+		}
+	}
+
+	// JSONPath过滤
+	if cf.jsonPath != nil {
+		body, err := decodeContentText(entry.Response.Content)
+		if err != nil || !cf.jsonPath.matchBody(body) {
+			return false
+		}
+	}
+	if cf.requestJSONPath != nil {
+		_, text := postDataText(entry.Request)
+		if !cf.requestJSONPath.matchBody([]byte(text)) {
+			return false
+		}
+	}
+
+	// 谓词过滤
+	if options.Predicate != nil && !options.Predicate(entry) {
+		return false
+	}
+
 	return true
 }
 
+// Predicate 将编译后的过滤条件转换为谓词，便于与其他谓词组合
+func (cf *CompiledFilter) Predicate() Predicate {
+	return cf.Match
+}
+
+// Filter 过滤HAR中的条目
+func (cf *CompiledFilter) Filter(h *Har) *FilterResult {
+	var result []Entries
+
+	for i := range h.Log.Entries {
+		if cf.Match(&h.Log.Entries[i]) {
+			result = append(result, h.Log.Entries[i])
+		}
+	}
+
+	return &FilterResult{
+		Entries: result,
+	}
+}
+
+// Iterator 包装迭代器，只返回符合过滤条件的条目
+func (cf *CompiledFilter) Iterator(it EntryIterator) EntryIterator {
+	return FilterIterator(it, cf.Match)
+}
+
+// Search 返回OptimizedHar中符合过滤条件的条目
+func (cf *CompiledFilter) Search(oh *OptimizedHar) []OptimizedEntries {
+	return oh.Search(cf.Match)
+}
+
+// textMatcher 预编译的文本匹配条件
+type textMatcher struct {
+	re         *regexp.Regexp
+	pattern    string // IgnoreCase时已转换为小写
+	exact      bool
+	ignoreCase bool
+}
+
+// newTextMatcher 按选项创建文本匹配条件。UseRegex时按正则表达式匹配，
+// 否则exact为true时要求完全相等，为false时要求包含
+func newTextMatcher(pattern string, exact bool, options FilterOptions) (*textMatcher, error) {
+	m := &textMatcher{pattern: pattern, exact: exact, ignoreCase: options.IgnoreCase}
+	if options.UseRegex {
+		if options.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.re = re
+		return m, nil
+	}
+	if options.IgnoreCase {
+		m.pattern = strings.ToLower(pattern)
+	}
+	return m, nil
+}
+
+func (m *textMatcher) match(text string) bool {
+	if m.re != nil {
+		return m.re.MatchString(text)
+	}
+	if m.ignoreCase {
+		if m.exact {
+			return strings.EqualFold(text, m.pattern)
+		}
+		text = strings.ToLower(text)
+	}
+	if m.exact {
+		return text == m.pattern
+	}
+	return strings.Contains(text, m.pattern)
+}
+
+// matchHeader 判断是否存在名称匹配(不区分大小写)且值满足条件的头部，value为nil时只比较名称
+func matchHeader(headers []Headers, name string, value *textMatcher) bool {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) && (value == nil || value.match(header.Value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPairs 判断是否存在名称和值都满足条件的键值对，条件为nil时不限制
+func matchPairs(pairs []Headers, name, value *textMatcher) bool {
+	for _, pair := range pairs {
+		if name != nil && !name.match(pair.Name) {
+			continue
+		}
+		if value != nil && !value.match(pair.Value) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// responseBodyText 返回解码后的响应体，解码失败时返回原始文本
+func responseBodyText(content Content) string {
+	body, err := decodeContentText(content)
+	if err != nil {
+		return content.Text
+	}
+	return string(body)
+}
+
+// queryParams 返回请求的查询参数，QueryString为空时从URL解析
+func queryParams(req Request) []Headers {
+	if len(req.QueryString) > 0 {
+		return req.QueryString
+	}
+	return queryStringFromURL(req.URL)
+}
+
+// entryCookies 返回请求和响应的所有Cookie，Cookies为空时从头部解析
+func entryCookies(entry Entries) []Cookie {
+	requestCookies := entry.Request.Cookies
+	if len(requestCookies) == 0 {
+		requestCookies = cookiesFromHeaders(entry.Request.Headers, "cookie")
+	}
+	responseCookies := entry.Response.Cookies
+	if len(responseCookies) == 0 {
+		responseCookies = cookiesFromHeaders(entry.Response.Headers, "set-cookie")
+	}
+	return append(append([]Cookie{}, requestCookies...), responseCookies...)
+}
+
 // 快捷过滤方法
 
 // FindByURL 按URL查找
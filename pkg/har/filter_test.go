@@ -0,0 +1,53 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileFilterErrors(t *testing.T) {
+	tests := []struct {
+		options FilterOptions
+		field   string
+	}{
+		{FilterOptions{URL: "(", UseRegex: true}, "URL"},
+		{FilterOptions{CookieValue: "[a-", UseRegex: true}, "CookieValue"},
+		{FilterOptions{JSONPath: "errors"}, "JSONPath"},
+		{FilterOptions{RequestJSONPath: `$.a =~ "("`}, "RequestJSONPath"},
+		{FilterOptions{StatusCodeMin: 500, StatusCodeMax: 400}, "StatusCodeMin"},
+		{FilterOptions{MinDuration: 10, MaxDuration: 1}, "MinDuration"},
+	}
+	for _, tt := range tests {
+		_, err := CompileFilter(tt.options)
+		if assert.Error(t, err) {
+			harErr, ok := err.(*HarError)
+			assert.True(t, ok)
+			assert.Equal(t, tt.field, harErr.Field)
+		}
+	}
+
+	// 方法和头部值不受UseRegex影响，因此不会报告正则错误
+	_, err := CompileFilter(FilterOptions{Method: "(", HeaderName: "X", HeaderValue: "(", UseRegex: true})
+	assert.NoError(t, err)
+}
+
+func TestCompiledFilterReuse(t *testing.T) {
+	h := newQueryTestHar()
+	cf := MustCompileFilter(FilterOptions{URL: `API\.X\.COM/users`, UseRegex: true, IgnoreCase: true, StatusCodeMin: 400})
+
+	assert.Equal(t, 1, cf.Filter(h).Count())
+	assert.Len(t, cf.Search(ToOptimizedHar(h)), 1)
+
+	it := cf.Iterator(h.Iterator())
+	count := 0
+	for it.Next() {
+		assert.Equal(t, "https://api.x.com/users?id=2", it.Entry().Request.URL)
+		count++
+	}
+	assert.Equal(t, 1, count)
+
+	// Har.Filter仍然忽略无效的正则表达式
+	assert.Equal(t, 4, h.Filter(FilterOptions{URL: "(", UseRegex: true}).Count())
+	assert.Panics(t, func() { MustCompileFilter(FilterOptions{URL: "(", UseRegex: true}) })
+}
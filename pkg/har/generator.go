@@ -152,7 +152,11 @@ func (h *Har) ToJSON(indent bool) ([]byte, error) {
 }
 
 // SaveToFile 将HAR对象保存到文件
+// 扩展名为.ndjson或.jsonl时按NDJSON格式逐行写入，此时忽略indent参数
 func (h *Har) SaveToFile(filePath string, indent bool) error {
+	if IsNDJSONPath(filePath) {
+		return h.SaveNDJSONToFile(filePath)
+	}
 	data, err := h.ToJSON(indent)
 	if err != nil {
 		return err
@@ -2,22 +2,21 @@ package har
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/url"
 	"os"
 	"time"
 )
 
-// 错误定义
+// 错误定义，用作errors.Is的目标，匹配规则见errors.go中的错误分类
 var (
-	// ErrInvalidHar 表示HAR对象缺少必要字段
-	ErrInvalidHar = NewValidationError("HAR对象缺少必要字段", "")
+	// ErrInvalidHar 表示输入不是有效的HAR，匹配JSON解析、格式、验证、缺少字段和字段值无效错误
+	ErrInvalidHar = newLocalizedError(ErrCodeValidation, "har.invalid", nil)
 
-	// ErrInvalidURL 表示HAR条目中的URL无效
-	ErrInvalidURL = NewValidationError("HAR条目中包含无效URL", "")
+	// ErrInvalidURL 表示HAR条目中的URL无效，匹配url字段上的验证错误
+	ErrInvalidURL = newLocalizedError(ErrCodeValidation, "har.invalid_url", nil)
 
-	// ErrNotJsonContent 表示内容不是JSON格式
-	ErrNotJsonContent = NewInvalidFormatError("内容不是JSON格式")
+	// ErrNotJsonContent 表示内容不是JSON格式，同时匹配JSON语法错误
+	ErrNotJsonContent = newLocalizedError(ErrCodeInvalidFormat, "input.not_json", nil)
 )
 
 // ParseHarFile 解析HAR格式的文件
@@ -34,7 +33,7 @@ var (
 func ParseHarFile(harFilePath string) (*Har, error) {
 	harFileBytes, err := os.ReadFile(harFilePath)
 	if err != nil {
-		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", harFilePath), err)
+		return nil, newLocalizedError(ErrCodeFileSystem, "file.read", err, harFilePath)
 	}
 	return ParseHar(harFileBytes)
 }
@@ -43,6 +42,7 @@ func ParseHarFile(harFilePath string) (*Har, error) {
 //
 // ParseHar函数将HAR格式的字节数据解析为Har结构体对象。
 // 该函数会进行完整的验证，确保HAR对象满足规范要求。
+// 解析和验证错误的Metadata中包含出错位置的行号、列号和片段，可以使用FormatError输出。
 //
 // 示例:
 //
@@ -54,23 +54,26 @@ func ParseHarFile(harFilePath string) (*Har, error) {
 func ParseHar(harFileBytes []byte) (*Har, error) {
 	// 检查输入是否为空
 	if len(harFileBytes) == 0 {
-		return nil, NewInvalidFormatError("输入为空")
+		return nil, newLocalizedError(ErrCodeInvalidFormat, "input.empty", nil)
 	}
 
 	// 检查是否是JSON格式
 	if !isJSONContent(harFileBytes) {
-		return nil, ErrNotJsonContent
+		return nil, newSentinelError(ErrNotJsonContent)
 	}
 
 	// 解析JSON
 	har := new(Har)
 	err := json.Unmarshal(harFileBytes, har)
 	if err != nil {
-		return nil, WrapJSONUnmarshalError(err)
+		return nil, wrapJSONError(harFileBytes, err)
 	}
 
-	// 验证HAR对象
+	// 验证HAR对象，并将错误定位到原始输入中
 	if err := ValidateHarFile(har); err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			return nil, newPositionLocator(harFileBytes).annotate(harErr)
+		}
 		return nil, err
 	}
 
@@ -0,0 +1,343 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep JSONPath中的一个路径步骤
+type jsonPathStep struct {
+	name      string // 对象成员名，为空时配合wildcard或index使用
+	index     int    // 数组下标，负数表示从末尾计数
+	isIndex   bool
+	wildcard  bool
+	recursive bool // 以..开头，匹配任意深度的后代
+}
+
+// jsonPathExpr 已解析的JSONPath条件，op为空时只要求路径存在
+type jsonPathExpr struct {
+	source     string
+	steps      []jsonPathStep
+	op         string
+	value      interface{}
+	re         *regexp.Regexp
+	ignoreCase bool
+}
+
+// jsonPathOperators 支持的比较运算符，较长的运算符在前
+var jsonPathOperators = []string{"==", "!=", "=~", "<=", ">=", "<", ">", "="}
+
+// compileJSONPath 解析JSONPath条件
+//
+// 路径支持 $、.name、['name']、[n]、[*]、.* 和递归下降 ..name，
+// 条件部分支持 == != =~ < <= > >=，右侧为JSON字面量或单引号字符串，
+// 省略条件时只要求路径存在。路径选中多个值时任一值满足即可。
+func compileJSONPath(expr string, ignoreCase bool) (*jsonPathExpr, error) {
+	src := strings.TrimSpace(expr)
+	invalid := func(reason string) error {
+		return NewInvalidFormatError(fmt.Sprintf("无效的JSONPath表达式 '%s': %s", expr, reason))
+	}
+	if !strings.HasPrefix(src, "$") {
+		return nil, invalid("必须以$开头")
+	}
+
+	e := &jsonPathExpr{source: expr, ignoreCase: ignoreCase}
+	i := 1
+	for i < len(src) {
+		c := src[i]
+		if c == ' ' || c == '\t' || strings.ContainsRune("=!<>", rune(c)) {
+			break
+		}
+		step := jsonPathStep{}
+		switch c {
+		case '.':
+			i++
+			if i < len(src) && src[i] == '.' {
+				step.recursive = true
+				i++
+			}
+			if i < len(src) && src[i] == '[' {
+				// ..[n] 或 ..['name']，交给下一轮按括号解析
+				if !step.recursive {
+					return nil, invalid("'.'后不能直接跟'['")
+				}
+				bracket, n, err := parseJSONPathBracket(src[i:])
+				if err != nil {
+					return nil, invalid(err.Error())
+				}
+				bracket.recursive = true
+				e.steps = append(e.steps, bracket)
+				i += n
+				continue
+			}
+			start := i
+			for i < len(src) && (isJSONPathNameChar(src[i]) || src[i] == '*') {
+				i++
+			}
+			name := src[start:i]
+			switch {
+			case name == "*":
+				step.wildcard = true
+			case name == "" || strings.Contains(name, "*"):
+				return nil, invalid(fmt.Sprintf("位置%d处缺少成员名", start))
+			default:
+				step.name = name
+			}
+		case '[':
+			bracket, n, err := parseJSONPathBracket(src[i:])
+			if err != nil {
+				return nil, invalid(err.Error())
+			}
+			step = bracket
+			i += n
+		default:
+			return nil, invalid(fmt.Sprintf("位置%d处的字符'%c'无法识别", i, c))
+		}
+		e.steps = append(e.steps, step)
+	}
+
+	rest := strings.TrimSpace(src[i:])
+	if rest == "" {
+		return e, nil
+	}
+	for _, op := range jsonPathOperators {
+		if strings.HasPrefix(rest, op) {
+			e.op = op
+			break
+		}
+	}
+	if e.op == "" {
+		return nil, invalid(fmt.Sprintf("无法识别的运算符 '%s'", rest))
+	}
+	if e.op == "=" {
+		e.op = "=="
+		rest = rest[1:]
+	} else {
+		rest = rest[len(e.op):]
+	}
+
+	literal := strings.TrimSpace(rest)
+	if literal == "" {
+		return nil, invalid("缺少比较值")
+	}
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		e.value = literal[1 : len(literal)-1]
+	} else if err := json.Unmarshal([]byte(literal), &e.value); err != nil {
+		return nil, invalid(fmt.Sprintf("比较值 '%s' 不是有效的JSON字面量", literal))
+	}
+
+	if e.op == "=~" {
+		pattern, ok := e.value.(string)
+		if !ok {
+			return nil, invalid("=~ 的右侧必须是字符串")
+		}
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, invalid(fmt.Sprintf("无效的正则表达式: %v", err))
+		}
+		e.re = re
+	}
+	return e, nil
+}
+
+// parseJSONPathBracket 解析以'['开头的步骤，返回消耗的字节数
+func parseJSONPathBracket(s string) (jsonPathStep, int, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return jsonPathStep{}, 0, fmt.Errorf("缺少 ']'")
+	}
+	inner := strings.TrimSpace(s[1:end])
+	step := jsonPathStep{}
+	switch {
+	case inner == "*":
+		step.wildcard = true
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		step.name = inner[1 : len(inner)-1]
+	default:
+		n, err := strconv.Atoi(inner)
+		if err != nil {
+			return jsonPathStep{}, 0, fmt.Errorf("无效的下标 '%s'", inner)
+		}
+		step.index = n
+		step.isIndex = true
+	}
+	return step, end + 1, nil
+}
+
+func isJSONPathNameChar(c byte) bool {
+	return c == '_' || c == '-' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// selectJSONPath 返回路径选中的所有值
+func (e *jsonPathExpr) selectJSONPath(doc interface{}) []interface{} {
+	current := []interface{}{doc}
+	for _, step := range e.steps {
+		var candidates []interface{}
+		if step.recursive {
+			for _, v := range current {
+				candidates = appendJSONDescendants(candidates, v)
+			}
+		} else {
+			candidates = current
+		}
+
+		var next []interface{}
+		for _, v := range candidates {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				if step.wildcard {
+					for _, child := range node {
+						next = append(next, child)
+					}
+				} else if !step.isIndex {
+					if child, ok := node[step.name]; ok {
+						next = append(next, child)
+					}
+				}
+			case []interface{}:
+				if step.wildcard {
+					next = append(next, node...)
+				} else if step.isIndex {
+					idx := step.index
+					if idx < 0 {
+						idx += len(node)
+					}
+					if idx >= 0 && idx < len(node) {
+						next = append(next, node[idx])
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// appendJSONDescendants 追加节点自身及其所有后代
+func appendJSONDescendants(out []interface{}, v interface{}) []interface{} {
+	out = append(out, v)
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for _, child := range node {
+			out = appendJSONDescendants(out, child)
+		}
+	case []interface{}:
+		for _, child := range node {
+			out = appendJSONDescendants(out, child)
+		}
+	}
+	return out
+}
+
+// matchDocument 判断已解码的JSON文档是否满足条件
+func (e *jsonPathExpr) matchDocument(doc interface{}) bool {
+	for _, v := range e.selectJSONPath(doc) {
+		if e.op == "" || e.compare(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchBody 解码JSON文本并判断是否满足条件，非JSON文本不匹配
+func (e *jsonPathExpr) matchBody(body []byte) bool {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return false
+	}
+	return e.matchDocument(doc)
+}
+
+func (e *jsonPathExpr) compare(v interface{}) bool {
+	switch e.op {
+	case "=~":
+		s, ok := v.(string)
+		return ok && e.re.MatchString(s)
+	case "==":
+		return e.equal(v)
+	case "!=":
+		return !e.equal(v)
+	}
+
+	var c int
+	switch a := v.(type) {
+	case float64:
+		b, ok := e.value.(float64)
+		if !ok {
+			return false
+		}
+		switch {
+		case a < b:
+			c = -1
+		case a > b:
+			c = 1
+		}
+	case string:
+		b, ok := e.value.(string)
+		if !ok {
+			return false
+		}
+		if e.ignoreCase {
+			a, b = strings.ToLower(a), strings.ToLower(b)
+		}
+		c = strings.Compare(a, b)
+	default:
+		return false
+	}
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func (e *jsonPathExpr) equal(v interface{}) bool {
+	if a, ok := v.(string); ok {
+		b, ok := e.value.(string)
+		if !ok {
+			return false
+		}
+		if e.ignoreCase {
+			return strings.EqualFold(a, b)
+		}
+		return a == b
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return v == e.value
+}
+
+// EvalJSONPath 对JSON文本求值JSONPath路径，返回选中的所有值
+//
+// 示例:
+//
+//	codes, err := EvalJSONPath([]byte(`{"errors":[{"code":"E42"}]}`), "$.errors[*].code")
+//	// codes == []interface{}{"E42"}
+func EvalJSONPath(data []byte, path string) ([]interface{}, error) {
+	e, err := compileJSONPath(path, false)
+	if err != nil {
+		return nil, err
+	}
+	if e.op != "" {
+		return nil, NewInvalidFormatError(fmt.Sprintf("JSONPath路径 '%s' 不能包含比较条件", path))
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, WrapJSONUnmarshalError(err)
+	}
+	return e.selectJSONPath(doc), nil
+}
@@ -0,0 +1,82 @@
+package har
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalJSONPath(t *testing.T) {
+	doc := []byte(`{"errors":[{"code":"E41"},{"code":"E42","detail":{"code":7}}],"meta":{"total":2}}`)
+
+	values, err := EvalJSONPath(doc, "$.errors[*].code")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"E41", "E42"}, values)
+
+	values, err = EvalJSONPath(doc, "$['meta'].total")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{2.0}, values)
+
+	values, err = EvalJSONPath(doc, "$.errors[-1].detail.code")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{7.0}, values)
+
+	values, err = EvalJSONPath(doc, "$..code")
+	assert.NoError(t, err)
+	assert.Len(t, values, 3)
+
+	for _, expr := range []string{"errors", "$.", "$[x]", "$.a ==", "$.a == nope", `$.a =~ 1`, `$.a =~ "("`} {
+		_, err := compileJSONPath(expr, false)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestFilterBodyAndMetadata(t *testing.T) {
+	h := NewHar()
+	a := h.AddEntry("POST", "https://api.x.com/login?lang=EN", "HTTP/1.1", "page_1")
+	a.Request.PostData = map[string]interface{}{"mimeType": "application/json", "text": `{"user":"alice"}`}
+	a.Response.Content.Text = `{"errors":[{"code":"E42"}]}`
+	a.ServerIPAddress = "10.0.0.1"
+	a.Priority = "High"
+	a.AddRequestHeader("Cookie", "session=abc123; theme=dark")
+
+	b := h.AddEntry("GET", "https://api.x.com/items?page=2", "HTTP/1.1", "page_2")
+	b.Response.Content.Text = base64.StdEncoding.EncodeToString([]byte(`{"items":[],"total":0}`))
+	b.Response.Content.Encoding = "base64"
+	b.Initiator.Type = "script"
+	b.Response.Cookies = []Cookie{{Name: "tracking", Value: "xyz"}}
+
+	urls := func(options FilterOptions) []string {
+		var out []string
+		for _, e := range h.Filter(options).Entries {
+			out = append(out, e.Request.URL)
+		}
+		return out
+	}
+	login := []string{"https://api.x.com/login?lang=EN"}
+	items := []string{"https://api.x.com/items?page=2"}
+
+	assert.Equal(t, login, urls(FilterOptions{JSONPath: `$.errors[*].code == "E42"`}))
+	assert.Nil(t, urls(FilterOptions{JSONPath: `$.errors[*].code == "e42"`}))
+	assert.Equal(t, login, urls(FilterOptions{JSONPath: `$.errors[*].code == "e42"`, IgnoreCase: true}))
+	assert.Equal(t, items, urls(FilterOptions{JSONPath: `$.total < 1`}))
+	assert.Equal(t, login, urls(FilterOptions{RequestJSONPath: `$.user =~ "^ali"`}))
+
+	assert.Equal(t, items, urls(FilterOptions{ResponseBody: `"items"`}))
+	assert.Equal(t, login, urls(FilterOptions{RequestBody: "ALICE", IgnoreCase: true}))
+	assert.Equal(t, login, urls(FilterOptions{QueryParamName: "lang", QueryParamValue: "en", IgnoreCase: true}))
+	assert.Equal(t, items, urls(FilterOptions{QueryParamName: "page", QueryParamValue: `^\d$`, UseRegex: true}))
+	assert.Equal(t, login, urls(FilterOptions{CookieName: "session", CookieValue: "abc"}))
+	assert.Equal(t, items, urls(FilterOptions{CookieName: "tracking"}))
+	assert.Equal(t, login, urls(FilterOptions{ServerIPAddress: "10.0.0.1"}))
+	assert.Equal(t, items, urls(FilterOptions{Pageref: "page_2"}))
+	assert.Nil(t, urls(FilterOptions{Pageref: "page"}))
+	assert.Equal(t, login, urls(FilterOptions{Priority: "high", IgnoreCase: true}))
+	assert.Equal(t, items, urls(FilterOptions{InitiatorType: "script"}))
+
+	assert.Equal(t, login, urls(FilterOptions{Predicate: MustJSONPathMatches(`$.errors`)}))
+	_, err := JSONPathMatches(`errors`)
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustJSONPathMatches(`$.errors[`) })
+}
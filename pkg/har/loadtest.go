@@ -0,0 +1,361 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 负载测试脚本格式
+const (
+	FormatK6     ConvertFormat = "k6"
+	FormatJMeter ConvertFormat = "jmeter"
+	FormatLocust ConvertFormat = "locust"
+)
+
+// minThinkTime 小于该值的请求间隔视为并发请求，不生成等待
+const minThinkTime = 100 * time.Millisecond
+
+// loadTestGroup 按页面分组的请求序列
+type loadTestGroup struct {
+	PageID string
+	Title  string
+	Steps  []loadTestStep
+	// ThinkTime 进入该分组前的思考时间，即上一个分组最后一个请求到本分组第一个请求的间隔
+	ThinkTime time.Duration
+}
+
+// loadTestStep 单个请求以及请求前的思考时间
+type loadTestStep struct {
+	Entry     Entries
+	ThinkTime time.Duration
+}
+
+// buildLoadTestGroups 按Pageref分组条目，步骤的思考时间为同一分组内与上一个请求的StartedDateTime间隔，
+// 分组的思考时间为与上一个分组最后一个请求的间隔，页面之间的停顿因此得以保留
+func buildLoadTestGroups(h *Har, entries []Entries) []loadTestGroup {
+	sorted := make([]Entries, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartedDateTime.Before(sorted[j].StartedDateTime)
+	})
+
+	titles := make(map[string]string, len(h.Log.Pages))
+	for _, page := range h.Log.Pages {
+		titles[page.ID] = page.Title
+	}
+
+	var groups []loadTestGroup
+	index := make(map[string]int)
+	for _, entry := range sorted {
+		gi, ok := index[entry.Pageref]
+		if !ok {
+			gi = len(groups)
+			index[entry.Pageref] = gi
+			groups = append(groups, loadTestGroup{
+				PageID: entry.Pageref,
+				Title:  titles[entry.Pageref],
+			})
+		}
+
+		var think time.Duration
+		if steps := groups[gi].Steps; len(steps) > 0 {
+			think = thinkTimeBetween(steps[len(steps)-1].Entry, entry)
+		}
+		groups[gi].Steps = append(groups[gi].Steps, loadTestStep{Entry: entry, ThinkTime: think})
+	}
+
+	for i := 1; i < len(groups); i++ {
+		prev := groups[i-1].Steps
+		groups[i].ThinkTime = thinkTimeBetween(prev[len(prev)-1].Entry, groups[i].Steps[0].Entry)
+	}
+
+	return groups
+}
+
+// thinkTimeBetween 返回两个请求StartedDateTime的间隔，小于minThinkTime(包括交错的负间隔)时返回0
+func thinkTimeBetween(prev, next Entries) time.Duration {
+	think := next.StartedDateTime.Sub(prev.StartedDateTime)
+	if think < minThinkTime {
+		return 0
+	}
+	return think
+}
+
+// groupName 返回分组在脚本中使用的名称
+func (g loadTestGroup) groupName() string {
+	switch {
+	case g.PageID == "":
+		return "requests"
+	case g.Title != "":
+		return g.PageID + " - " + g.Title
+	default:
+		return g.PageID
+	}
+}
+
+// postDataText 从PostData中提取MIME类型和文本内容
+func postDataText(req Request) (string, string) {
+	switch data := req.PostData.(type) {
+	case nil:
+		return "", ""
+	case string:
+		return "", data
+	case map[string]interface{}:
+		mimeType, _ := data["mimeType"].(string)
+		text, _ := data["text"].(string)
+		if text == "" {
+			// 只有params时按表单编码还原
+			if params, ok := data["params"].([]interface{}); ok {
+				values := url.Values{}
+				for _, p := range params {
+					if m, ok := p.(map[string]interface{}); ok {
+						name, _ := m["name"].(string)
+						value, _ := m["value"].(string)
+						values.Add(name, value)
+					}
+				}
+				text = values.Encode()
+			}
+		}
+		return mimeType, text
+	default:
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return "", ""
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return "", ""
+		}
+		return postDataText(Request{PostData: m})
+	}
+}
+
+// replayHeaders 返回适合在脚本中重放的请求头
+func replayHeaders(req Request) []Headers {
+	var headers []Headers
+	for _, header := range req.Headers {
+		name := strings.ToLower(header.Name)
+		// 跳过HTTP/2伪头部和由客户端自动计算的头部
+		if strings.HasPrefix(name, ":") || name == "content-length" || name == "host" {
+			continue
+		}
+		headers = append(headers, header)
+	}
+	return headers
+}
+
+// jsString 将字符串编码为JavaScript/Python通用的双引号字面量
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// convertToK6 生成k6 JavaScript脚本
+func convertToK6(h *Har, entries []Entries) (string, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "import http from 'k6/http';")
+	fmt.Fprintln(buf, "import { group, sleep } from 'k6';")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "export const options = { vus: 1, iterations: 1 };")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "export default function () {")
+
+	for _, group := range buildLoadTestGroups(h, entries) {
+		if group.ThinkTime > 0 {
+			fmt.Fprintf(buf, "  sleep(%.3f);\n", group.ThinkTime.Seconds())
+		}
+		fmt.Fprintf(buf, "  group(%s, function () {\n", jsString(group.groupName()))
+		for _, step := range group.Steps {
+			if step.ThinkTime > 0 {
+				fmt.Fprintf(buf, "    sleep(%.3f);\n", step.ThinkTime.Seconds())
+			}
+
+			req := step.Entry.Request
+			_, body := postDataText(req)
+			bodyLiteral := "null"
+			if body != "" {
+				bodyLiteral = jsString(body)
+			}
+
+			fmt.Fprintf(buf, "    http.request(%s, %s, %s, {\n", jsString(req.Method), jsString(req.URL), bodyLiteral)
+			fmt.Fprintln(buf, "      headers: {")
+			for _, header := range replayHeaders(req) {
+				fmt.Fprintf(buf, "        %s: %s,\n", jsString(header.Name), jsString(header.Value))
+			}
+			fmt.Fprintln(buf, "      },")
+			fmt.Fprintln(buf, "    });")
+		}
+		fmt.Fprintln(buf, "  });")
+	}
+
+	fmt.Fprintln(buf, "}")
+	return buf.String(), nil
+}
+
+// convertToLocust 生成Locust Python脚本
+func convertToLocust(h *Har, entries []Entries) (string, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "import time")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "from locust import HttpUser, task")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "class HarUser(HttpUser):")
+	fmt.Fprintln(buf, "    @task")
+	fmt.Fprintln(buf, "    def har_session(self):")
+
+	groups := buildLoadTestGroups(h, entries)
+	if len(groups) == 0 {
+		fmt.Fprintln(buf, "        pass")
+	}
+	for _, group := range groups {
+		fmt.Fprintf(buf, "        # %s\n", strings.ReplaceAll(group.groupName(), "\n", " "))
+		if group.ThinkTime > 0 {
+			fmt.Fprintf(buf, "        time.sleep(%.3f)\n", group.ThinkTime.Seconds())
+		}
+		for _, step := range group.Steps {
+			if step.ThinkTime > 0 {
+				fmt.Fprintf(buf, "        time.sleep(%.3f)\n", step.ThinkTime.Seconds())
+			}
+
+			req := step.Entry.Request
+			_, body := postDataText(req)
+
+			fmt.Fprintf(buf, "        self.client.request(\n")
+			fmt.Fprintf(buf, "            %s,\n", jsString(req.Method))
+			fmt.Fprintf(buf, "            %s,\n", jsString(req.URL))
+			fmt.Fprintf(buf, "            name=%s,\n", jsString(group.groupName()))
+			fmt.Fprintln(buf, "            headers={")
+			for _, header := range replayHeaders(req) {
+				fmt.Fprintf(buf, "                %s: %s,\n", jsString(header.Name), jsString(header.Value))
+			}
+			fmt.Fprintln(buf, "            },")
+			if body != "" {
+				fmt.Fprintf(buf, "            data=%s,\n", jsString(body))
+			}
+			fmt.Fprintln(buf, "        )")
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// xmlText 转义XML文本
+func xmlText(s string) string {
+	buf := &bytes.Buffer{}
+	_ = xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
+
+// convertToJMeter 生成JMeter .jmx测试计划
+func convertToJMeter(h *Har, entries []Entries) (string, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(buf, `<jmeterTestPlan version="1.2" properties="5.0" jmeter="5.6">`)
+	fmt.Fprintln(buf, `  <hashTree>`)
+	fmt.Fprintln(buf, `    <TestPlan guiclass="TestPlanGui" testclass="TestPlan" testname="HAR Test Plan" enabled="true">`)
+	fmt.Fprintln(buf, `      <boolProp name="TestPlan.functional_mode">false</boolProp>`)
+	fmt.Fprintln(buf, `      <boolProp name="TestPlan.serialize_threadgroups">false</boolProp>`)
+	fmt.Fprintln(buf, `    </TestPlan>`)
+	fmt.Fprintln(buf, `    <hashTree>`)
+	fmt.Fprintln(buf, `      <ThreadGroup guiclass="ThreadGroupGui" testclass="ThreadGroup" testname="HAR Users" enabled="true">`)
+	fmt.Fprintln(buf, `        <stringProp name="ThreadGroup.on_sample_error">continue</stringProp>`)
+	fmt.Fprintln(buf, `        <elementProp name="ThreadGroup.main_controller" elementType="LoopController" guiclass="LoopControlPanel" testclass="LoopController" enabled="true">`)
+	fmt.Fprintln(buf, `          <boolProp name="LoopController.continue_forever">false</boolProp>`)
+	fmt.Fprintln(buf, `          <stringProp name="LoopController.loops">1</stringProp>`)
+	fmt.Fprintln(buf, `        </elementProp>`)
+	fmt.Fprintln(buf, `        <stringProp name="ThreadGroup.num_threads">1</stringProp>`)
+	fmt.Fprintln(buf, `        <stringProp name="ThreadGroup.ramp_time">1</stringProp>`)
+	fmt.Fprintln(buf, `      </ThreadGroup>`)
+	fmt.Fprintln(buf, `      <hashTree>`)
+
+	for _, group := range buildLoadTestGroups(h, entries) {
+		fmt.Fprintf(buf, "        <TransactionController guiclass=\"TransactionControllerGui\" testclass=\"TransactionController\" testname=\"%s\" enabled=\"true\">\n", xmlText(group.groupName()))
+		fmt.Fprintln(buf, `          <boolProp name="TransactionController.includeTimers">false</boolProp>`)
+		fmt.Fprintln(buf, `        </TransactionController>`)
+		fmt.Fprintln(buf, `        <hashTree>`)
+		for i, step := range group.Steps {
+			if i == 0 {
+				// 分组前的思考时间合并到第一个采样器的定时器中
+				step.ThinkTime += group.ThinkTime
+			}
+			writeJMeterSampler(buf, step)
+		}
+		fmt.Fprintln(buf, `        </hashTree>`)
+	}
+
+	fmt.Fprintln(buf, `      </hashTree>`)
+	fmt.Fprintln(buf, `    </hashTree>`)
+	fmt.Fprintln(buf, `  </hashTree>`)
+	fmt.Fprintln(buf, `</jmeterTestPlan>`)
+	return buf.String(), nil
+}
+
+// writeJMeterSampler 写入单个HTTP采样器及其头部管理器和定时器
+func writeJMeterSampler(buf *bytes.Buffer, step loadTestStep) {
+	req := step.Entry.Request
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		u = &url.URL{Path: req.URL}
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	_, body := postDataText(req)
+
+	fmt.Fprintf(buf, "          <HTTPSamplerProxy guiclass=\"HttpTestSampleGui\" testclass=\"HTTPSamplerProxy\" testname=\"%s %s\" enabled=\"true\">\n",
+		xmlText(req.Method), xmlText(path))
+	if body != "" {
+		fmt.Fprintln(buf, `            <boolProp name="HTTPSampler.postBodyRaw">true</boolProp>`)
+		fmt.Fprintln(buf, `            <elementProp name="HTTPsampler.Arguments" elementType="Arguments">`)
+		fmt.Fprintln(buf, `              <collectionProp name="Arguments.arguments">`)
+		fmt.Fprintln(buf, `                <elementProp name="" elementType="HTTPArgument">`)
+		fmt.Fprintln(buf, `                  <boolProp name="HTTPArgument.always_encode">false</boolProp>`)
+		fmt.Fprintf(buf, "                  <stringProp name=\"Argument.value\">%s</stringProp>\n", xmlText(body))
+		fmt.Fprintln(buf, `                  <stringProp name="Argument.metadata">=</stringProp>`)
+		fmt.Fprintln(buf, `                </elementProp>`)
+		fmt.Fprintln(buf, `              </collectionProp>`)
+		fmt.Fprintln(buf, `            </elementProp>`)
+	} else {
+		fmt.Fprintln(buf, `            <elementProp name="HTTPsampler.Arguments" elementType="Arguments">`)
+		fmt.Fprintln(buf, `              <collectionProp name="Arguments.arguments"/>`)
+		fmt.Fprintln(buf, `            </elementProp>`)
+	}
+	fmt.Fprintf(buf, "            <stringProp name=\"HTTPSampler.domain\">%s</stringProp>\n", xmlText(u.Hostname()))
+	fmt.Fprintf(buf, "            <stringProp name=\"HTTPSampler.port\">%s</stringProp>\n", xmlText(u.Port()))
+	fmt.Fprintf(buf, "            <stringProp name=\"HTTPSampler.protocol\">%s</stringProp>\n", xmlText(u.Scheme))
+	fmt.Fprintf(buf, "            <stringProp name=\"HTTPSampler.path\">%s</stringProp>\n", xmlText(path))
+	fmt.Fprintf(buf, "            <stringProp name=\"HTTPSampler.method\">%s</stringProp>\n", xmlText(req.Method))
+	fmt.Fprintln(buf, `            <boolProp name="HTTPSampler.follow_redirects">false</boolProp>`)
+	fmt.Fprintln(buf, `            <boolProp name="HTTPSampler.use_keepalive">true</boolProp>`)
+	fmt.Fprintln(buf, `          </HTTPSamplerProxy>`)
+
+	fmt.Fprintln(buf, `          <hashTree>`)
+	fmt.Fprintln(buf, `            <HeaderManager guiclass="HeaderPanel" testclass="HeaderManager" testname="HTTP Header Manager" enabled="true">`)
+	fmt.Fprintln(buf, `              <collectionProp name="HeaderManager.headers">`)
+	for _, header := range replayHeaders(req) {
+		fmt.Fprintln(buf, `                <elementProp name="" elementType="Header">`)
+		fmt.Fprintf(buf, "                  <stringProp name=\"Header.name\">%s</stringProp>\n", xmlText(header.Name))
+		fmt.Fprintf(buf, "                  <stringProp name=\"Header.value\">%s</stringProp>\n", xmlText(header.Value))
+		fmt.Fprintln(buf, `                </elementProp>`)
+	}
+	fmt.Fprintln(buf, `              </collectionProp>`)
+	fmt.Fprintln(buf, `            </HeaderManager>`)
+	fmt.Fprintln(buf, `            <hashTree/>`)
+	if step.ThinkTime > 0 {
+		// JMeter定时器在采样器之前执行，用于模拟请求前的思考时间
+		fmt.Fprintln(buf, `            <ConstantTimer guiclass="ConstantTimerGui" testclass="ConstantTimer" testname="Think Time" enabled="true">`)
+		fmt.Fprintf(buf, "              <stringProp name=\"ConstantTimer.delay\">%d</stringProp>\n", step.ThinkTime.Milliseconds())
+		fmt.Fprintln(buf, `            </ConstantTimer>`)
+		fmt.Fprintln(buf, `            <hashTree/>`)
+	}
+	fmt.Fprintln(buf, `          </hashTree>`)
+}
@@ -0,0 +1,155 @@
+package har
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newLoadTestHar 创建两个页面交错请求的HAR:
+// page_1: 0s, 2s；page_2: 1s, 1.05s
+func newLoadTestHar() *Har {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHar()
+	h.AddPage("page_1", "Home")
+	h.AddPage("page_2", "Search")
+
+	home := h.AddEntry("GET", "https://x.com/", "HTTP/1.1", "page_1")
+	home.StartedDateTime = start
+	home.AddRequestHeader("Accept", "text/html")
+	home.AddRequestHeader(":authority", "x.com")
+
+	search := h.AddEntry("POST", "https://x.com/search?q=a&b=1", "HTTP/1.1", "page_2")
+	search.StartedDateTime = start.Add(time.Second)
+	search.AddRequestHeader("Content-Type", "application/json")
+	search.Request.PostData = map[string]interface{}{"mimeType": "application/json", "text": `{"q":"a"}`}
+
+	asset := h.AddEntry("GET", "https://x.com/search.js", "HTTP/1.1", "page_2")
+	asset.StartedDateTime = start.Add(1050 * time.Millisecond)
+
+	logo := h.AddEntry("GET", "https://x.com/logo.png", "HTTP/1.1", "page_1")
+	logo.StartedDateTime = start.Add(2 * time.Second)
+	return h
+}
+
+func TestBuildLoadTestGroups(t *testing.T) {
+	h := newLoadTestHar()
+	groups := buildLoadTestGroups(h, h.Log.Entries)
+	if !assert.Len(t, groups, 2) {
+		return
+	}
+
+	// 思考时间只计算同一分组内相邻请求的间隔
+	assert.Equal(t, "page_1 - Home", groups[0].groupName())
+	assert.Equal(t, []time.Duration{0, 2 * time.Second}, thinkTimes(groups[0]))
+	assert.Equal(t, "page_2 - Search", groups[1].groupName())
+	assert.Equal(t, []time.Duration{0, 0}, thinkTimes(groups[1]), "间隔小于minThinkTime视为并发")
+	// 交错的页面与上一个分组之间没有停顿
+	assert.Equal(t, time.Duration(0), groups[1].ThinkTime)
+}
+
+// newTwoPageHar 创建两个先后访问的页面: page_1: 0s, 0.5s；page_2: 3.5s, 4s
+func newTwoPageHar() *Har {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHar()
+	h.AddPage("page_1", "Home")
+	h.AddPage("page_2", "Search")
+	for _, e := range []struct {
+		url    string
+		page   string
+		offset time.Duration
+	}{
+		{"https://x.com/", "page_1", 0},
+		{"https://x.com/app.js", "page_1", 500 * time.Millisecond},
+		{"https://x.com/search", "page_2", 3500 * time.Millisecond},
+		{"https://x.com/search.js", "page_2", 4 * time.Second},
+	} {
+		h.AddEntry("GET", e.url, "HTTP/1.1", e.page).StartedDateTime = start.Add(e.offset)
+	}
+	return h
+}
+
+func TestLoadTestPageThinkTime(t *testing.T) {
+	h := newTwoPageHar()
+	groups := buildLoadTestGroups(h, h.Log.Entries)
+	if !assert.Len(t, groups, 2) {
+		return
+	}
+	assert.Equal(t, time.Duration(0), groups[0].ThinkTime)
+	assert.Equal(t, 3*time.Second, groups[1].ThinkTime, "页面之间的停顿从上一个分组的最后一个请求开始计算")
+	assert.Equal(t, []time.Duration{0, 500 * time.Millisecond}, thinkTimes(groups[1]))
+
+	k6, err := h.Convert(FormatK6, ConvertOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, k6, "  sleep(3.000);\n  group(\"page_2 - Search\", function () {\n")
+
+	locust, err := h.Convert(FormatLocust, ConvertOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, locust, "        # page_2 - Search\n        time.sleep(3.000)\n")
+
+	// JMeter中分组前的停顿合并到第一个采样器的定时器
+	plan, err := h.Convert(FormatJMeter, ConvertOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, plan, `<stringProp name="ConstantTimer.delay">3000</stringProp>`)
+	assert.Equal(t, 3, strings.Count(plan, "<ConstantTimer "))
+}
+
+func thinkTimes(g loadTestGroup) []time.Duration {
+	var times []time.Duration
+	for _, step := range g.Steps {
+		times = append(times, step.ThinkTime)
+	}
+	return times
+}
+
+func TestConvertToK6(t *testing.T) {
+	script, err := newLoadTestHar().Convert(FormatK6, ConvertOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, script, `group("page_1 - Home", function () {`)
+	assert.Contains(t, script, `http.request("POST", "https://x.com/search?q=a\u0026b=1", "{\"q\":\"a\"}", {`)
+	assert.Contains(t, script, `"Accept": "text/html",`)
+	assert.NotContains(t, script, ":authority")
+	assert.Equal(t, 1, strings.Count(script, "sleep(2.000);"))
+	assert.NotContains(t, script, "sleep(1.000);")
+}
+
+func TestConvertToLocust(t *testing.T) {
+	script, err := newLoadTestHar().Convert(FormatLocust, ConvertOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, script, "class HarUser(HttpUser):")
+	assert.Contains(t, script, "        # page_2 - Search\n")
+	assert.Contains(t, script, `name="page_2 - Search",`)
+	assert.Contains(t, script, `data="{\"q\":\"a\"}",`)
+	assert.Equal(t, 1, strings.Count(script, "time.sleep("))
+	assert.Contains(t, script, "time.sleep(2.000)")
+
+	empty, err := NewHar().Convert(FormatLocust, ConvertOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, empty, "        pass\n")
+}
+
+func TestConvertToJMeter(t *testing.T) {
+	plan, err := newLoadTestHar().Convert(FormatJMeter, ConvertOptions{})
+	assert.NoError(t, err)
+
+	// 输出必须是格式正确的XML
+	decoder := xml.NewDecoder(strings.NewReader(plan))
+	for {
+		_, err := decoder.Token()
+		if err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+	}
+
+	assert.Equal(t, 2, strings.Count(plan, "<TransactionController "))
+	assert.Equal(t, 4, strings.Count(plan, "<HTTPSamplerProxy "))
+	assert.Contains(t, plan, `<stringProp name="HTTPSampler.path">/search?q=a&amp;b=1</stringProp>`)
+	assert.Contains(t, plan, `<stringProp name="Argument.value">{&#34;q&#34;:&#34;a&#34;}</stringProp>`)
+	assert.Equal(t, 1, strings.Count(plan, "<ConstantTimer "))
+	assert.Contains(t, plan, `<stringProp name="ConstantTimer.delay">2000</stringProp>`)
+}
@@ -0,0 +1,435 @@
+package har
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Language 错误信息和校验说明使用的语言
+type Language string
+
+const (
+	// LanguageChinese 简体中文，默认语言
+	LanguageChinese Language = "zh"
+	// LanguageEnglish 英语
+	LanguageEnglish Language = "en"
+)
+
+// messageCatalog 按语言保存的消息模板，模板使用fmt格式，参数顺序在各语言中一致
+var messageCatalog = struct {
+	sync.RWMutex
+	language Language
+	messages map[Language]map[string]string
+}{
+	language: LanguageChinese,
+	messages: map[Language]map[string]string{
+		LanguageChinese: {
+			"error.field":              "字段 '%s': %s",
+			"error.partial":            "%s (部分错误: %s)",
+			"error.position":           "第%d行，第%d列",
+			"code.unknown":             "未知错误",
+			"code.filesystem":          "文件系统错误",
+			"code.json_parse":          "JSON解析错误",
+			"code.invalid_format":      "格式错误",
+			"code.validation":          "验证错误",
+			"code.missing_field":       "缺少必要字段",
+			"code.invalid_value":       "字段值无效",
+			"code.unsupported":         "不支持的操作",
+			"code.custom":              "自定义错误(%d)",
+			"code.undefined":           "未知错误代码(%d)",
+			"har.invalid":              "HAR对象缺少必要字段",
+			"har.invalid_url":          "HAR条目中包含无效URL",
+			"har.nil":                  "HAR对象为空",
+			"input.empty":              "输入为空",
+			"input.not_json":           "内容不是JSON格式",
+			"file.read":                "无法读取文件 '%s'",
+			"json.error":               "JSON解析错误",
+			"json.error_detail":        "JSON解析错误: %s",
+			"json.syntax":              "JSON语法错误: %s",
+			"json.type_mismatch":       "类型不匹配: 预期 %s 类型，但得到 %s",
+			"field.missing":            "必需字段缺失",
+			"field.invalid":            "字段值无效",
+			"field.invalid_reason":     "字段值无效: %s",
+			"parse.partial":            "HAR解析过程中发生错误，但部分内容已成功解析",
+			"parse.field":              "无法解析%s字段",
+			"parse.page":               "无法解析第%d个page",
+			"parse.entry":              "无法解析第%d个entry",
+			"url.invalid":              "无效的URL格式: %s",
+			"url.space":                "URL包含空格: %s",
+			"url.no_scheme":            "URL缺少协议: %s",
+			"url.not_absolute":         "URL不是绝对URL: %s",
+			"validation.failed":        "HAR验证失败",
+			"validation.version":       "不支持的HAR版本: %s",
+			"validation.page_id":       "页面必须有ID",
+			"validation.page_dup":      "页面ID '%s' 重复",
+			"validation.page_start":    "页面必须有开始时间",
+			"validation.page_title":    "页面缺少标题",
+			"validation.entry_start":   "条目必须有开始时间",
+			"validation.pageref":       "引用的页面 '%s' 不存在",
+			"validation.date":          "日期 %s 不在合理范围内",
+			"validation.method":        "HTTP请求必须有方法",
+			"validation.url":           "HTTP请求必须有URL",
+			"validation.req_version":   "HTTP请求必须有版本",
+			"validation.body_zero":     "请求包含postData但bodySize为0",
+			"validation.body_extra":    "bodySize为%d但没有postData",
+			"validation.status":        "HTTP响应必须有有效的状态码",
+			"validation.resp_version":  "HTTP响应必须有版本",
+			"validation.mime_type":     "内容必须有MIME类型",
+			"validation.content_size":  "内容大小不能为负: %d",
+			"validation.body_exceeds":  "响应未压缩，但bodySize(%d)大于content.size(%d)",
+			"validation.size_sentinel": "不可用的大小应为-1，实际为%d",
+			"validation.headers_size":  "headersSize为%d但没有记录头部",
+			"validation.header_name":   "HTTP头必须有名称",
+			"validation.cookie_name":   "Cookie必须有名称",
+			"validation.send":          "发送时间不能为负",
+			"validation.wait":          "等待时间不能为负",
+			"validation.receive":       "接收时间不能为负",
+			"validation.time_sentinel": "不可用的时间应为-1，实际为%v",
+			"validation.ssl_connect":   "ssl(%v)大于connect(%v)",
+			"validation.time_sum":      "time(%v)与各计时阶段之和(%v)不一致",
+
+			"recover.not_object":         "输入不是以JSON对象开始，无法恢复",
+			"recover.damaged":            "输入在字节偏移%d处损坏，之后的内容已丢弃",
+			"recover.partial":            "HAR输入已损坏，但部分内容已成功恢复",
+			"coerce.null":                "null已替换为默认值",
+			"coerce.number_to_string":    "数字%s已转换为字符串",
+			"coerce.bool_to_string":      "布尔值%v已转换为字符串",
+			"coerce.string_to_bool":      "字符串\"%s\"已转换为布尔值",
+			"coerce.number_to_bool":      "数字%s已转换为布尔值",
+			"coerce.round":               "小数%s已四舍五入为整数",
+			"coerce.empty_number":        "空字符串已转换为0",
+			"coerce.string_to_number":    "字符串\"%s\"已转换为数字",
+			"coerce.bool_to_number":      "布尔值%v已转换为数字",
+			"coerce.empty_date":          "空日期已替换为零值",
+			"coerce.date_layout":         "日期\"%s\"已按格式%s转换",
+			"coerce.timestamp_string":    "时间戳\"%s\"已转换为日期",
+			"coerce.timestamp":           "时间戳%s已转换为日期",
+			"schema.invalid":             "JSON不符合Schema",
+			"schema.type":                "类型应为%s，实际为%s",
+			"schema.enum":                "值不在允许的枚举值中",
+			"schema.minimum":             "值%s小于最小值%v",
+			"schema.format":              "\"%s\"不是RFC3339格式的日期时间",
+			"schema.required":            "缺少必需属性'%s'",
+			"schema.additional_property": "不允许的属性'%s'",
+			"schema.ref_unsupported":     "不支持的$ref '%s'，只支持#/definitions/下的引用",
+			"schema.ref_missing":         "$ref '%s'引用的定义不存在",
+			"schema.ref_cycle":           "$ref '%s'形成循环引用",
+			"code.unknown_name":          "字段值无效: 未知的错误代码",
+			"registry.nil_rule":          "字段值无效: 规则不能为空",
+			"registry.builtin_id":        "字段值无效: 与内置规则重复",
+			"registry.duplicate_id":      "字段值无效: 规则已注册",
+			"filter.status_range":        "字段值无效: 不能大于StatusCodeMax",
+			"filter.duration_range":      "字段值无效: 不能大于MaxDuration",
+			"filter.time_range":          "字段值无效: 不能晚于EndTime",
+			"filter.regexp":              "字段值无效: 无效的正则表达式: %s",
+			"predicate.regexp":           "无效的正则表达式 '%s': %s",
+
+			"diff.format":          "不支持的差异输出格式: %s",
+			"diff.title":           "HAR差异",
+			"diff.summary":         "新增%d个请求，删除%d个请求，变化%d个请求，未变化%d个请求",
+			"diff.column_change":   "变化",
+			"diff.column_method":   "方法",
+			"diff.column_changes":  "差异",
+			"diff.added":           "新增",
+			"diff.removed":         "删除",
+			"diff.changed":         "变化",
+			"diff.status":          "状态码由%d变为%d",
+			"diff.header_added":    "新增头部，值为%q",
+			"diff.header_removed":  "删除头部，原值为%q",
+			"diff.value_changed":   "由%s变为%s",
+			"diff.body_skipped":    "另有%d处差异未列出",
+			"diff.body_size":       "内容不同(%d字节变为%d字节)",
+			"diff.element_added":   "新增元素%s",
+			"diff.element_removed": "删除元素%s",
+			"diff.type_changed":    "类型由%s变为%s",
+			"diff.field_added":     "新增字段，值为%s",
+			"diff.field_removed":   "删除字段，原值为%s",
+			"diff.size_from_zero":  "响应大小由0字节增长到%d字节",
+			"diff.size_growth":     "响应大小由%d字节增长到%d字节(+%.1f%%)",
+			"diff.time_from_zero":  "耗时由%.1fms增长到%.1fms",
+			"diff.time_growth":     "耗时由%.1fms增长到%.1fms(+%.1f%%)",
+
+			"rule.log-version-required":           "log.version必须存在",
+			"rule.log-version-supported":          "log.version必须是1.1、1.2或1.3",
+			"rule.log-creator-required":           "log.creator必须包含name和version",
+			"rule.log-entries-required":           "log.entries必须是数组",
+			"rule.page-id-required":               "页面必须有id",
+			"rule.page-id-unique":                 "页面id不能重复",
+			"rule.page-started-required":          "页面必须有startedDateTime",
+			"rule.page-title-required":            "页面应有title",
+			"rule.page-timings-sentinel":          "pageTimings中不可用的值应为-1",
+			"rule.entry-started-required":         "条目必须有startedDateTime",
+			"rule.entry-pageref-exists":           "pageref必须引用log.pages中存在的页面",
+			"rule.entry-time-sum":                 "time应等于各非负计时阶段之和(不含ssl)",
+			"rule.date-plausible":                 "startedDateTime应在合理的时间范围内",
+			"rule.request-method-required":        "请求必须有method",
+			"rule.request-url-required":           "请求必须有url",
+			"rule.request-url-valid":              "请求url必须可以解析",
+			"rule.request-url-absolute":           "请求url应为包含协议和主机的绝对URL",
+			"rule.request-http-version-required":  "请求必须有httpVersion",
+			"rule.request-body-size":              "请求bodySize应与postData一致",
+			"rule.response-status-valid":          "响应必须有有效的status",
+			"rule.response-http-version-required": "响应必须有httpVersion",
+			"rule.response-body-size":             "未压缩响应的bodySize不应大于content.size",
+			"rule.content-mime-type-required":     "响应内容必须有mimeType",
+			"rule.content-size-valid":             "content.size不能为负",
+			"rule.header-name-required":           "头部必须有name",
+			"rule.headers-size-consistency":       "headersSize大于0时应记录头部",
+			"rule.cookie-name-required":           "Cookie必须有name",
+			"rule.size-sentinel":                  "headersSize和bodySize不可用时应为-1，不能小于-1",
+			"rule.timings-required":               "send、wait和receive必须为非负数",
+			"rule.timings-sentinel":               "blocked、dns、connect和ssl不可用时应为-1，不能小于-1",
+			"rule.timings-ssl-connect":            "ssl时间包含在connect时间中，不应大于connect",
+		},
+		LanguageEnglish: {
+			"error.field":              "field '%s': %s",
+			"error.partial":            "%s (partial errors: %s)",
+			"error.position":           "line %d, column %d",
+			"code.unknown":             "unknown error",
+			"code.filesystem":          "file system error",
+			"code.json_parse":          "JSON parse error",
+			"code.invalid_format":      "invalid format",
+			"code.validation":          "validation error",
+			"code.missing_field":       "missing required field",
+			"code.invalid_value":       "invalid field value",
+			"code.unsupported":         "unsupported operation",
+			"code.custom":              "custom error (%d)",
+			"code.undefined":           "unknown error code (%d)",
+			"har.invalid":              "HAR object is missing required fields",
+			"har.invalid_url":          "HAR entry contains an invalid URL",
+			"har.nil":                  "HAR object is nil",
+			"input.empty":              "input is empty",
+			"input.not_json":           "content is not JSON",
+			"file.read":                "cannot read file '%s'",
+			"json.error":               "JSON parse error",
+			"json.error_detail":        "JSON parse error: %s",
+			"json.syntax":              "JSON syntax error: %s",
+			"json.type_mismatch":       "type mismatch: expected %s but got %s",
+			"field.missing":            "required field is missing",
+			"field.invalid":            "invalid field value",
+			"field.invalid_reason":     "invalid field value: %s",
+			"parse.partial":            "errors occurred while parsing the HAR, but part of it was parsed successfully",
+			"parse.field":              "cannot parse the %s field",
+			"parse.page":               "cannot parse page #%d",
+			"parse.entry":              "cannot parse entry #%d",
+			"url.invalid":              "invalid URL: %s",
+			"url.space":                "URL contains spaces: %s",
+			"url.no_scheme":            "URL has no scheme: %s",
+			"url.not_absolute":         "URL is not absolute: %s",
+			"validation.failed":        "HAR validation failed",
+			"validation.version":       "unsupported HAR version: %s",
+			"validation.page_id":       "page must have an id",
+			"validation.page_dup":      "duplicate page id '%s'",
+			"validation.page_start":    "page must have a start time",
+			"validation.page_title":    "page has no title",
+			"validation.entry_start":   "entry must have a start time",
+			"validation.pageref":       "referenced page '%s' does not exist",
+			"validation.date":          "date %s is outside the plausible range",
+			"validation.method":        "HTTP request must have a method",
+			"validation.url":           "HTTP request must have a URL",
+			"validation.req_version":   "HTTP request must have a version",
+			"validation.body_zero":     "request has postData but bodySize is 0",
+			"validation.body_extra":    "bodySize is %d but there is no postData",
+			"validation.status":        "HTTP response must have a valid status code",
+			"validation.resp_version":  "HTTP response must have a version",
+			"validation.mime_type":     "content must have a MIME type",
+			"validation.content_size":  "content size cannot be negative: %d",
+			"validation.body_exceeds":  "response is not compressed, but bodySize (%d) is larger than content.size (%d)",
+			"validation.size_sentinel": "unavailable size should be -1, got %d",
+			"validation.headers_size":  "headersSize is %d but no headers were recorded",
+			"validation.header_name":   "HTTP header must have a name",
+			"validation.cookie_name":   "cookie must have a name",
+			"validation.send":          "send time cannot be negative",
+			"validation.wait":          "wait time cannot be negative",
+			"validation.receive":       "receive time cannot be negative",
+			"validation.time_sentinel": "unavailable timing should be -1, got %v",
+			"validation.ssl_connect":   "ssl (%v) is larger than connect (%v)",
+			"validation.time_sum":      "time (%v) does not match the sum of the timing phases (%v)",
+
+			"recover.not_object":         "input does not start with a JSON object and cannot be recovered",
+			"recover.damaged":            "input is damaged at byte offset %d, the rest was discarded",
+			"recover.partial":            "the HAR input is damaged, but part of it was recovered",
+			"coerce.null":                "null replaced with the default value",
+			"coerce.number_to_string":    "number %s converted to a string",
+			"coerce.bool_to_string":      "boolean %v converted to a string",
+			"coerce.string_to_bool":      "string \"%s\" converted to a boolean",
+			"coerce.number_to_bool":      "number %s converted to a boolean",
+			"coerce.round":               "decimal %s rounded to an integer",
+			"coerce.empty_number":        "empty string converted to 0",
+			"coerce.string_to_number":    "string \"%s\" converted to a number",
+			"coerce.bool_to_number":      "boolean %v converted to a number",
+			"coerce.empty_date":          "empty date replaced with the zero time",
+			"coerce.date_layout":         "date \"%s\" converted using layout %s",
+			"coerce.timestamp_string":    "timestamp \"%s\" converted to a date",
+			"coerce.timestamp":           "timestamp %s converted to a date",
+			"schema.invalid":             "JSON does not match the schema",
+			"schema.type":                "type should be %s, got %s",
+			"schema.enum":                "value is not one of the allowed enum values",
+			"schema.minimum":             "value %s is less than the minimum %v",
+			"schema.format":              "\"%s\" is not an RFC3339 date-time",
+			"schema.required":            "missing required property '%s'",
+			"schema.additional_property": "property '%s' is not allowed",
+			"schema.ref_unsupported":     "unsupported $ref '%s': only #/definitions/ references are supported",
+			"schema.ref_missing":         "$ref '%s' points to a missing definition",
+			"schema.ref_cycle":           "$ref '%s' forms a reference cycle",
+			"code.unknown_name":          "invalid field value: unknown error code",
+			"registry.nil_rule":          "invalid field value: rule must not be nil",
+			"registry.builtin_id":        "invalid field value: conflicts with a built-in rule",
+			"registry.duplicate_id":      "invalid field value: rule is already registered",
+			"filter.status_range":        "invalid field value: must not be greater than StatusCodeMax",
+			"filter.duration_range":      "invalid field value: must not be greater than MaxDuration",
+			"filter.time_range":          "invalid field value: must not be after EndTime",
+			"filter.regexp":              "invalid field value: invalid regular expression: %s",
+			"predicate.regexp":           "invalid regular expression '%s': %s",
+
+			"diff.format":          "unsupported diff output format: %s",
+			"diff.title":           "HAR diff",
+			"diff.summary":         "%d requests added, %d removed, %d changed, %d unchanged",
+			"diff.column_change":   "Change",
+			"diff.column_method":   "Method",
+			"diff.column_changes":  "Differences",
+			"diff.added":           "Added",
+			"diff.removed":         "Removed",
+			"diff.changed":         "Changed",
+			"diff.status":          "status changed from %d to %d",
+			"diff.header_added":    "header added with value %q",
+			"diff.header_removed":  "header removed, was %q",
+			"diff.value_changed":   "changed from %s to %s",
+			"diff.body_skipped":    "%d more differences not listed",
+			"diff.body_size":       "content differs (%d bytes to %d bytes)",
+			"diff.element_added":   "element %s added",
+			"diff.element_removed": "element %s removed",
+			"diff.type_changed":    "type changed from %s to %s",
+			"diff.field_added":     "field added with value %s",
+			"diff.field_removed":   "field removed, was %s",
+			"diff.size_from_zero":  "response size grew from 0 bytes to %d bytes",
+			"diff.size_growth":     "response size grew from %d bytes to %d bytes (+%.1f%%)",
+			"diff.time_from_zero":  "time grew from %.1fms to %.1fms",
+			"diff.time_growth":     "time grew from %.1fms to %.1fms (+%.1f%%)",
+
+			"rule.log-version-required":           "log.version must be present",
+			"rule.log-version-supported":          "log.version must be 1.1, 1.2 or 1.3",
+			"rule.log-creator-required":           "log.creator must contain name and version",
+			"rule.log-entries-required":           "log.entries must be an array",
+			"rule.page-id-required":               "pages must have an id",
+			"rule.page-id-unique":                 "page ids must be unique",
+			"rule.page-started-required":          "pages must have startedDateTime",
+			"rule.page-title-required":            "pages should have a title",
+			"rule.page-timings-sentinel":          "unavailable pageTimings values should be -1",
+			"rule.entry-started-required":         "entries must have startedDateTime",
+			"rule.entry-pageref-exists":           "pageref must reference a page in log.pages",
+			"rule.entry-time-sum":                 "time should equal the sum of the non-negative timing phases (excluding ssl)",
+			"rule.date-plausible":                 "startedDateTime should be within a plausible time range",
+			"rule.request-method-required":        "requests must have a method",
+			"rule.request-url-required":           "requests must have a url",
+			"rule.request-url-valid":              "request url must be parseable",
+			"rule.request-url-absolute":           "request url should be absolute with scheme and host",
+			"rule.request-http-version-required":  "requests must have httpVersion",
+			"rule.request-body-size":              "request bodySize should match postData",
+			"rule.response-status-valid":          "responses must have a valid status",
+			"rule.response-http-version-required": "responses must have httpVersion",
+			"rule.response-body-size":             "bodySize of an uncompressed response should not exceed content.size",
+			"rule.content-mime-type-required":     "response content must have mimeType",
+			"rule.content-size-valid":             "content.size must not be negative",
+			"rule.header-name-required":           "headers must have a name",
+			"rule.headers-size-consistency":       "headers should be recorded when headersSize is greater than 0",
+			"rule.cookie-name-required":           "cookies must have a name",
+			"rule.size-sentinel":                  "unavailable headersSize and bodySize should be -1 and never less than -1",
+			"rule.timings-required":               "send, wait and receive must be non-negative",
+			"rule.timings-sentinel":               "unavailable blocked, dns, connect and ssl should be -1 and never less than -1",
+			"rule.timings-ssl-connect":            "ssl time is included in connect time and should not exceed it",
+		},
+	},
+}
+
+// SetLanguage 设置之后创建的错误和校验发现使用的默认语言，语言没有消息目录时返回错误
+//
+// 已创建的错误可以通过HarError.Localize转换为其他语言，单次解析可以使用WithLanguage选项。
+func SetLanguage(lang Language) error {
+	messageCatalog.Lock()
+	_, ok := messageCatalog.messages[lang]
+	if ok {
+		messageCatalog.language = lang
+	}
+	messageCatalog.Unlock()
+	if !ok {
+		return NewInvalidValueError("language", lang, "没有该语言的消息目录")
+	}
+	return nil
+}
+
+// CurrentLanguage 返回当前的默认语言
+func CurrentLanguage() Language {
+	messageCatalog.RLock()
+	defer messageCatalog.RUnlock()
+	return messageCatalog.language
+}
+
+// RegisterMessages 为语言添加或覆盖消息模板，可用于支持新语言或本地化自定义规则的消息
+//
+// 新语言中缺少的键使用中文模板。自定义规则可以将消息键作为RuleReporter.Report的format传入。
+//
+// 示例:
+//
+//	har.RegisterMessages("de", map[string]string{
+//	    "validation.failed": "HAR-Validierung fehlgeschlagen",
+//	})
+//	har.RegisterMessages(har.LanguageEnglish, map[string]string{
+//	    "house.request_id": "API request %s has no X-Request-ID",
+//	})
+func RegisterMessages(lang Language, messages map[string]string) {
+	messageCatalog.Lock()
+	defer messageCatalog.Unlock()
+	catalog, ok := messageCatalog.messages[lang]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		messageCatalog.messages[lang] = catalog
+	}
+	for key, message := range messages {
+		catalog[key] = message
+	}
+}
+
+// Languages 返回所有有消息目录的语言
+func Languages() []Language {
+	messageCatalog.RLock()
+	defer messageCatalog.RUnlock()
+	languages := make([]Language, 0, len(messageCatalog.messages))
+	for lang := range messageCatalog.messages {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i] < languages[j] })
+	return languages
+}
+
+// Message 使用指定语言的模板格式化消息，语言中没有该键时使用中文模板，都没有时返回键本身
+func Message(lang Language, key string, params ...interface{}) string {
+	template, ok := lookupMessage(lang, key)
+	if !ok {
+		return key
+	}
+	if len(params) == 0 {
+		return template
+	}
+	return fmt.Sprintf(template, params...)
+}
+
+// lookupMessage 查找消息模板，缺少时回退到中文
+func lookupMessage(lang Language, key string) (string, bool) {
+	messageCatalog.RLock()
+	defer messageCatalog.RUnlock()
+	if lang == "" {
+		lang = messageCatalog.language
+	}
+	if template, ok := messageCatalog.messages[lang][key]; ok {
+		return template, true
+	}
+	template, ok := messageCatalog.messages[LanguageChinese][key]
+	return template, ok
+}
+
+// localize 使用当前默认语言格式化消息
+func localize(key string, params ...interface{}) string {
+	return Message("", key, params...)
+}
@@ -0,0 +1,189 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLanguage(t *testing.T) {
+	_, err := Parse(nil, WithLanguage(LanguageEnglish))
+	if assert.Error(t, err) {
+		assert.Equal(t, "input is empty", err.Error())
+	}
+
+	h := NewHar()
+	e := h.AddEntry("GET", "http://[::1", "HTTP/1.1", "")
+	e.Request.Method = ""
+	data, _ := json.Marshal(h)
+
+	_, err = Parse(data, WithLanguage(LanguageEnglish))
+	var harErr *HarError
+	if assert.True(t, errors.As(err, &harErr)) {
+		assert.Equal(t, "HAR validation failed", harErr.Message)
+		assert.Contains(t, harErr.Error(), "field '")
+		assert.True(t, errors.Is(err, ErrInvalidURL), "本地化不影响哨兵错误匹配")
+	}
+
+	// 默认语言不受单次解析的选项影响
+	_, err = Parse(data)
+	if assert.True(t, errors.As(err, &harErr)) {
+		assert.Equal(t, "HAR验证失败", harErr.Message)
+
+		localized := harErr.Localize(LanguageEnglish)
+		assert.Equal(t, "HAR validation failed", localized.Message)
+		assert.Contains(t, localized.Error(), "field '")
+		assert.Equal(t, "HAR验证失败", harErr.Message, "Localize不应修改原错误")
+	}
+}
+
+func TestRecoverLanguage(t *testing.T) {
+	// 第一个条目需要类型转换，第二个条目无法解析，之后输入被截断
+	data := []byte(`{"log": {"version": "1.2", "creator": {"name": "x", "version": "1"}, "entries": [
+		{"startedDateTime": "2024-01-01T00:00:00Z", "time": "12", "request": {"method": "GET", "url": "https://x.com/",
+		 "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "cookies": [], "headersSize": -1, "bodySize": -1},
+		 "response": {"status": 200, "statusText": "", "httpVersion": "HTTP/1.1", "headers": [], "cookies": [],
+		 "content": {"size": 0, "mimeType": ""}, "redirectURL": "", "headersSize": -1, "bodySize": -1},
+		 "cache": {}, "timings": {"send": 0, "wait": 0, "receive": 0}},
+		{"request": 5},
+		{"startedDateTime": `)
+
+	_, err := Parse(data, WithRecover(), WithLanguage(LanguageEnglish))
+	var harErr *HarError
+	if !assert.True(t, errors.As(err, &harErr)) {
+		return
+	}
+	assert.Equal(t, "the HAR input is damaged, but part of it was recovered", harErr.Message)
+	var keys []string
+	for _, pe := range harErr.PartialErrors {
+		keys = append(keys, pe.MessageKey)
+	}
+	assert.Equal(t, []string{"coerce.string_to_number", "parse.entry", "recover.damaged"}, keys)
+	assert.Contains(t, harErr.Error(), "field 'log.entries[1]': cannot parse entry #2")
+	assert.Contains(t, harErr.Error(), `string "12" converted to a number`)
+	assert.NotRegexp(t, `\p{Han}`, harErr.Error())
+
+	// Schema校验的结果同样可以本地化
+	err = HarSchema().Validate([]byte(`{"log": {"version": 1}}`))
+	if assert.True(t, errors.As(err, &harErr)) {
+		localized := harErr.Localize(LanguageEnglish)
+		assert.Equal(t, "JSON does not match the schema", localized.Message)
+		assert.Contains(t, localized.Error(), "missing required property 'creator'")
+		assert.Contains(t, localized.Error(), "type should be string, got number")
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	assert.Error(t, SetLanguage("xx"))
+	assert.Equal(t, LanguageChinese, CurrentLanguage())
+
+	assert.NoError(t, SetLanguage(LanguageEnglish))
+	defer SetLanguage(LanguageChinese)
+
+	assert.Equal(t, "missing required field", ErrCodeMissingField.Error())
+	for _, info := range ValidationRules() {
+		assert.NotEmpty(t, info.Description, info.ID)
+		assert.Equal(t, Message(LanguageEnglish, "rule."+info.ID), info.Description)
+	}
+
+	// 未知的键原样返回
+	assert.Equal(t, "no.such.key", Message(LanguageEnglish, "no.such.key"))
+}
+
+func TestRegisterMessages(t *testing.T) {
+	RegisterMessages(LanguageChinese, map[string]string{"test.request_id": "缺少请求头 %s"})
+	RegisterMessages(LanguageEnglish, map[string]string{"test.request_id": "missing header %s"})
+	assert.Contains(t, Languages(), LanguageEnglish)
+
+	v := NewValidator()
+	v.AddRule(NewRule(RuleInfo{ID: "test-request-id", Severity: SeverityError},
+		func(entry *Entries, path string, r *RuleReporter) {
+			r.ReportMessage(ErrCodeCustom+1, path+".request.headers", "test.request_id", "X-Request-ID")
+		}))
+
+	h := NewHar()
+	h.AddEntry("GET", "http://example.com/", "HTTP/1.1", "")
+	report := v.Validate(h)
+
+	var found *Finding
+	for i := range report.Findings {
+		if report.Findings[i].RuleID == "test-request-id" {
+			found = &report.Findings[i]
+		}
+	}
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "缺少请求头 X-Request-ID", found.Message)
+		assert.Equal(t, "missing header X-Request-ID", found.HarError().Localize(LanguageEnglish).Message)
+	}
+}
+
+func TestHarErrorJSON(t *testing.T) {
+	h := NewHar()
+	h.Log.Version = ""
+	data, _ := json.Marshal(h)
+	_, err := ParseHar(data)
+
+	var harErr *HarError
+	if !assert.True(t, errors.As(err, &harErr)) {
+		return
+	}
+	out, jsonErr := json.Marshal(harErr)
+	assert.NoError(t, jsonErr)
+
+	var raw map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out, &raw))
+	assert.Equal(t, "validation", raw["code"])
+	assert.Equal(t, "validation.failed", raw["messageKey"])
+	partials, _ := raw["partialErrors"].([]interface{})
+	if assert.NotEmpty(t, partials) {
+		first := partials[0].(map[string]interface{})
+		assert.Equal(t, "missing_field", first["code"])
+		assert.Equal(t, "field.missing", first["messageKey"])
+		assert.Equal(t, "log.version", first["field"])
+	}
+
+	var decoded HarError
+	assert.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, ErrCodeValidation, decoded.Code)
+	assert.Equal(t, harErr.Message, decoded.Message)
+	assert.Len(t, decoded.PartialErrors, len(harErr.PartialErrors))
+	assert.True(t, errors.Is(&decoded, ErrCodeMissingField))
+	assert.Equal(t, "required field is missing", decoded.PartialErrors[0].Localize(LanguageEnglish).Message)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"code":"bogus"}`), &decoded))
+}
+
+func TestErrorReasonsLocalized(t *testing.T) {
+	var decoded HarError
+	unknownCode := json.Unmarshal([]byte(`{"code":"bogus"}`), &decoded)
+	_, filterErr := CompileFilter(FilterOptions{StatusCodeMin: 500, StatusCodeMax: 400})
+	_, regexpErr := CompileFilter(FilterOptions{URL: "(", UseRegex: true})
+	_, predicateErr := URLMatches("(")
+	h := NewHar()
+	h.AddEntry("GET", "https://x.com/", "HTTP/1.1", "")
+	data, _ := json.Marshal(h)
+	// NewStreamingHarFromBytes会预先解析整个输入，这里直接构造以便在迭代时出错
+	streaming := (&StreamingHar{data: bytes.Replace(data, []byte(`"time":0`), []byte(`"time":"x"`), 1)}).Entries()
+	streaming.Next()
+
+	errs := map[string]error{
+		"code.unknown_name":   unknownCode,
+		"registry.nil_rule":   RegisterRule(nil),
+		"registry.builtin_id": RegisterRule(NewRule(RuleInfo{ID: "entry-time-sum"}, nil)),
+		"filter.status_range": filterErr,
+		"filter.regexp":       regexpErr,
+		"predicate.regexp":    predicateErr,
+		"parse.entry":         streaming.Err(),
+	}
+	for key, err := range errs {
+		harErr, ok := err.(*HarError)
+		if !assert.True(t, ok, key) {
+			continue
+		}
+		assert.Equal(t, key, harErr.MessageKey)
+		assert.NotRegexp(t, `\p{Han}`, harErr.Localize(LanguageEnglish).Message, key)
+	}
+}
@@ -0,0 +1,387 @@
+package har
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImportMitmproxyFile 从mitmproxy保存的流文件(mitmdump -w)导入HTTP流量
+//
+// 流文件由tnetstring编码的flow序列组成，只有type为http的flow会被转换。
+// 请求和响应的原始内容按Content-Encoding解码，二进制内容以base64保存。
+// 计时信息取自flow中记录的时间戳：connect和ssl来自server_conn，
+// 并且只计入同一服务端连接上的第一个请求。
+//
+// 示例:
+//
+//	h, err := ImportMitmproxyFile("flows.mitm")
+//	if err != nil {
+//	    log.Fatalf("导入mitmproxy流文件失败: %v", err)
+//	}
+func ImportMitmproxyFile(filePath string) (*Har, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	h, err := ImportMitmproxy(file)
+	if err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return nil, err
+	}
+	return h, nil
+}
+
+// ImportMitmproxy 从mitmproxy流数据导入HTTP流量
+func ImportMitmproxy(r io.Reader) (*Har, error) {
+	br := bufio.NewReader(r)
+	h := NewHar()
+	h.SetCreator("go-har mitmproxy importer", "1.0")
+
+	seenConnections := make(map[string]bool)
+	for index := 0; ; index++ {
+		value, err := readTNetString(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, NewInvalidFormatError(fmt.Sprintf("第%d个flow解析失败: %v", index+1, err))
+		}
+		flow, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, NewInvalidFormatError(fmt.Sprintf("第%d个flow不是字典", index+1))
+		}
+		if flowType := tnetString(flow["type"]); flowType != "" && flowType != "http" {
+			continue
+		}
+		entry, ok := mitmproxyEntry(flow, seenConnections)
+		if ok {
+			h.Log.Entries = append(h.Log.Entries, entry)
+		}
+	}
+
+	sort.SliceStable(h.Log.Entries, func(i, j int) bool {
+		return h.Log.Entries[i].StartedDateTime.Before(h.Log.Entries[j].StartedDateTime)
+	})
+	return h, nil
+}
+
+// mitmproxyEntry 将单个http flow转换为条目
+func mitmproxyEntry(flow map[string]interface{}, seenConnections map[string]bool) (Entries, bool) {
+	request, ok := flow["request"].(map[string]interface{})
+	if !ok {
+		return Entries{}, false
+	}
+	response, _ := flow["response"].(map[string]interface{})
+	serverConn, _ := flow["server_conn"].(map[string]interface{})
+
+	method := tnetString(request["method"])
+	version := tnetString(request["http_version"])
+	rawURL := mitmproxyURL(request)
+
+	reqHeaders := tnetHeaders(request["headers"])
+	reqBody := tnetBytes(request["content"])
+
+	entry := Entries{
+		Request: Request{
+			Method:      method,
+			URL:         rawURL,
+			HTTPVersion: version,
+			Headers:     reqHeaders,
+			Cookies:     cookiesFromHeaders(reqHeaders, "cookie"),
+			QueryString: queryStringFromURL(rawURL),
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: Response{
+			HTTPVersion: version,
+			Cookies:     []Cookie{},
+			Headers:     []Headers{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = map[string]interface{}{
+			"mimeType": headerValue(reqHeaders, "content-type"),
+			"text":     string(reqBody),
+		}
+	}
+
+	if response != nil {
+		respHeaders := tnetHeaders(response["headers"])
+		respBody := tnetBytes(response["content"])
+		entry.Response.Status = int(tnetFloat(response["status_code"]))
+		entry.Response.StatusText = tnetString(response["reason"])
+		entry.Response.HTTPVersion = tnetString(response["http_version"])
+		entry.Response.Headers = respHeaders
+		entry.Response.Cookies = cookiesFromHeaders(respHeaders, "set-cookie")
+		entry.Response.RedirectURL = headerValue(respHeaders, "location")
+		entry.Response.BodySize = len(respBody)
+		entry.Response.Content = buildContent(respBody, headerValue(respHeaders, "content-type"),
+			headerValue(respHeaders, "content-encoding"), 0)
+	}
+
+	if serverConn != nil {
+		if peer, ok := serverConn["peername"].([]interface{}); ok && len(peer) > 0 {
+			entry.ServerIPAddress = tnetString(peer[0])
+		} else if ip := tnetString(serverConn["ip_address"]); ip != "" {
+			entry.ServerIPAddress = ip
+		}
+		entry.Connection = tnetString(serverConn["id"])
+	}
+
+	// 计时以秒为单位的时间戳记录，缺失的阶段为-1
+	timings := Timings{Blocked: -1, DNS: -1, Connect: -1, Ssl: -1, Send: 0, Wait: -1, Receive: -1}
+	reqStart := tnetFloat(request["timestamp_start"])
+	reqEnd := tnetFloat(request["timestamp_end"])
+	start := reqStart
+
+	connID := entry.Connection
+	if serverConn != nil && (connID == "" || !seenConnections[connID]) {
+		connStart := tnetFloat(serverConn["timestamp_start"])
+		tcpSetup := tnetFloat(serverConn["timestamp_tcp_setup"])
+		tlsSetup := tnetFloat(serverConn["timestamp_tls_setup"])
+		if connStart > 0 && tcpSetup >= connStart {
+			timings.Connect = secondsToMs(tcpSetup - connStart)
+			if tlsSetup >= tcpSetup {
+				timings.Ssl = secondsToMs(tlsSetup - tcpSetup)
+				timings.Connect += timings.Ssl
+			}
+			if connStart < start || start == 0 {
+				start = connStart
+			}
+		}
+	}
+	if connID != "" {
+		seenConnections[connID] = true
+	}
+
+	if reqStart > 0 && reqEnd >= reqStart {
+		timings.Send = secondsToMs(reqEnd - reqStart)
+	}
+	if response != nil {
+		respStart := tnetFloat(response["timestamp_start"])
+		respEnd := tnetFloat(response["timestamp_end"])
+		if respStart > 0 && reqEnd > 0 {
+			timings.Wait = math.Max(0, secondsToMs(respStart-reqEnd))
+		}
+		if respStart > 0 && respEnd >= respStart {
+			timings.Receive = secondsToMs(respEnd - respStart)
+		}
+	}
+
+	entry.StartedDateTime = unixSeconds(start)
+	entry.Timings = timings
+	entry.Time = timingsTotal(timings)
+	return entry, true
+}
+
+// mitmproxyURL 根据请求字段构造完整URL
+func mitmproxyURL(request map[string]interface{}) string {
+	scheme := tnetString(request["scheme"])
+	if scheme == "" {
+		scheme = "http"
+	}
+	host := tnetString(request["authority"])
+	if host == "" {
+		host = tnetString(request["host"])
+		port := int(tnetFloat(request["port"]))
+		if port != 0 && !(scheme == "http" && port == 80) && !(scheme == "https" && port == 443) {
+			host += ":" + strconv.Itoa(port)
+		}
+	}
+	path := tnetString(request["path"])
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return scheme + "://" + host + path
+}
+
+// secondsToMs 将秒转换为毫秒
+func secondsToMs(seconds float64) float64 {
+	return seconds * 1000
+}
+
+// unixSeconds 将浮点数Unix时间戳转换为time.Time
+func unixSeconds(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	sec, frac := math.Modf(seconds)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC()
+}
+
+// maxTNetStringSize 单个tnetstring值的最大长度，防止损坏文件导致过量内存分配
+const maxTNetStringSize = 256 * 1024 * 1024
+
+// maxTNetStringDepth 列表和字典的最大嵌套层级，防止恶意构造的深层嵌套耗尽栈空间
+const maxTNetStringDepth = 64
+
+// readTNetString 从流中读取一个完整的tnetstring值
+func readTNetString(br *bufio.Reader) (interface{}, error) {
+	var prefix []byte
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(string(prefix)) == "" {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("读取长度前缀失败: %w", err)
+		}
+		if c == ':' {
+			break
+		}
+		prefix = append(prefix, c)
+		if len(prefix) > 12 {
+			return nil, fmt.Errorf("长度前缀过长: %q", prefix)
+		}
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(string(prefix)))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("长度前缀无效: %q", prefix)
+	}
+	if length > maxTNetStringSize {
+		return nil, fmt.Errorf("长度异常: %d", length)
+	}
+	payload := make([]byte, length+1)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		return nil, fmt.Errorf("数据不完整: %w", err)
+	}
+	return decodeTNetValue(payload[:length], payload[length], 0)
+}
+
+// parseTNetString 从内存数据中解析一个tnetstring值，返回值和剩余数据
+func parseTNetString(data []byte, depth int) (interface{}, []byte, error) {
+	colon := -1
+	for i := 0; i < len(data) && i < 12; i++ {
+		if data[i] == ':' {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return nil, nil, fmt.Errorf("缺少长度前缀")
+	}
+	length, err := strconv.Atoi(string(data[:colon]))
+	if err != nil || length < 0 {
+		return nil, nil, fmt.Errorf("长度前缀无效: %q", data[:colon])
+	}
+	end := colon + 1 + length
+	if end >= len(data) {
+		return nil, nil, fmt.Errorf("数据不完整")
+	}
+	value, err := decodeTNetValue(data[colon+1:end], data[end], depth)
+	return value, data[end+1:], err
+}
+
+// decodeTNetValue 按类型标记解码tnetstring负载，depth为当前值的嵌套层级
+func decodeTNetValue(payload []byte, tag byte, depth int) (interface{}, error) {
+	if (tag == ']' || tag == '}') && depth >= maxTNetStringDepth {
+		return nil, fmt.Errorf("嵌套层级超过%d", maxTNetStringDepth)
+	}
+	switch tag {
+	case ',':
+		return append([]byte(nil), payload...), nil
+	case ';':
+		return string(payload), nil
+	case '#':
+		return strconv.ParseInt(string(payload), 10, 64)
+	case '^':
+		return strconv.ParseFloat(string(payload), 64)
+	case '!':
+		return string(payload) == "true", nil
+	case '~':
+		return nil, nil
+	case ']':
+		list := []interface{}{}
+		for len(payload) > 0 {
+			item, rest, err := parseTNetString(payload, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+			payload = rest
+		}
+		return list, nil
+	case '}':
+		dict := make(map[string]interface{})
+		for len(payload) > 0 {
+			key, rest, err := parseTNetString(payload, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			value, rest, err := parseTNetString(rest, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			dict[tnetString(key)] = value
+			payload = rest
+		}
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("未知的类型标记 %q", tag)
+	}
+}
+
+// tnetString 将字节串或字符串值转换为string
+func tnetString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	default:
+		return ""
+	}
+}
+
+// tnetBytes 将字节串或字符串值转换为[]byte
+func tnetBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return nil
+	}
+}
+
+// tnetFloat 将数值转换为float64
+func tnetFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	default:
+		return 0
+	}
+}
+
+// tnetHeaders 将[[name, value], ...]形式的头部列表转换为Headers
+func tnetHeaders(value interface{}) []Headers {
+	headers := []Headers{}
+	list, _ := value.([]interface{})
+	for _, item := range list {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) != 2 {
+			continue
+		}
+		headers = append(headers, Headers{Name: tnetString(pair[0]), Value: tnetString(pair[1])})
+	}
+	return headers
+}
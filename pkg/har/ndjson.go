@@ -0,0 +1,407 @@
+package har
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NDJSON格式：第一行为不含entries的log元数据 {"log":{...}}，之后每行一个条目。
+// 合并多个分片文件时，后续出现的元数据行会被识别并合并其中的页面信息。
+
+// ndjsonHeader NDJSON文件的元数据行
+type ndjsonHeader struct {
+	Log *ndjsonLog `json:"log"`
+}
+
+// ndjsonLog 不包含条目的log元数据
+type ndjsonLog struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Pages   []Pages `json:"pages,omitempty"`
+}
+
+// IsNDJSONPath 根据扩展名(.ndjson或.jsonl)判断文件是否为NDJSON格式
+func IsNDJSONPath(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".ndjson", ".jsonl":
+		return true
+	}
+	return false
+}
+
+// NDJSONReader 逐行读取NDJSON格式的条目，实现了EntryIterator接口
+type NDJSONReader struct {
+	reader *bufio.Reader
+	closer io.Closer
+	log    Log
+	entry  Entries
+	line   int
+	err    error
+	closed bool
+	// 读取元数据时预读的第一条条目
+	pending []byte
+}
+
+// NewNDJSONReader 创建NDJSON读取器，立即读取元数据行(如果存在)
+//
+// 示例:
+//
+//	reader, err := NewNDJSONReader(file)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer reader.Close()
+//	for reader.Next() {
+//	    fmt.Println(reader.Entry().Request.URL)
+//	}
+//	if err := reader.Err(); err != nil {
+//	    log.Fatal(err)
+//	}
+func NewNDJSONReader(r io.Reader) (*NDJSONReader, error) {
+	reader := &NDJSONReader{reader: bufio.NewReader(r)}
+	if c, ok := r.(io.Closer); ok {
+		reader.closer = c
+	}
+
+	line, err := reader.readLine()
+	if err != nil && err != io.EOF {
+		return nil, NewFileSystemError("无法读取NDJSON数据", err)
+	}
+	if line == nil {
+		return reader, nil
+	}
+	if !isNDJSONHeader(line) {
+		reader.pending = line
+		return reader, nil
+	}
+	header, err := parseNDJSONHeader(line)
+	if err != nil {
+		return nil, NewJSONParseError("NDJSON元数据行解析失败", err).WithMetadata("line", reader.line)
+	}
+	reader.log.Version = header.Version
+	reader.log.Creator = header.Creator
+	reader.log.Pages = header.Pages
+	return reader, nil
+}
+
+// NewNDJSONReaderFromFile 打开NDJSON文件并创建读取器，Close时关闭文件
+func NewNDJSONReaderFromFile(filePath string) (*NDJSONReader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", filePath), err)
+	}
+	reader, err := NewNDJSONReader(file)
+	if err != nil {
+		file.Close()
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Log 返回元数据行中的log信息(不含条目)，读取过程中遇到的后续元数据行的页面会被合并
+func (r *NDJSONReader) Log() Log {
+	return r.log
+}
+
+// Next 读取下一个条目
+func (r *NDJSONReader) Next() bool {
+	if r.closed || r.err != nil {
+		return false
+	}
+	for {
+		line := r.pending
+		r.pending = nil
+		if line == nil {
+			var err error
+			line, err = r.readLine()
+			if err != nil && err != io.EOF {
+				r.err = NewFileSystemError("无法读取NDJSON数据", err)
+				return false
+			}
+			if line == nil {
+				return false
+			}
+		}
+
+		if isNDJSONHeader(line) {
+			header, err := parseNDJSONHeader(line)
+			if err != nil {
+				r.err = NewJSONParseError(fmt.Sprintf("第%d行解析失败", r.line), err).WithMetadata("line", r.line)
+				return false
+			}
+			// 追加或合并的分片文件会重复出现元数据行
+			r.log.Pages = append(r.log.Pages, header.Pages...)
+			continue
+		}
+
+		var entry Entries
+		if err := json.Unmarshal(line, &entry); err != nil {
+			r.err = WrapJSONUnmarshalError(err).WithMetadata("line", r.line)
+			return false
+		}
+		r.entry = entry
+		return true
+	}
+}
+
+// Entry 返回当前条目
+func (r *NDJSONReader) Entry() *Entries {
+	return &r.entry
+}
+
+// Line 返回当前条目所在的行号(从1开始)
+func (r *NDJSONReader) Line() int {
+	return r.line
+}
+
+// Err 返回迭代过程中的错误
+func (r *NDJSONReader) Err() error {
+	return r.err
+}
+
+// Close 关闭读取器，如果底层数据源可关闭则一并关闭
+func (r *NDJSONReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// readLine 读取下一个非空行，没有更多数据时返回nil
+func (r *NDJSONReader) readLine() ([]byte, error) {
+	for {
+		line, err := r.reader.ReadBytes('\n')
+		if len(line) > 0 {
+			r.line++
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// isNDJSONHeader 判断一行是否为元数据行。元数据行只有一个log键，条目没有log字段，
+// 因此只检查第一个键而不解析整行，每行数据只需反序列化一次
+func isNDJSONHeader(line []byte) bool {
+	rest := bytes.TrimLeft(line, " \t")
+	if len(rest) == 0 || rest[0] != '{' {
+		return false
+	}
+	rest = bytes.TrimLeft(rest[1:], " \t\r\n")
+	if !bytes.HasPrefix(rest, []byte(`"log"`)) {
+		return false
+	}
+	rest = bytes.TrimLeft(rest[len(`"log"`):], " \t\r\n")
+	return len(rest) > 0 && rest[0] == ':'
+}
+
+// parseNDJSONHeader 解析元数据行
+func parseNDJSONHeader(line []byte) (*ndjsonLog, error) {
+	var header ndjsonHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, err
+	}
+	if header.Log == nil {
+		return &ndjsonLog{}, nil
+	}
+	return header.Log, nil
+}
+
+// ReadNDJSON 读取全部NDJSON数据并组装为Har对象
+func ReadNDJSON(r io.Reader) (*Har, error) {
+	reader, err := NewNDJSONReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var entries []Entries
+	for reader.Next() {
+		entries = append(entries, *reader.Entry())
+	}
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+
+	log := reader.Log()
+	if log.Version == "" {
+		log.Version = "1.2"
+	}
+	if log.Pages == nil {
+		log.Pages = []Pages{}
+	}
+	log.Entries = entries
+	if log.Entries == nil {
+		log.Entries = []Entries{}
+	}
+	return &Har{Log: log}, nil
+}
+
+// ParseNDJSONFile 读取NDJSON文件并组装为Har对象
+func ParseNDJSONFile(filePath string) (*Har, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	h, err := ReadNDJSON(file)
+	if err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return nil, err
+	}
+	return h, nil
+}
+
+// NDJSONWriter 逐条写入NDJSON格式的条目
+type NDJSONWriter struct {
+	writer *bufio.Writer
+	count  int
+}
+
+// NewNDJSONWriter 创建NDJSON写入器并写入log元数据行，log中的条目会被忽略
+func NewNDJSONWriter(w io.Writer, log Log) (*NDJSONWriter, error) {
+	writer := newNDJSONEntryWriter(w)
+	header := ndjsonHeader{Log: &ndjsonLog{Version: log.Version, Creator: log.Creator, Pages: log.Pages}}
+	if err := writer.writeLine(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// newNDJSONEntryWriter 创建不写元数据行的写入器，用于追加到已有文件
+func newNDJSONEntryWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{writer: bufio.NewWriter(w)}
+}
+
+// WriteEntry 写入一个条目
+func (w *NDJSONWriter) WriteEntry(entry *Entries) error {
+	if err := w.writeLine(entry); err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+// WriteAll 写入迭代器中的全部条目，返回写入的条目数
+func (w *NDJSONWriter) WriteAll(it EntryIterator) (int, error) {
+	written := 0
+	for it.Next() {
+		if err := w.WriteEntry(it.Entry()); err != nil {
+			return written, err
+		}
+		written++
+	}
+	if err := it.Err(); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// Count 返回已写入的条目数
+func (w *NDJSONWriter) Count() int {
+	return w.count
+}
+
+// Flush 将缓冲的数据写入底层Writer
+func (w *NDJSONWriter) Flush() error {
+	if err := w.writer.Flush(); err != nil {
+		return NewFileSystemError("无法写入NDJSON数据", err)
+	}
+	return nil
+}
+
+// writeLine 将值编码为单行JSON
+func (w *NDJSONWriter) writeLine(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return NewHarError(ErrCodeUnknown, "无法序列化NDJSON行", err)
+	}
+	data = append(data, '\n')
+	if _, err := w.writer.Write(data); err != nil {
+		return NewFileSystemError("无法写入NDJSON数据", err)
+	}
+	return nil
+}
+
+// WriteNDJSON 将HAR写为NDJSON格式：一行log元数据，随后每行一个条目
+func (h *Har) WriteNDJSON(w io.Writer) error {
+	writer, err := NewNDJSONWriter(w, h.Log)
+	if err != nil {
+		return err
+	}
+	for i := range h.Log.Entries {
+		if err := writer.WriteEntry(&h.Log.Entries[i]); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// SaveNDJSONToFile 将HAR保存为NDJSON文件
+func (h *Har) SaveNDJSONToFile(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return NewFileSystemError(fmt.Sprintf("无法创建文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	if err := h.WriteNDJSON(file); err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return err
+	}
+	return nil
+}
+
+// AppendNDJSONFile 将条目追加到NDJSON文件末尾
+//
+// 文件不存在或为空时先写入log元数据行，否则只追加条目行，
+// 因此可以反复调用以持续记录新条目。
+func AppendNDJSONFile(filePath string, log Log, entries ...Entries) error {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return NewFileSystemError(fmt.Sprintf("无法打开文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return NewFileSystemError(fmt.Sprintf("无法读取文件信息 '%s'", filePath), err)
+	}
+
+	var writer *NDJSONWriter
+	if info.Size() == 0 {
+		if writer, err = NewNDJSONWriter(file, log); err != nil {
+			return err
+		}
+	} else {
+		writer = newNDJSONEntryWriter(file)
+	}
+	for i := range entries {
+		if err := writer.WriteEntry(&entries[i]); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
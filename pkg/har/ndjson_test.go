@@ -0,0 +1,82 @@
+package har
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	h := NewHar()
+	h.SetCreator("test", "1.0")
+	h.AddPage("page_1", "Home")
+	h.AddEntry("GET", "https://example.com/a", "HTTP/1.1", "")
+	h.AddEntry("POST", "https://example.com/b", "HTTP/1.1", "")
+
+	var buf bytes.Buffer
+	assert.NoError(t, h.WriteNDJSON(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], `{"log":`))
+
+	reader, err := NewNDJSONReader(&buf)
+	assert.NoError(t, err)
+	var it EntryIterator = reader
+	var urls []string
+	for it.Next() {
+		urls = append(urls, it.Entry().Request.URL)
+	}
+	assert.NoError(t, it.Err())
+	assert.NoError(t, it.Close())
+	assert.Equal(t, []string{"https://example.com/a", "https://example.com/b"}, urls)
+	assert.Equal(t, "test", reader.Log().Creator.Name)
+	assert.Len(t, reader.Log().Pages, 1)
+}
+
+func TestNDJSONAppendAndSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "capture.ndjson")
+
+	h := NewHar()
+	h.AddEntry("GET", "https://example.com/1", "HTTP/1.1", "")
+	assert.NoError(t, h.SaveToFile(path, true))
+	extra := NewHar()
+	extra.AddEntry("GET", "https://example.com/2", "HTTP/1.1", "")
+	assert.NoError(t, AppendNDJSONFile(path, h.Log, extra.Log.Entries...))
+
+	// 拼接另一个带元数据行的分片
+	shard := NewHar()
+	shard.AddEntry("GET", "https://example.com/3", "HTTP/1.1", "")
+	var buf bytes.Buffer
+	assert.NoError(t, shard.WriteNDJSON(&buf))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	assert.NoError(t, err)
+	f.Write(buf.Bytes())
+	f.Close()
+
+	loaded, err := ParseNDJSONFile(path)
+	assert.NoError(t, err)
+	assert.Len(t, loaded.Log.Entries, 3)
+	assert.Equal(t, "https://example.com/3", loaded.Log.Entries[2].Request.URL)
+
+	assert.NoError(t, os.WriteFile(path, []byte("{\"log\":{}}\n{bad json}\n"), 0644))
+	reader, err := NewNDJSONReaderFromFile(path)
+	assert.NoError(t, err)
+	defer reader.Close()
+	assert.False(t, reader.Next())
+	assert.Error(t, reader.Err())
+	assert.Equal(t, 2, reader.Line())
+}
+
+func TestIsNDJSONHeader(t *testing.T) {
+	assert.True(t, isNDJSONHeader([]byte(`{"log":{"version":"1.2"}}`)))
+	assert.True(t, isNDJSONHeader([]byte(`{ "log" : {}}`)))
+	// 只检查第一个键，条目中嵌套的log不影响判断
+	assert.False(t, isNDJSONHeader([]byte(`{"request":{"url":"https://x.com/log"},"log":1}`)))
+	assert.False(t, isNDJSONHeader([]byte(`{"logs":{}}`)))
+	assert.False(t, isNDJSONHeader([]byte(`["log"]`)))
+}
@@ -23,6 +23,12 @@ type options struct {
 	harVersion string
 	// 是否自动检测版本
 	autoDetectVersion bool
+	// 是否从被截断或损坏的JSON中恢复
+	recover bool
+	// 返回的错误使用的语言
+	language Language
+	// 流式解析中自定义规则错误级别的发现是否终止迭代
+	strictRules bool
 }
 
 // 默认选项
@@ -45,6 +51,8 @@ func (o *options) toParseOptions() ParseOptions {
 		SkipValidation:  o.skipValidation,
 		CollectWarnings: o.collectWarnings,
 		MaxWarnings:     o.maxWarnings,
+		Recover:         o.recover,
+		Language:        o.language,
 	}
 }
 
@@ -69,6 +77,29 @@ func WithCollectWarnings() Option {
 	}
 }
 
+// WithRecover 从被截断或损坏的JSON中恢复损坏位置之前的内容，同时启用宽松模式和警告收集
+func WithRecover() Option {
+	return func(o *options) {
+		o.lenient = true
+		o.collectWarnings = true
+		o.recover = true
+	}
+}
+
+// WithLanguage 设置返回的错误使用的语言，不影响其他解析的默认语言
+func WithLanguage(lang Language) Option {
+	return func(o *options) {
+		o.language = lang
+	}
+}
+
+// WithStrictRules 流式解析时，已注册的自定义规则产生错误级别的发现会终止迭代并作为错误返回
+func WithStrictRules() Option {
+	return func(o *options) {
+		o.strictRules = true
+	}
+}
+
 // WithMaxWarnings 设置最大警告数量
 func WithMaxWarnings(max int) Option {
 	return func(o *options) {
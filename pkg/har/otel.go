@@ -0,0 +1,391 @@
+package har
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLP span类型，取值与opentelemetry-proto中的SpanKind一致
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindClient   = 3
+)
+
+// OTLP状态码
+const (
+	otlpStatusUnset = 0
+	otlpStatusError = 2
+)
+
+// OTLPOptions OTLP导出选项
+type OTLPOptions struct {
+	// 资源属性service.name，为空时使用creator名称
+	ServiceName string
+	// 是否为Timings各阶段生成子span
+	IncludePhases bool
+	// 过滤选项（可选，用于在导出前先过滤数据）
+	Filter *FilterOptions
+}
+
+// DefaultOTLPOptions 默认的OTLP导出选项
+func DefaultOTLPOptions() OTLPOptions {
+	return OTLPOptions{
+		IncludePhases: true,
+	}
+}
+
+// OTLPTraces 表示OTLP/JSON格式的ExportTraceServiceRequest
+type OTLPTraces struct {
+	ResourceSpans []OTLPResourceSpans `json:"resourceSpans"`
+}
+
+// OTLPResourceSpans 表示同一资源下的span集合
+type OTLPResourceSpans struct {
+	Resource   OTLPResource     `json:"resource"`
+	ScopeSpans []OTLPScopeSpans `json:"scopeSpans"`
+}
+
+// OTLPResource 表示产生遥测数据的资源
+type OTLPResource struct {
+	Attributes []OTLPKeyValue `json:"attributes"`
+}
+
+// OTLPScopeSpans 表示同一插桩范围下的span集合
+type OTLPScopeSpans struct {
+	Scope OTLPScope  `json:"scope"`
+	Spans []OTLPSpan `json:"spans"`
+}
+
+// OTLPScope 表示插桩范围
+type OTLPScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// OTLPSpan 表示单个span
+type OTLPSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []OTLPKeyValue `json:"attributes,omitempty"`
+	Status            OTLPStatus     `json:"status"`
+}
+
+// OTLPStatus 表示span状态
+type OTLPStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// OTLPKeyValue 表示属性键值对
+type OTLPKeyValue struct {
+	Key   string       `json:"key"`
+	Value OTLPAnyValue `json:"value"`
+}
+
+// OTLPAnyValue 表示属性值，只会设置其中一个字段
+type OTLPAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"` // OTLP/JSON中int64编码为字符串
+}
+
+// ToOTLP 将HAR转换为OTLP trace数据
+//
+// 每个页面生成一个根span，页面下的条目生成CLIENT类型的子span，
+// Timings中的各个阶段(blocked, dns, connect, ssl, send, wait, receive)生成孙span。
+// 没有关联页面的条目各自作为独立trace的根span。
+// ID重复的页面只使用第一个，对应的条目都归入该页面的trace。
+func (h *Har) ToOTLP(options OTLPOptions) *OTLPTraces {
+	entries := h.Log.Entries
+	if options.Filter != nil {
+		entries = h.Filter(*options.Filter).Entries
+	}
+
+	serviceName := options.ServiceName
+	if serviceName == "" {
+		serviceName = h.Log.Creator.Name
+	}
+	if serviceName == "" {
+		serviceName = "go-har"
+	}
+
+	var spans []OTLPSpan
+	var pageIDs []string
+	pageSpans := make(map[string]*OTLPSpan)
+	pageEnds := make(map[string]time.Time)
+
+	for _, page := range h.Log.Pages {
+		if _, ok := pageSpans[page.ID]; ok {
+			continue
+		}
+		traceID := otlpID("trace", page.ID, page.StartedDateTime.String())[:32]
+		end := page.StartedDateTime
+		if page.PageTimings.OnLoad > 0 {
+			end = end.Add(msToDuration(page.PageTimings.OnLoad))
+		}
+		span := OTLPSpan{
+			TraceID: traceID,
+			SpanID:  otlpID("page", traceID)[:16],
+			Name:    pageSpanName(page),
+			Kind:    otlpSpanKindInternal,
+			Attributes: []OTLPKeyValue{
+				otlpString("har.page.id", page.ID),
+				otlpString("har.page.title", page.Title),
+			},
+		}
+		span.StartTimeUnixNano = otlpTime(page.StartedDateTime)
+		pageIDs = append(pageIDs, page.ID)
+		pageSpans[page.ID] = &span
+		pageEnds[page.ID] = end
+	}
+
+	for i, entry := range entries {
+		start := entry.StartedDateTime
+		end := start.Add(msToDuration(entry.Time))
+
+		var traceID, parentID string
+		if page, ok := pageSpans[entry.Pageref]; ok {
+			traceID = page.TraceID
+			parentID = page.SpanID
+			if end.After(pageEnds[entry.Pageref]) {
+				pageEnds[entry.Pageref] = end
+			}
+		} else {
+			traceID = otlpID("trace", strconv.Itoa(i), entry.Request.URL, start.String())[:32]
+		}
+
+		span := OTLPSpan{
+			TraceID:           traceID,
+			SpanID:            otlpID("entry", traceID, strconv.Itoa(i))[:16],
+			ParentSpanID:      parentID,
+			Name:              entry.Request.Method,
+			Kind:              otlpSpanKindClient,
+			StartTimeUnixNano: otlpTime(start),
+			EndTimeUnixNano:   otlpTime(end),
+			Attributes:        entryAttributes(entry),
+			Status:            OTLPStatus{Code: otlpStatusUnset},
+		}
+		if entry.Response.Status >= 400 || entry.Response.Status <= 0 {
+			span.Status = OTLPStatus{Code: otlpStatusError, Message: entry.Response.StatusText}
+		}
+		spans = append(spans, span)
+
+		if options.IncludePhases {
+			spans = append(spans, phaseSpans(span, entry)...)
+		}
+	}
+
+	// 页面span在所有条目处理完成后确定结束时间
+	for _, id := range pageIDs {
+		span := pageSpans[id]
+		span.EndTimeUnixNano = otlpTime(pageEnds[id])
+		spans = append(spans, *span)
+	}
+
+	return &OTLPTraces{
+		ResourceSpans: []OTLPResourceSpans{
+			{
+				Resource: OTLPResource{
+					Attributes: []OTLPKeyValue{otlpString("service.name", serviceName)},
+				},
+				ScopeSpans: []OTLPScopeSpans{
+					{
+						Scope: OTLPScope{Name: "github.com/cyberspacesec/go-har", Version: h.Log.Version},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	}
+}
+
+// ToOTLPJSON 将HAR转换为OTLP/JSON字节
+func (h *Har) ToOTLPJSON(options OTLPOptions, indent bool) ([]byte, error) {
+	traces := h.ToOTLP(options)
+	if indent {
+		return json.MarshalIndent(traces, "", "  ")
+	}
+	return json.Marshal(traces)
+}
+
+// SaveOTLPToFile 将HAR以OTLP/JSON格式保存到文件，可直接被OTel后端或collector的文件接收器加载
+func (h *Har) SaveOTLPToFile(filePath string, options OTLPOptions) error {
+	data, err := h.ToOTLPJSON(options, false)
+	if err != nil {
+		return NewHarError(ErrCodeUnknown, "无法生成OTLP数据", err)
+	}
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		return NewFileSystemError(fmt.Sprintf("无法写入文件 '%s'", filePath), err)
+	}
+	return nil
+}
+
+// pageSpanName 返回页面span名称
+func pageSpanName(page Pages) string {
+	if page.Title != "" {
+		return page.Title
+	}
+	return page.ID
+}
+
+// entryAttributes 按HTTP语义约定生成条目属性
+func entryAttributes(entry Entries) []OTLPKeyValue {
+	attrs := []OTLPKeyValue{
+		otlpString("http.request.method", entry.Request.Method),
+		otlpString("url.full", entry.Request.URL),
+	}
+
+	if u, err := url.Parse(entry.Request.URL); err == nil {
+		attrs = append(attrs, otlpString("server.address", u.Hostname()))
+		if port := u.Port(); port != "" {
+			if p, err := strconv.Atoi(port); err == nil {
+				attrs = append(attrs, otlpInt("server.port", int64(p)))
+			}
+		}
+		attrs = append(attrs, otlpString("url.scheme", u.Scheme))
+	}
+
+	if entry.Response.Status > 0 {
+		attrs = append(attrs, otlpInt("http.response.status_code", int64(entry.Response.Status)))
+	}
+	if version := protocolVersion(entry.Response.HTTPVersion); version != "" {
+		attrs = append(attrs, otlpString("network.protocol.version", version))
+	}
+	if entry.ServerIPAddress != "" {
+		attrs = append(attrs, otlpString("network.peer.address", entry.ServerIPAddress))
+	}
+	if entry.Request.BodySize >= 0 {
+		attrs = append(attrs, otlpInt("http.request.body.size", int64(entry.Request.BodySize)))
+	}
+	if entry.Response.BodySize >= 0 {
+		attrs = append(attrs, otlpInt("http.response.body.size", int64(entry.Response.BodySize)))
+	}
+	for _, header := range entry.Request.Headers {
+		if strings.EqualFold(header.Name, "User-Agent") {
+			attrs = append(attrs, otlpString("user_agent.original", header.Value))
+			break
+		}
+	}
+	if entry.ResourceType != "" {
+		attrs = append(attrs, otlpString("har.resource_type", entry.ResourceType))
+	}
+	if entry.Connection != "" {
+		attrs = append(attrs, otlpString("har.connection", entry.Connection))
+	}
+
+	return attrs
+}
+
+// protocolVersion 将"HTTP/1.1"、"h2"等转换为语义约定中的版本号
+func protocolVersion(httpVersion string) string {
+	v := strings.ToLower(strings.TrimSpace(httpVersion))
+	switch {
+	case v == "":
+		return ""
+	case v == "h2" || v == "http/2.0" || v == "http/2":
+		return "2"
+	case v == "h3" || v == "http/3.0" || v == "http/3":
+		return "3"
+	case strings.HasPrefix(v, "http/"):
+		return strings.TrimPrefix(v, "http/")
+	default:
+		return v
+	}
+}
+
+// timingPhase 表示Timings中的一个阶段
+type timingPhase struct {
+	Name   string
+	Offset time.Duration
+	Length time.Duration
+}
+
+// timingPhases 按时间顺序计算各阶段相对条目开始时间的偏移量
+//
+// 根据HAR规范，ssl时间包含在connect时间内，因此ssl阶段放置在connect阶段的末尾。
+func timingPhases(t Timings) []timingPhase {
+	var phases []timingPhase
+	var offset time.Duration
+
+	add := func(name string, ms float64) {
+		if ms <= 0 {
+			return
+		}
+		d := msToDuration(ms)
+		phases = append(phases, timingPhase{Name: name, Offset: offset, Length: d})
+		offset += d
+	}
+
+	add("blocked", t.Blocked)
+	add("dns", t.DNS)
+	connectStart := offset
+	add("connect", t.Connect)
+	if t.Ssl > 0 {
+		ssl := msToDuration(t.Ssl)
+		start := offset - ssl
+		if start < connectStart {
+			start = connectStart
+		}
+		phases = append(phases, timingPhase{Name: "ssl", Offset: start, Length: offset - start})
+	}
+	add("send", t.Send)
+	add("wait", t.Wait)
+	add("receive", t.Receive)
+
+	return phases
+}
+
+// phaseSpans 为条目的各个计时阶段生成子span
+func phaseSpans(parent OTLPSpan, entry Entries) []OTLPSpan {
+	var spans []OTLPSpan
+	for _, phase := range timingPhases(entry.Timings) {
+		start := entry.StartedDateTime.Add(phase.Offset)
+		spans = append(spans, OTLPSpan{
+			TraceID:           parent.TraceID,
+			SpanID:            otlpID("phase", parent.SpanID, phase.Name)[:16],
+			ParentSpanID:      parent.SpanID,
+			Name:              phase.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: otlpTime(start),
+			EndTimeUnixNano:   otlpTime(start.Add(phase.Length)),
+			Status:            OTLPStatus{Code: otlpStatusUnset},
+		})
+	}
+	return spans
+}
+
+// msToDuration 将毫秒浮点数转换为time.Duration
+func msToDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// otlpID 根据输入生成确定性的十六进制ID，保证同一HAR多次导出结果一致
+func otlpID(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// otlpTime 将时间转换为OTLP/JSON使用的纳秒字符串
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpString(key, value string) OTLPKeyValue {
+	return OTLPKeyValue{Key: key, Value: OTLPAnyValue{StringValue: &value}}
+}
+
+func otlpInt(key string, value int64) OTLPKeyValue {
+	s := strconv.FormatInt(value, 10)
+	return OTLPKeyValue{Key: key, Value: OTLPAnyValue{IntValue: &s}}
+}
@@ -0,0 +1,89 @@
+package har
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToOTLP(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHar()
+	h.SetCreator("browser", "1.0")
+	page := h.AddPage("page_1", "Home")
+	page.StartedDateTime = start
+	page.SetPageTimings(-1, 500)
+	// ID重复的页面不生成额外的span
+	h.AddPage("page_1", "Duplicate").StartedDateTime = start.Add(time.Hour)
+
+	entry := h.AddEntry("GET", "https://x.com:8443/a", "HTTP/2.0", "page_1")
+	entry.StartedDateTime = start.Add(10 * time.Millisecond)
+	entry.SetResponseStatus(200, "OK")
+	entry.SetTimings(-1, 5, 20, 1, 30, 4, 10)
+	entry.Time = 60
+
+	orphan := h.AddEntry("POST", "https://y.com/b", "HTTP/1.1", "")
+	orphan.StartedDateTime = start
+	orphan.SetResponseStatus(503, "Service Unavailable")
+	orphan.SetTimings(-1, -1, -1, 1, 2, 3, -1)
+
+	traces := h.ToOTLP(DefaultOTLPOptions())
+	resource := traces.ResourceSpans[0]
+	assert.Equal(t, "browser", *resource.Resource.Attributes[0].Value.StringValue)
+	spans := resource.ScopeSpans[0].Spans
+
+	traceIDPattern := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	spanIDPattern := regexp.MustCompile(`^[0-9a-f]{16}$`)
+	byName := make(map[string][]OTLPSpan)
+	for _, span := range spans {
+		assert.Regexp(t, traceIDPattern, span.TraceID)
+		assert.Regexp(t, spanIDPattern, span.SpanID)
+		byName[span.Name] = append(byName[span.Name], span)
+	}
+
+	// 页面 → 条目 → 计时阶段
+	if !assert.Len(t, byName["Home"], 1) || !assert.Len(t, byName["GET"], 1) {
+		return
+	}
+	assert.Empty(t, byName["Duplicate"])
+	pageSpan, entrySpan := byName["Home"][0], byName["GET"][0]
+	assert.Empty(t, pageSpan.ParentSpanID)
+	assert.Equal(t, pageSpan.TraceID, entrySpan.TraceID)
+	assert.Equal(t, pageSpan.SpanID, entrySpan.ParentSpanID)
+	assert.Equal(t, otlpSpanKindClient, entrySpan.Kind)
+	assert.Equal(t, otlpTime(start.Add(500*time.Millisecond)), pageSpan.EndTimeUnixNano)
+
+	// 值为-1的阶段不生成span
+	var phases []string
+	for _, span := range spans {
+		if span.ParentSpanID == entrySpan.SpanID {
+			assert.Equal(t, entrySpan.TraceID, span.TraceID)
+			phases = append(phases, span.Name)
+		}
+	}
+	assert.Equal(t, []string{"dns", "connect", "ssl", "send", "wait", "receive"}, phases)
+	assert.Empty(t, byName["blocked"])
+	ssl, connect := byName["ssl"][0], byName["connect"][0]
+	assert.Equal(t, connect.EndTimeUnixNano, ssl.EndTimeUnixNano, "ssl位于connect末尾")
+
+	// 没有页面的条目为独立trace的根span
+	orphanSpan := byName["POST"][0]
+	assert.Empty(t, orphanSpan.ParentSpanID)
+	assert.NotEqual(t, pageSpan.TraceID, orphanSpan.TraceID)
+	assert.Equal(t, otlpStatusError, orphanSpan.Status.Code)
+
+	// 导出结果是确定的
+	first, err := h.ToOTLPJSON(DefaultOTLPOptions(), false)
+	assert.NoError(t, err)
+	second, _ := h.ToOTLPJSON(DefaultOTLPOptions(), false)
+	assert.Equal(t, first, second)
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(first, &decoded))
+
+	options := DefaultOTLPOptions()
+	options.IncludePhases = false
+	assert.Len(t, h.ToOTLP(options).ResourceSpans[0].ScopeSpans[0].Spans, 3)
+}
@@ -0,0 +1,425 @@
+package har
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+)
+
+// parquetMagic Parquet文件首尾的魔数
+const parquetMagic = "PAR1"
+
+// ParquetOptions Parquet导出选项
+type ParquetOptions struct {
+	// IncludeHeaders 是否输出request_headers和response_headers嵌套列
+	IncludeHeaders bool
+	// RowGroupSize 每个行组包含的最大行数，决定写入时缓冲的条目数量
+	RowGroupSize int
+}
+
+// DefaultParquetOptions 默认的Parquet导出选项
+func DefaultParquetOptions() ParquetOptions {
+	return ParquetOptions{RowGroupSize: 10000}
+}
+
+// parquetColumn 一个叶子列在当前行组中的数据
+type parquetColumn struct {
+	path      []string
+	physical  int32
+	converted int32
+	maxDef    int
+	maxRep    int
+	values    bytes.Buffer
+	defLevels []int
+	repLevels []int
+}
+
+// parquetChunk 已写出的列块元数据
+type parquetChunk struct {
+	offset    int64
+	size      int64
+	numValues int64
+}
+
+// parquetField 扁平列的定义，value返回nil表示空值
+type parquetField struct {
+	name      string
+	physical  int32
+	converted int32
+	optional  bool
+	value     func(e *Entries) interface{}
+}
+
+// parquetFields 条目展开后的扁平列
+var parquetFields = []parquetField{
+	{"started_date_time", parquetInt64, parquetTimestampMillis, false, func(e *Entries) interface{} {
+		return e.StartedDateTime.UnixNano() / 1e6
+	}},
+	{"page_id", parquetByteArray, parquetUTF8, true, func(e *Entries) interface{} { return optionalString(e.Pageref) }},
+	{"method", parquetByteArray, parquetUTF8, false, func(e *Entries) interface{} { return e.Request.Method }},
+	{"url", parquetByteArray, parquetUTF8, false, func(e *Entries) interface{} { return e.Request.URL }},
+	{"host", parquetByteArray, parquetUTF8, false, func(e *Entries) interface{} {
+		if u, err := url.Parse(e.Request.URL); err == nil {
+			return u.Hostname()
+		}
+		return ""
+	}},
+	{"http_version", parquetByteArray, parquetUTF8, true, func(e *Entries) interface{} { return optionalString(e.Request.HTTPVersion) }},
+	{"status", parquetInt32, parquetNoConverted, false, func(e *Entries) interface{} { return int32(e.Response.Status) }},
+	{"mime_type", parquetByteArray, parquetUTF8, true, func(e *Entries) interface{} { return optionalString(e.Response.Content.MimeType) }},
+	{"server_ip_address", parquetByteArray, parquetUTF8, true, func(e *Entries) interface{} { return optionalString(e.ServerIPAddress) }},
+	{"request_headers_size", parquetInt64, parquetNoConverted, false, func(e *Entries) interface{} { return int64(e.Request.HeadersSize) }},
+	{"request_body_size", parquetInt64, parquetNoConverted, false, func(e *Entries) interface{} { return int64(e.Request.BodySize) }},
+	{"response_headers_size", parquetInt64, parquetNoConverted, false, func(e *Entries) interface{} { return int64(e.Response.HeadersSize) }},
+	{"response_body_size", parquetInt64, parquetNoConverted, false, func(e *Entries) interface{} { return int64(e.Response.BodySize) }},
+	{"content_size", parquetInt64, parquetNoConverted, false, func(e *Entries) interface{} { return int64(e.Response.Content.Size) }},
+	{"time", parquetDouble, parquetNoConverted, false, func(e *Entries) interface{} { return e.Time }},
+	{"blocked", parquetDouble, parquetNoConverted, true, func(e *Entries) interface{} { return optionalTiming(e.Timings.Blocked) }},
+	{"dns", parquetDouble, parquetNoConverted, true, func(e *Entries) interface{} { return optionalTiming(e.Timings.DNS) }},
+	{"connect", parquetDouble, parquetNoConverted, true, func(e *Entries) interface{} { return optionalTiming(e.Timings.Connect) }},
+	{"ssl", parquetDouble, parquetNoConverted, true, func(e *Entries) interface{} { return optionalTiming(e.Timings.Ssl) }},
+	{"send", parquetDouble, parquetNoConverted, true, func(e *Entries) interface{} { return optionalTiming(e.Timings.Send) }},
+	{"wait", parquetDouble, parquetNoConverted, true, func(e *Entries) interface{} { return optionalTiming(e.Timings.Wait) }},
+	{"receive", parquetDouble, parquetNoConverted, true, func(e *Entries) interface{} { return optionalTiming(e.Timings.Receive) }},
+}
+
+// optionalString 空字符串作为空值
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// optionalTiming 负数计时(-1表示不适用)作为空值
+func optionalTiming(v float64) interface{} {
+	if v < 0 {
+		return nil
+	}
+	return v
+}
+
+// parquetWriter 按行组流式写出Parquet文件
+type parquetWriter struct {
+	w         io.Writer
+	offset    int64
+	options   ParquetOptions
+	columns   []*parquetColumn
+	rows      int
+	totalRows int64
+	rowGroups []parquetRowGroup
+}
+
+// parquetRowGroup 已写出的行组元数据
+type parquetRowGroup struct {
+	numRows   int64
+	totalSize int64
+	chunks    []parquetChunk
+}
+
+// WriteParquet 从迭代器读取条目并以Parquet列式格式写出，返回写入的行数
+//
+// 每个条目展开为一行，包含URL、主机、方法、状态码、各项大小、Timings的每个阶段
+// (值为-1的阶段写为空值)、MIME类型、页面ID和开始时间(毫秒时间戳)。
+// 设置IncludeHeaders后，请求和响应头部以重复组(name, value)的嵌套列输出。
+// 写入时只缓冲一个行组的数据，适合处理大型HAR文件。
+//
+// 示例:
+//
+//	sh, _ := NewStreamingHarFromFile("large.har")
+//	defer sh.Close()
+//	it := sh.Entries()
+//	defer it.Close()
+//	rows, err := WriteParquet(file, it, DefaultParquetOptions())
+func WriteParquet(w io.Writer, it EntryIterator, options ParquetOptions) (int, error) {
+	if options.RowGroupSize <= 0 {
+		options.RowGroupSize = DefaultParquetOptions().RowGroupSize
+	}
+	pw := newParquetWriter(w, options)
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return 0, err
+	}
+
+	for it.Next() {
+		pw.addRow(it.Entry())
+		if pw.rows >= options.RowGroupSize {
+			if err := pw.flushRowGroup(); err != nil {
+				return int(pw.totalRows), err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return int(pw.totalRows), err
+	}
+	if err := pw.flushRowGroup(); err != nil {
+		return int(pw.totalRows), err
+	}
+	if err := pw.writeFooter(); err != nil {
+		return int(pw.totalRows), err
+	}
+	return int(pw.totalRows), nil
+}
+
+// WriteParquet 将HAR中的条目以Parquet格式写出
+func (h *Har) WriteParquet(w io.Writer, options ParquetOptions) error {
+	_, err := WriteParquet(w, h.Iterator(), options)
+	return err
+}
+
+// SaveParquetToFile 将HAR中的条目保存为Parquet文件
+func (h *Har) SaveParquetToFile(filePath string, options ParquetOptions) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return NewFileSystemError(fmt.Sprintf("无法创建文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	if err := h.WriteParquet(file, options); err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return err
+	}
+	return nil
+}
+
+func newParquetWriter(w io.Writer, options ParquetOptions) *parquetWriter {
+	pw := &parquetWriter{w: w, options: options}
+	for _, field := range parquetFields {
+		column := &parquetColumn{
+			path:      []string{field.name},
+			physical:  field.physical,
+			converted: field.converted,
+		}
+		if field.optional {
+			column.maxDef = 1
+		}
+		pw.columns = append(pw.columns, column)
+	}
+	if options.IncludeHeaders {
+		for _, group := range []string{"request_headers", "response_headers"} {
+			for _, leaf := range []string{"name", "value"} {
+				pw.columns = append(pw.columns, &parquetColumn{
+					path:      []string{group, leaf},
+					physical:  parquetByteArray,
+					converted: parquetUTF8,
+					maxDef:    1,
+					maxRep:    1,
+				})
+			}
+		}
+	}
+	return pw
+}
+
+// addRow 将一个条目追加到当前行组
+func (pw *parquetWriter) addRow(entry *Entries) {
+	for i, field := range parquetFields {
+		column := pw.columns[i]
+		value := field.value(entry)
+		if column.maxDef > 0 {
+			if value == nil {
+				column.defLevels = append(column.defLevels, 0)
+				continue
+			}
+			column.defLevels = append(column.defLevels, 1)
+		}
+		switch v := value.(type) {
+		case string:
+			plainByteArray(&column.values, v)
+		case int32:
+			plainInt32(&column.values, v)
+		case int64:
+			plainInt64(&column.values, v)
+		case float64:
+			plainDouble(&column.values, v)
+		}
+	}
+
+	if pw.options.IncludeHeaders {
+		base := len(parquetFields)
+		pw.addHeaders(pw.columns[base], pw.columns[base+1], entry.Request.Headers)
+		pw.addHeaders(pw.columns[base+2], pw.columns[base+3], entry.Response.Headers)
+	}
+	pw.rows++
+}
+
+// addHeaders 将头部列表写入name和value两个重复列
+func (pw *parquetWriter) addHeaders(names, values *parquetColumn, headers []Headers) {
+	if len(headers) == 0 {
+		for _, column := range []*parquetColumn{names, values} {
+			column.repLevels = append(column.repLevels, 0)
+			column.defLevels = append(column.defLevels, 0)
+		}
+		return
+	}
+	for i, header := range headers {
+		rep := 1
+		if i == 0 {
+			rep = 0
+		}
+		names.repLevels = append(names.repLevels, rep)
+		names.defLevels = append(names.defLevels, 1)
+		plainByteArray(&names.values, header.Name)
+		values.repLevels = append(values.repLevels, rep)
+		values.defLevels = append(values.defLevels, 1)
+		plainByteArray(&values.values, header.Value)
+	}
+}
+
+// flushRowGroup 将当前缓冲的行写为一个行组，每列一个数据页
+func (pw *parquetWriter) flushRowGroup() error {
+	if pw.rows == 0 {
+		return nil
+	}
+	group := parquetRowGroup{numRows: int64(pw.rows)}
+	for _, column := range pw.columns {
+		numValues := pw.rows
+		if column.maxRep > 0 {
+			numValues = len(column.repLevels)
+		}
+
+		var page bytes.Buffer
+		if column.maxRep > 0 {
+			page.Write(encodeLevels(column.repLevels, column.maxRep))
+		}
+		if column.maxDef > 0 {
+			page.Write(encodeLevels(column.defLevels, column.maxDef))
+		}
+		page.Write(column.values.Bytes())
+
+		header := &thriftWriter{}
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.structField(5, func() {
+			header.i32(1, int32(numValues))
+			header.i32(2, parquetEncodingPlain)
+			header.i32(3, parquetEncodingRLE)
+			header.i32(4, parquetEncodingRLE)
+		})
+		header.buf.WriteByte(0)
+
+		chunk := parquetChunk{
+			offset:    pw.offset,
+			size:      int64(header.buf.Len() + page.Len()),
+			numValues: int64(numValues),
+		}
+		if err := pw.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := pw.write(page.Bytes()); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.totalSize += chunk.size
+
+		column.values.Reset()
+		column.defLevels = column.defLevels[:0]
+		column.repLevels = column.repLevels[:0]
+	}
+
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.totalRows += int64(pw.rows)
+	pw.rows = 0
+	return nil
+}
+
+// writeFooter 写出FileMetaData、长度和结尾魔数
+func (pw *parquetWriter) writeFooter() error {
+	meta := &thriftWriter{}
+	meta.i32(1, 1)
+
+	// schema按深度优先顺序展开，根节点之后依次为各字段
+	type schemaElement struct {
+		name       string
+		physical   int32
+		repetition int32
+		converted  int32
+		children   int
+	}
+	topLevel := len(parquetFields)
+	if pw.options.IncludeHeaders {
+		topLevel += 2
+	}
+	elements := []schemaElement{{name: "schema", physical: -1, repetition: -1, converted: parquetNoConverted, children: topLevel}}
+	for _, field := range parquetFields {
+		repetition := parquetRequired
+		if field.optional {
+			repetition = parquetOptional
+		}
+		elements = append(elements, schemaElement{name: field.name, physical: field.physical, repetition: repetition, converted: field.converted})
+	}
+	if pw.options.IncludeHeaders {
+		for _, group := range []string{"request_headers", "response_headers"} {
+			elements = append(elements,
+				schemaElement{name: group, physical: -1, repetition: parquetRepeated, converted: parquetNoConverted, children: 2},
+				schemaElement{name: "name", physical: parquetByteArray, repetition: parquetRequired, converted: parquetUTF8},
+				schemaElement{name: "value", physical: parquetByteArray, repetition: parquetRequired, converted: parquetUTF8})
+		}
+	}
+	meta.structList(2, len(elements), func(i int) {
+		e := elements[i]
+		if e.physical >= 0 {
+			meta.i32(1, e.physical)
+		}
+		if e.repetition >= 0 {
+			meta.i32(3, e.repetition)
+		}
+		meta.str(4, e.name)
+		if e.children > 0 {
+			meta.i32(5, int32(e.children))
+		}
+		if e.converted != parquetNoConverted {
+			meta.i32(6, e.converted)
+		}
+	})
+
+	meta.i64(3, pw.totalRows)
+	meta.structList(4, len(pw.rowGroups), func(i int) {
+		group := pw.rowGroups[i]
+		meta.structList(1, len(group.chunks), func(j int) {
+			chunk := group.chunks[j]
+			column := pw.columns[j]
+			meta.i64(2, chunk.offset)
+			meta.structField(3, func() {
+				meta.i32(1, column.physical)
+				meta.i32List(2, []int32{parquetEncodingPlain, parquetEncodingRLE})
+				meta.strList(3, column.path)
+				meta.i32(4, parquetUncompressed)
+				meta.i64(5, chunk.numValues)
+				meta.i64(6, chunk.size)
+				meta.i64(7, chunk.size)
+				meta.i64(9, chunk.offset)
+			})
+		})
+		meta.i64(2, group.totalSize)
+		meta.i64(3, group.numRows)
+	})
+	meta.str(6, "go-har")
+	meta.buf.WriteByte(0)
+
+	footer := meta.buf.Bytes()
+	length := make([]byte, 4)
+	binary.LittleEndian.PutUint32(length, uint32(len(footer)))
+	if err := pw.write(footer); err != nil {
+		return err
+	}
+	if err := pw.write(length); err != nil {
+		return err
+	}
+	return pw.write([]byte(parquetMagic))
+}
+
+// write 写入数据并记录文件偏移量
+func (pw *parquetWriter) write(data []byte) error {
+	n, err := pw.w.Write(data)
+	pw.offset += int64(n)
+	if err != nil {
+		return NewFileSystemError("无法写入Parquet数据", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package har
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// Parquet物理类型
+const (
+	parquetInt32     int32 = 1
+	parquetInt64     int32 = 2
+	parquetDouble    int32 = 5
+	parquetByteArray int32 = 6
+)
+
+// Parquet字段重复类型
+const (
+	parquetRequired int32 = 0
+	parquetOptional int32 = 1
+	parquetRepeated int32 = 2
+)
+
+// Parquet转换类型，parquetNoConverted表示不写入该字段
+const (
+	parquetNoConverted     int32 = -1
+	parquetUTF8            int32 = 0
+	parquetTimestampMillis int32 = 9
+)
+
+// Parquet编码和页类型
+const (
+	parquetEncodingPlain int32 = 0
+	parquetEncodingRLE   int32 = 3
+	parquetDataPage      int32 = 0
+	parquetUncompressed  int32 = 0
+)
+
+// Thrift compact协议的字段类型
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter 以Thrift compact协议编码Parquet元数据
+type thriftWriter struct {
+	buf       bytes.Buffer
+	lastField int16
+	stack     []int16
+}
+
+// fieldHeader 写入字段头，字段ID差值较小时使用短格式
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	delta := id - t.lastField
+	if delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.lastField = id
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	t.buf.Write(tmp[:n])
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) str(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+// listHeader 写入列表字段头和元素类型
+func (t *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.varint(uint64(size))
+	}
+}
+
+func (t *thriftWriter) i32List(id int16, values []int32) {
+	t.listHeader(id, thriftI32, len(values))
+	for _, v := range values {
+		t.varint(zigzag(int64(v)))
+	}
+}
+
+func (t *thriftWriter) strList(id int16, values []string) {
+	t.listHeader(id, thriftBinary, len(values))
+	for _, v := range values {
+		t.varint(uint64(len(v)))
+		t.buf.WriteString(v)
+	}
+}
+
+// structList 写入结构体列表，每个元素由fn编码
+func (t *thriftWriter) structList(id int16, size int, fn func(i int)) {
+	t.listHeader(id, thriftStruct, size)
+	for i := 0; i < size; i++ {
+		t.beginStruct()
+		fn(i)
+		t.endStruct()
+	}
+}
+
+// structField 写入结构体类型的字段
+func (t *thriftWriter) structField(id int16, fn func()) {
+	t.fieldHeader(id, thriftStruct)
+	t.beginStruct()
+	fn()
+	t.endStruct()
+}
+
+func (t *thriftWriter) beginStruct() {
+	t.stack = append(t.stack, t.lastField)
+	t.lastField = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	t.lastField = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// encodeLevels 以RLE/bit-packing混合编码写入重复或定义级别，带4字节长度前缀
+func encodeLevels(levels []int, maxLevel int) []byte {
+	width := bits.Len(uint(maxLevel))
+	byteWidth := (width + 7) / 8
+
+	var runs bytes.Buffer
+	var tmp [binary.MaxVarintLen64]byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		n := binary.PutUvarint(tmp[:], uint64(j-i)<<1)
+		runs.Write(tmp[:n])
+		value := levels[i]
+		for b := 0; b < byteWidth; b++ {
+			runs.WriteByte(byte(value >> (8 * b)))
+		}
+		i = j
+	}
+
+	out := make([]byte, 4, 4+runs.Len())
+	binary.LittleEndian.PutUint32(out, uint32(runs.Len()))
+	return append(out, runs.Bytes()...)
+}
+
+// plainInt32 以PLAIN编码追加INT32值
+func plainInt32(buf *bytes.Buffer, v int32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(v))
+	buf.Write(b[:])
+}
+
+// plainInt64 以PLAIN编码追加INT64值
+func plainInt64(buf *bytes.Buffer, v int64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(v))
+	buf.Write(b[:])
+}
+
+// plainDouble 以PLAIN编码追加DOUBLE值
+func plainDouble(buf *bytes.Buffer, v float64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	buf.Write(b[:])
+}
+
+// plainByteArray 以PLAIN编码追加BYTE_ARRAY值
+func plainByteArray(buf *bytes.Buffer, s string) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(len(s)))
+	buf.Write(b[:])
+	buf.WriteString(s)
+}
@@ -0,0 +1,185 @@
+package har
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// thriftReader 解码Thrift compact协议，结构体解码为以字段ID为键的map，仅用于校验写出的元数据
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.data[r.pos]
+		r.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.structValue()
+	}
+	panic("unexpected thrift type")
+}
+
+func (r *thriftReader) structValue() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := r.data[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(header & 0x0f)
+		last = id
+	}
+}
+
+func TestThriftWriterGolden(t *testing.T) {
+	w := &thriftWriter{}
+	w.i32(1, 1)
+	w.str(4, "ab")
+	w.i64(20, -1)
+	w.structField(21, func() {
+		w.i32List(1, []int32{0, 3})
+	})
+	w.buf.WriteByte(0)
+	assert.Equal(t, []byte{
+		0x15, 0x02, // 字段1 i32 1
+		0x38, 0x02, 'a', 'b', // 字段4(差值3) binary
+		0x06, 0x28, 0x01, // 字段20(差值超过15使用长格式) i64 -1
+		0x1c,                   // 字段21 struct
+		0x19, 0x25, 0x00, 0x06, // 字段1 list<i32>{0, 3}
+		0x00, 0x00,
+	}, w.buf.Bytes())
+
+	// 长度为4字节前缀，之后为(游程长度<<1, 值)的RLE游程
+	assert.Equal(t, []byte{4, 0, 0, 0, 0x04, 1, 0x02, 0}, encodeLevels([]int{1, 1, 0}, 1))
+}
+
+func TestWriteParquet(t *testing.T) {
+	h := NewHar()
+	first := h.AddEntry("GET", "https://x.com/a", "HTTP/1.1", "page_1")
+	first.StartedDateTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first.SetResponseStatus(200, "OK")
+	first.SetTimings(-1, 5, 10, 1, 20, 3, -1)
+	first.AddRequestHeader("Accept", "*/*")
+	first.AddRequestHeader("Host", "x.com")
+	second := h.AddEntry("POST", "https://y.com/b", "", "")
+	second.SetResponseStatus(404, "Not Found")
+	second.SetTimings(-1, -1, -1, 1, 2, 3, -1)
+
+	var buf bytes.Buffer
+	assert.NoError(t, h.WriteParquet(&buf, ParquetOptions{IncludeHeaders: true, RowGroupSize: 1}))
+	data := buf.Bytes()
+
+	// 文件以魔数开始和结束，结尾魔数之前为4字节的footer长度
+	assert.Equal(t, parquetMagic, string(data[:4]))
+	assert.Equal(t, parquetMagic, string(data[len(data)-4:]))
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - footerLen
+	meta := (&thriftReader{data: data[:len(data)-8], pos: footerStart}).structValue()
+
+	assert.Equal(t, int64(1), meta[1], "version")
+	assert.Equal(t, int64(2), meta[3], "num_rows")
+	assert.Equal(t, "go-har", meta[6], "created_by")
+
+	// schema: 根节点、扁平列、两个头部重复组
+	schema := meta[2].([]interface{})
+	assert.Len(t, schema, 1+len(parquetFields)+2*3)
+	element := func(i int) map[int16]interface{} { return schema[i].(map[int16]interface{}) }
+	assert.Equal(t, "schema", element(0)[4])
+	assert.Equal(t, int64(len(parquetFields)+2), element(0)[5])
+	assert.Nil(t, element(0)[1])
+	for i, field := range parquetFields {
+		e := element(i + 1)
+		assert.Equal(t, field.name, e[4])
+		assert.Equal(t, int64(field.physical), e[1], field.name)
+		if field.optional {
+			assert.Equal(t, int64(parquetOptional), e[3], field.name)
+		} else {
+			assert.Equal(t, int64(parquetRequired), e[3], field.name)
+		}
+	}
+	headers := element(len(parquetFields) + 1)
+	assert.Equal(t, "request_headers", headers[4])
+	assert.Equal(t, int64(parquetRepeated), headers[3])
+	assert.Equal(t, int64(2), headers[5])
+	assert.Equal(t, "name", element(len(parquetFields) + 2)[4])
+	assert.Equal(t, int64(parquetUTF8), element(len(parquetFields) + 2)[6])
+
+	// 每行一个行组，每个列块指向一个数据页
+	rowGroups := meta[4].([]interface{})
+	if !assert.Len(t, rowGroups, 2) {
+		return
+	}
+	column := func(group, index int) (map[int16]interface{}, []byte) {
+		chunks := rowGroups[group].(map[int16]interface{})[1].([]interface{})
+		assert.Len(t, chunks, len(parquetFields)+4)
+		chunkMeta := chunks[index].(map[int16]interface{})[3].(map[int16]interface{})
+		r := &thriftReader{data: data, pos: int(chunkMeta[9].(int64))}
+		page := r.structValue()
+		assert.Equal(t, int64(parquetDataPage), page[1])
+		assert.Equal(t, chunkMeta[5], page[5].(map[int16]interface{})[1], "num_values")
+		return chunkMeta, data[r.pos : r.pos+int(page[2].(int64))]
+	}
+
+	// status为必需列，页中只有PLAIN编码的值
+	chunkMeta, page := column(1, 6)
+	assert.Equal(t, []interface{}{"status"}, chunkMeta[3])
+	assert.Equal(t, uint32(404), binary.LittleEndian.Uint32(page))
+
+	// dns为可选列，值为-1时定义级别为0且没有值
+	chunkMeta, page = column(0, 16)
+	assert.Equal(t, []interface{}{"dns"}, chunkMeta[3])
+	assert.Equal(t, append(encodeLevels([]int{1}, 1), 0, 0, 0, 0, 0, 0, 0x14, 0x40), page)
+	_, page = column(1, 16)
+	assert.Equal(t, encodeLevels([]int{0}, 1), page)
+
+	// 头部名称为重复列，依次为重复级别、定义级别和值
+	chunkMeta, page = column(0, len(parquetFields))
+	assert.Equal(t, []interface{}{"request_headers", "name"}, chunkMeta[3])
+	assert.Equal(t, int64(2), chunkMeta[5])
+	var expected bytes.Buffer
+	expected.Write(encodeLevels([]int{0, 1}, 1))
+	expected.Write(encodeLevels([]int{1, 1}, 1))
+	plainByteArray(&expected, "Accept")
+	plainByteArray(&expected, "Host")
+	assert.Equal(t, expected.Bytes(), page)
+}
@@ -1,7 +1,6 @@
 package har
 
 import (
-	"fmt"
 	"os"
 )
 
@@ -26,25 +25,30 @@ func Parse(harFileBytes []byte, opts ...Option) (HARProvider, error) {
 	// 应用选项
 	options := applyOptions(opts...)
 
-	// 验证输入
-	if err := validateInput(harFileBytes); err != nil {
-		return nil, err
+	// 验证输入，恢复模式允许输入被截断或损坏
+	if options.recover {
+		if len(harFileBytes) == 0 {
+			return nil, localizeError(newLocalizedError(ErrCodeInvalidFormat, "input.empty", nil), options.language)
+		}
+	} else if err := validateInput(harFileBytes); err != nil {
+		return nil, localizeError(err, options.language)
 	}
 
 	// 根据选项选择相应的解析方法
-	return parseWithStrategy(harFileBytes, options)
+	provider, err := parseWithStrategy(harFileBytes, options)
+	return provider, localizeError(err, options.language)
 }
 
 // validateInput 验证输入数据是否有效
 func validateInput(harFileBytes []byte) error {
 	// 检查输入是否为空
 	if len(harFileBytes) == 0 {
-		return NewInvalidFormatError("输入为空")
+		return newLocalizedError(ErrCodeInvalidFormat, "input.empty", nil)
 	}
 
 	// 检查文件是否是JSON格式
 	if !isJSONContent(harFileBytes) {
-		return NewInvalidFormatError("输入不是有效的JSON格式")
+		return newSentinelError(ErrNotJsonContent)
 	}
 
 	return nil
@@ -87,7 +91,7 @@ func ParseFile(harFilePath string, opts ...Option) (HARProvider, error) {
 	// 读取文件
 	harFileBytes, err := os.ReadFile(harFilePath)
 	if err != nil {
-		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", harFilePath), err)
+		return nil, localizeError(newLocalizedError(ErrCodeFileSystem, "file.read", err, harFilePath), applyOptions(opts...).language)
 	}
 
 	// 解析文件内容
@@ -117,6 +121,9 @@ func ParseFile(harFilePath string, opts ...Option) (HARProvider, error) {
 //	    entry := iterator.Entry()
 //	    // 处理单个条目
 //	}
+//
+// 已注册的自定义规则会在每个条目上运行，发现可以通过StreamingEntryIterator.Findings获取，
+// 默认不会终止迭代；使用WithStrictRules时错误级别的发现会作为迭代错误返回。
 func NewStreamingParser(harFileBytes []byte, opts ...Option) (EntryIterator, error) {
 	// 验证输入
 	if err := validateInput(harFileBytes); err != nil {
@@ -128,7 +135,9 @@ func NewStreamingParser(harFileBytes []byte, opts ...Option) (EntryIterator, err
 	if err != nil {
 		return nil, err
 	}
-	return streamingHar.Entries(), nil
+	it := streamingHar.Entries()
+	it.strict = applyOptions(opts...).strictRules
+	return it, nil
 }
 
 // NewStreamingParserFromFile 从文件创建一个新的流式解析器
@@ -137,7 +146,7 @@ func NewStreamingParser(harFileBytes []byte, opts ...Option) (EntryIterator, err
 func NewStreamingParserFromFile(harFilePath string, opts ...Option) (EntryIterator, error) {
 	harFileBytes, err := os.ReadFile(harFilePath)
 	if err != nil {
-		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", harFilePath), err)
+		return nil, newLocalizedError(ErrCodeFileSystem, "file.read", err, harFilePath)
 	}
 
 	return NewStreamingParser(harFileBytes, opts...)
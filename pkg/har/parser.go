@@ -10,13 +10,23 @@ import (
 
 // ParseHarWithOptions 解析HAR格式的字节数据，使用自定义解析选项
 func ParseHarWithOptions(harFileBytes []byte, options ParseOptions) (*Har, error) {
+	har, err := parseHarWithOptions(harFileBytes, options)
+	return har, localizeError(err, options.Language)
+}
+
+func parseHarWithOptions(harFileBytes []byte, options ParseOptions) (*Har, error) {
 	if len(harFileBytes) == 0 {
-		return nil, NewInvalidFormatError("输入为空")
+		return nil, newLocalizedError(ErrCodeInvalidFormat, "input.empty", nil)
+	}
+
+	// 恢复模式允许输入被截断或损坏
+	if options.Lenient && options.Recover {
+		return parseRecover(harFileBytes, options)
 	}
 
 	// 检查文件是否是JSON格式
 	if !isJSONContent(harFileBytes) {
-		return nil, NewInvalidFormatError("输入不是有效的JSON格式")
+		return nil, newSentinelError(ErrNotJsonContent)
 	}
 
 	// 如果是严格模式，直接解析
@@ -24,12 +34,15 @@ func ParseHarWithOptions(harFileBytes []byte, options ParseOptions) (*Har, error
 		har := new(Har)
 		err := json.Unmarshal(harFileBytes, har)
 		if err != nil {
-			return nil, WrapJSONUnmarshalError(err)
+			return nil, wrapJSONError(harFileBytes, err)
 		}
 
 		// 如果需要验证
 		if !options.SkipValidation {
 			if err := validateHar(har); err != nil {
+				if harErr, ok := err.(*HarError); ok {
+					return nil, newPositionLocator(harFileBytes).annotate(harErr)
+				}
 				return nil, err
 			}
 		}
@@ -45,7 +58,7 @@ func ParseHarWithOptions(harFileBytes []byte, options ParseOptions) (*Har, error
 func ParseHarFileWithOptions(harFilePath string, options ParseOptions) (*Har, error) {
 	harFileBytes, err := os.ReadFile(harFilePath)
 	if err != nil {
-		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", harFilePath), err)
+		return nil, newLocalizedError(ErrCodeFileSystem, "file.read", err, harFilePath)
 	}
 
 	har, err := ParseHarWithOptions(harFileBytes, options)
@@ -113,7 +126,7 @@ func isJSONContent(content []byte) bool {
 // 该函数现在转发到validator.go中实现的ValidateHarFile
 func validateHar(har *Har) error {
 	if har == nil {
-		return NewInvalidFormatError("HAR对象为空")
+		return newLocalizedError(ErrCodeInvalidFormat, "har.nil", nil)
 	}
 
 	return ValidateHarFile(har)
@@ -132,13 +145,45 @@ func parseLenient(harFileBytes []byte, options ParseOptions) (*Har, error) {
 	// 使用map来进行初步解析，这样即使部分字段无效也能解析其他部分
 	var rawData map[string]json.RawMessage
 	if err := json.Unmarshal(harFileBytes, &rawData); err != nil {
-		return nil, WrapJSONUnmarshalError(err)
+		return nil, wrapJSONError(harFileBytes, err)
 	}
 
 	// 跟踪所有错误
 	rootError := &HarError{
-		Code:    ErrCodeJSONParse,
-		Message: "HAR解析过程中发生错误，但部分内容已成功解析",
+		Code:       ErrCodeJSONParse,
+		Message:    localize("parse.partial"),
+		MessageKey: "parse.partial",
+	}
+
+	// locate 出错时才创建定位器，用于将错误定位到原始输入中
+	var locator *positionLocator
+	locate := func() *positionLocator {
+		if locator == nil {
+			locator = newPositionLocator(harFileBytes)
+		}
+		return locator
+	}
+
+	// fieldError 创建字段解析错误，位置为path处的值加上JSON错误在该值中的偏移
+	fieldError := func(path string, data []byte, err error, key string, params ...interface{}) *HarError {
+		harErr := newLocalizedError(ErrCodeJSONParse, key, err, params...).WithField(path)
+		if offset, ok := jsonErrorOffset(data, err); ok {
+			if base, ok := locate().offset(path); ok {
+				harErr.withPosition(locate().lines, base+offset)
+			}
+		}
+		return harErr
+	}
+
+	// decode 转换类型不符的值后解码，收集警告时记录每次转换
+	decode := func(data []byte, v interface{}, path string) error {
+		coercions, err := decodeLenient(data, v, path)
+		if options.CollectWarnings {
+			for _, coercion := range coercions {
+				rootError.AddPartialError(coercion)
+			}
+		}
+		return err
 	}
 
 	// 解析log字段
@@ -146,27 +191,25 @@ func parseLenient(harFileBytes []byte, options ParseOptions) (*Har, error) {
 		var logData map[string]json.RawMessage
 		if err := json.Unmarshal(logBytes, &logData); err != nil {
 			rootError.AddPartialError(
-				NewJSONParseError("无法解析log字段", err).WithField("log"))
+				newLocalizedError(ErrCodeJSONParse, "parse.field", err, "log").WithField("log"))
 		} else {
 			// 解析version字段
 			if versionBytes, ok := logData["version"]; ok {
 				var version string
-				if err := json.Unmarshal(versionBytes, &version); err == nil {
+				if err := decode(versionBytes, &version, "log.version"); err == nil {
 					har.Log.Version = version
 				} else {
-					rootError.AddPartialError(
-						NewJSONParseError("无法解析version字段", err).WithField("log.version"))
+					rootError.AddPartialError(fieldError("log.version", versionBytes, err, "parse.field", "version"))
 				}
 			}
 
 			// 解析creator字段
 			if creatorBytes, ok := logData["creator"]; ok {
 				var creator Creator
-				if err := json.Unmarshal(creatorBytes, &creator); err == nil {
+				if err := decode(creatorBytes, &creator, "log.creator"); err == nil {
 					har.Log.Creator = creator
 				} else {
-					rootError.AddPartialError(
-						NewJSONParseError("无法解析creator字段", err).WithField("log.creator"))
+					rootError.AddPartialError(fieldError("log.creator", creatorBytes, err, "parse.field", "creator"))
 				}
 			}
 
@@ -176,18 +219,16 @@ func parseLenient(harFileBytes []byte, options ParseOptions) (*Har, error) {
 				if err := json.Unmarshal(pagesBytes, &pages); err == nil {
 					for i, pageBytes := range pages {
 						var page Pages
-						if err := json.Unmarshal(pageBytes, &page); err == nil {
+						if err := decode(pageBytes, &page, fmt.Sprintf("log.pages[%d]", i)); err == nil {
 							har.Log.Pages = append(har.Log.Pages, page)
 						} else {
-							rootError.AddPartialError(
-								NewJSONParseError(
-									fmt.Sprintf("无法解析第%d个page", i+1), err).
-									WithField(fmt.Sprintf("log.pages[%d]", i)))
+							rootError.AddPartialError(fieldError(
+								fmt.Sprintf("log.pages[%d]", i), pageBytes, err, "parse.page", i+1))
 						}
 					}
 				} else {
 					rootError.AddPartialError(
-						NewJSONParseError("无法解析pages字段", err).WithField("log.pages"))
+						newLocalizedError(ErrCodeJSONParse, "parse.field", err, "pages").WithField("log.pages"))
 				}
 			}
 
@@ -197,18 +238,16 @@ func parseLenient(harFileBytes []byte, options ParseOptions) (*Har, error) {
 				if err := json.Unmarshal(entriesBytes, &entries); err == nil {
 					for i, entryBytes := range entries {
 						var entry Entries
-						if err := json.Unmarshal(entryBytes, &entry); err == nil {
+						if err := decode(entryBytes, &entry, fmt.Sprintf("log.entries[%d]", i)); err == nil {
 							har.Log.Entries = append(har.Log.Entries, entry)
 						} else {
-							rootError.AddPartialError(
-								NewJSONParseError(
-									fmt.Sprintf("无法解析第%d个entry", i+1), err).
-									WithField(fmt.Sprintf("log.entries[%d]", i)))
+							rootError.AddPartialError(fieldError(
+								fmt.Sprintf("log.entries[%d]", i), entryBytes, err, "parse.entry", i+1))
 						}
 					}
 				} else {
 					rootError.AddPartialError(
-						NewJSONParseError("无法解析entries字段", err).WithField("log.entries"))
+						newLocalizedError(ErrCodeJSONParse, "parse.field", err, "entries").WithField("log.entries"))
 				}
 			}
 		}
@@ -216,6 +255,11 @@ func parseLenient(harFileBytes []byte, options ParseOptions) (*Har, error) {
 		rootError.AddPartialError(NewMissingFieldError("log"))
 	}
 
+	// 其余错误和类型转换警告按字段路径定位
+	if rootError.HasPartialErrors() {
+		locate().annotate(rootError)
+	}
+
 	// 如果有错误，并且选项指定收集警告
 	if rootError.HasPartialErrors() && options.CollectWarnings {
 		// 如果解析了部分内容，返回HAR对象和错误
@@ -272,10 +316,21 @@ func ParseHarWithWarnings(harFileBytes []byte) (*Result, error) {
 		result.Warnings = appendWarnings(result.Warnings, urlWarnings)
 	}
 
-	// 如果仍未找到警告，尝试运行完整验证
+	// 如果仍未找到警告，尝试运行完整验证，否则只运行自定义规则
 	if len(result.Warnings) == 0 {
 		validationWarnings := performFullValidation(har)
 		result.Warnings = appendWarnings(result.Warnings, validationWarnings)
+	} else {
+		ruleWarnings := newRegisteredRuleValidator().Validate(har).HarErrors(SeverityWarning)
+		result.Warnings = appendWarnings(result.Warnings, ruleWarnings)
+	}
+
+	// 将校验警告定位到原始输入中，解析阶段的警告已包含位置
+	if len(result.Warnings) > 0 {
+		locator := newPositionLocator(harFileBytes)
+		for _, warning := range result.Warnings {
+			locator.annotate(warning)
+		}
 	}
 
 	return result, nil
@@ -295,28 +350,22 @@ func validateURLs(har *Har) []*HarError {
 
 		// 严格URL验证
 		if _, err := url.Parse(entry.Request.URL); err != nil {
-			urlError := NewValidationError(
-				fmt.Sprintf("无效的URL格式: %s", err.Error()),
-				fmt.Sprintf("log.entries[%d].request.url", i),
-			)
+			urlError := newLocalizedError(ErrCodeValidation, "url.invalid", nil, err.Error()).
+				WithField(fmt.Sprintf("log.entries[%d].request.url", i))
 			warnings = append(warnings, urlError)
 			continue
 		}
 
 		// 额外检查常见URL问题
 		if strings.Contains(entry.Request.URL, " ") {
-			urlError := NewValidationError(
-				fmt.Sprintf("URL包含空格: %s", entry.Request.URL),
-				fmt.Sprintf("log.entries[%d].request.url", i),
-			)
+			urlError := newLocalizedError(ErrCodeValidation, "url.space", nil, entry.Request.URL).
+				WithField(fmt.Sprintf("log.entries[%d].request.url", i))
 			warnings = append(warnings, urlError)
 		}
 
 		if !strings.Contains(entry.Request.URL, "://") {
-			urlError := NewValidationError(
-				fmt.Sprintf("URL缺少协议: %s", entry.Request.URL),
-				fmt.Sprintf("log.entries[%d].request.url", i),
-			)
+			urlError := newLocalizedError(ErrCodeValidation, "url.no_scheme", nil, entry.Request.URL).
+				WithField(fmt.Sprintf("log.entries[%d].request.url", i))
 			warnings = append(warnings, urlError)
 		}
 	}
@@ -324,25 +373,12 @@ func validateURLs(har *Har) []*HarError {
 	return warnings
 }
 
-// performFullValidation 执行完整的HAR验证，并将错误转换为警告
+// performFullValidation 执行完整的HAR验证，并将错误和警告级别的发现转换为警告
 func performFullValidation(har *Har) []*HarError {
 	if har == nil {
 		return nil
 	}
-
-	validationErr := ValidateHarFile(har)
-	if validationErr == nil {
-		return nil
-	}
-
-	if harErr, ok := validationErr.(*HarError); ok {
-		return harErr.GetPartialErrors()
-	}
-
-	// 如果是其他类型错误，包装为HarError
-	return []*HarError{
-		NewValidationError(validationErr.Error(), ""),
-	}
+	return NewValidator().Validate(har).HarErrors(SeverityWarning)
 }
 
 // appendWarnings 将新警告追加到现有警告列表，避免重复
@@ -378,7 +414,7 @@ func appendWarnings(existing []*HarError, newWarnings []*HarError) []*HarError {
 func ParseHarFileWithWarnings(harFilePath string) (*Result, error) {
 	harFileBytes, err := os.ReadFile(harFilePath)
 	if err != nil {
-		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", harFilePath), err)
+		return nil, newLocalizedError(ErrCodeFileSystem, "file.read", err, harFilePath)
 	}
 
 	return ParseHarWithWarnings(harFileBytes)
@@ -0,0 +1,426 @@
+package har
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"net"
+	"time"
+)
+
+// pcap/pcapng相关常量
+const (
+	pcapMagicMicro        = 0xa1b2c3d4
+	pcapMagicNano         = 0xa1b23c4d
+	pcapngSectionHeader   = 0x0a0d0d0a
+	pcapngByteOrderMagic  = 0x1a2b3c4d
+	pcapngInterfaceDesc   = 0x00000001
+	pcapngSimplePacket    = 0x00000003
+	pcapngEnhancedPacket  = 0x00000006
+	pcapngOptionTsResol   = 9
+	pcapngOptionEndOfOpts = 0
+)
+
+// 支持的链路层类型
+const (
+	linkTypeNull     = 0
+	linkTypeEthernet = 1
+	linkTypeRawBSD   = 12
+	linkTypeRawAlt   = 14
+	linkTypeRaw      = 101
+	linkTypeLoop     = 108
+	linkTypeLinuxSLL = 113
+	linkTypeIPv4     = 228
+	linkTypeIPv6     = 229
+	linkTypeSLL2     = 276
+)
+
+// TCP标志位
+const (
+	tcpFlagFIN = 0x01
+	tcpFlagSYN = 0x02
+	tcpFlagRST = 0x04
+	tcpFlagACK = 0x10
+)
+
+// maxPcapRecordSize 单个记录的最大长度，防止损坏文件导致过量内存分配
+const maxPcapRecordSize = 256 * 1024 * 1024
+
+// capturedPacket 表示抓包文件中的一个链路层数据包
+type capturedPacket struct {
+	Timestamp time.Time
+	LinkType  uint32
+	Data      []byte
+}
+
+// tcpSegment 表示解码后的TCP段
+type tcpSegment struct {
+	Timestamp time.Time
+	SrcIP     net.IP
+	DstIP     net.IP
+	SrcPort   uint16
+	DstPort   uint16
+	Seq       uint32
+	Ack       uint32
+	Flags     uint8
+	Payload   []byte
+}
+
+// packetReader 抓包文件读取器
+type packetReader interface {
+	// next 返回下一个数据包，结束时返回io.EOF
+	next() (*capturedPacket, error)
+}
+
+// newPacketReader 根据文件头自动识别pcap或pcapng格式
+func newPacketReader(r io.Reader) (packetReader, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, NewInvalidFormatError("抓包文件过短，无法识别格式")
+	}
+
+	switch {
+	case binary.LittleEndian.Uint32(magic) == pcapngSectionHeader:
+		return &pcapngReader{r: br}, nil
+	case binary.LittleEndian.Uint32(magic) == pcapMagicMicro ||
+		binary.LittleEndian.Uint32(magic) == pcapMagicNano ||
+		binary.BigEndian.Uint32(magic) == pcapMagicMicro ||
+		binary.BigEndian.Uint32(magic) == pcapMagicNano:
+		return newPcapReader(br)
+	default:
+		return nil, NewInvalidFormatError("不是pcap或pcapng格式的抓包文件")
+	}
+}
+
+// pcapReader 经典pcap格式读取器
+type pcapReader struct {
+	r        io.Reader
+	order    binary.ByteOrder
+	nano     bool
+	linkType uint32
+}
+
+func newPcapReader(r io.Reader) (*pcapReader, error) {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, NewInvalidFormatError("pcap文件头不完整")
+	}
+
+	pr := &pcapReader{r: r}
+	switch {
+	case binary.LittleEndian.Uint32(header) == pcapMagicMicro:
+		pr.order = binary.LittleEndian
+	case binary.LittleEndian.Uint32(header) == pcapMagicNano:
+		pr.order, pr.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(header) == pcapMagicMicro:
+		pr.order = binary.BigEndian
+	default:
+		pr.order, pr.nano = binary.BigEndian, true
+	}
+	pr.linkType = pr.order.Uint32(header[20:24]) & 0x0fffffff
+	return pr, nil
+}
+
+func (pr *pcapReader) next() (*capturedPacket, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(pr.r, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+
+	sec := pr.order.Uint32(header[0:4])
+	frac := pr.order.Uint32(header[4:8])
+	inclLen := pr.order.Uint32(header[8:12])
+	if inclLen > maxPcapRecordSize {
+		return nil, NewInvalidFormatError(fmt.Sprintf("pcap记录长度异常: %d", inclLen))
+	}
+
+	data := make([]byte, inclLen)
+	if _, err := io.ReadFull(pr.r, data); err != nil {
+		return nil, io.EOF
+	}
+
+	nsec := int64(frac) * 1000
+	if pr.nano {
+		nsec = int64(frac)
+	}
+	return &capturedPacket{
+		Timestamp: time.Unix(int64(sec), nsec).UTC(),
+		LinkType:  pr.linkType,
+		Data:      data,
+	}, nil
+}
+
+// pcapngInterface pcapng接口描述
+type pcapngInterface struct {
+	linkType uint32
+	// 每秒的时间戳单位数
+	unitsPerSecond uint64
+}
+
+// pcapngReader pcapng格式读取器
+type pcapngReader struct {
+	r          io.Reader
+	order      binary.ByteOrder
+	interfaces []pcapngInterface
+}
+
+func (pr *pcapngReader) next() (*capturedPacket, error) {
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(pr.r, header); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+
+		blockType := binary.LittleEndian.Uint32(header[0:4])
+		if blockType == pcapngSectionHeader {
+			// 节头块决定后续所有块的字节序
+			bom := make([]byte, 4)
+			if _, err := io.ReadFull(pr.r, bom); err != nil {
+				return nil, io.EOF
+			}
+			if binary.LittleEndian.Uint32(bom) == pcapngByteOrderMagic {
+				pr.order = binary.LittleEndian
+			} else if binary.BigEndian.Uint32(bom) == pcapngByteOrderMagic {
+				pr.order = binary.BigEndian
+			} else {
+				return nil, NewInvalidFormatError("pcapng字节序标记无效")
+			}
+			pr.interfaces = nil
+			length := pr.order.Uint32(header[4:8])
+			if length < 16 || length > maxPcapRecordSize {
+				return nil, NewInvalidFormatError("pcapng节头块长度无效")
+			}
+			if _, err := io.CopyN(io.Discard, pr.r, int64(length-12)); err != nil {
+				return nil, io.EOF
+			}
+			continue
+		}
+
+		if pr.order == nil {
+			return nil, NewInvalidFormatError("pcapng缺少节头块")
+		}
+		blockType = pr.order.Uint32(header[0:4])
+		length := pr.order.Uint32(header[4:8])
+		if length < 12 || length > maxPcapRecordSize {
+			return nil, NewInvalidFormatError(fmt.Sprintf("pcapng块长度异常: %d", length))
+		}
+		body := make([]byte, length-8)
+		if _, err := io.ReadFull(pr.r, body); err != nil {
+			return nil, io.EOF
+		}
+		// 去掉结尾的重复长度字段
+		body = body[:len(body)-4]
+
+		switch blockType {
+		case pcapngInterfaceDesc:
+			iface, err := pr.parseInterface(body)
+			if err != nil {
+				return nil, err
+			}
+			pr.interfaces = append(pr.interfaces, iface)
+		case pcapngEnhancedPacket:
+			if packet := pr.parseEnhancedPacket(body); packet != nil {
+				return packet, nil
+			}
+		case pcapngSimplePacket:
+			if len(body) < 4 || len(pr.interfaces) == 0 {
+				continue
+			}
+			// 简单包块没有时间戳
+			return &capturedPacket{LinkType: pr.interfaces[0].linkType, Data: body[4:]}, nil
+		}
+	}
+}
+
+// parseInterface 解析接口描述块，if_tsresol超出uint64范围时返回错误
+func (pr *pcapngReader) parseInterface(body []byte) (pcapngInterface, error) {
+	iface := pcapngInterface{unitsPerSecond: 1000000}
+	if len(body) < 8 {
+		return iface, nil
+	}
+	iface.linkType = uint32(pr.order.Uint16(body[0:2]))
+
+	options := body[8:]
+	for len(options) >= 4 {
+		code := pr.order.Uint16(options[0:2])
+		size := int(pr.order.Uint16(options[2:4]))
+		if code == pcapngOptionEndOfOpts || 4+size > len(options) {
+			break
+		}
+		if code == pcapngOptionTsResol && size >= 1 {
+			resol := options[4]
+			exponent := int(resol & 0x7f)
+			var units uint64 = 1
+			if resol&0x80 != 0 {
+				// 2^64及以上溢出为0，之后按时间戳单位相除会导致除零
+				if exponent >= 64 {
+					return iface, NewInvalidFormatError(fmt.Sprintf("pcapng时间戳精度无效: 2^-%d", exponent))
+				}
+				units <<= uint(exponent)
+			} else {
+				// uint64最多表示10^19
+				if exponent > 19 {
+					return iface, NewInvalidFormatError(fmt.Sprintf("pcapng时间戳精度无效: 10^-%d", exponent))
+				}
+				for i := 0; i < exponent; i++ {
+					units *= 10
+				}
+			}
+			iface.unitsPerSecond = units
+		}
+		// 选项值按4字节对齐
+		options = options[4+(size+3)&^3:]
+	}
+	return iface, nil
+}
+
+// parseEnhancedPacket 解析增强包块
+func (pr *pcapngReader) parseEnhancedPacket(body []byte) *capturedPacket {
+	if len(body) < 20 {
+		return nil
+	}
+	ifaceID := pr.order.Uint32(body[0:4])
+	if int(ifaceID) >= len(pr.interfaces) {
+		return nil
+	}
+	iface := pr.interfaces[ifaceID]
+
+	ts := uint64(pr.order.Uint32(body[4:8]))<<32 | uint64(pr.order.Uint32(body[8:12]))
+	capLen := int(pr.order.Uint32(body[12:16]))
+	if 20+capLen > len(body) {
+		capLen = len(body) - 20
+	}
+
+	sec := ts / iface.unitsPerSecond
+	rem := ts % iface.unitsPerSecond
+	// 精度高于纳秒时rem*10^9会溢出uint64，使用128位中间结果
+	hi, lo := bits.Mul64(rem, uint64(time.Second))
+	nsec, _ := bits.Div64(hi, lo, iface.unitsPerSecond)
+
+	return &capturedPacket{
+		Timestamp: time.Unix(int64(sec), int64(nsec)).UTC(),
+		LinkType:  iface.linkType,
+		Data:      body[20 : 20+capLen],
+	}
+}
+
+// decodeTCPSegment 从链路层数据包中解码TCP段，非TCP数据包返回nil
+func decodeTCPSegment(packet *capturedPacket) *tcpSegment {
+	data := packet.Data
+	var etherType uint16
+
+	switch packet.LinkType {
+	case linkTypeEthernet:
+		if len(data) < 14 {
+			return nil
+		}
+		etherType = binary.BigEndian.Uint16(data[12:14])
+		data = data[14:]
+		// 跳过VLAN标签
+		for (etherType == 0x8100 || etherType == 0x88a8) && len(data) >= 4 {
+			etherType = binary.BigEndian.Uint16(data[2:4])
+			data = data[4:]
+		}
+	case linkTypeLinuxSLL:
+		if len(data) < 16 {
+			return nil
+		}
+		etherType = binary.BigEndian.Uint16(data[14:16])
+		data = data[16:]
+	case linkTypeSLL2:
+		if len(data) < 20 {
+			return nil
+		}
+		etherType = binary.BigEndian.Uint16(data[0:2])
+		data = data[20:]
+	case linkTypeNull, linkTypeLoop:
+		if len(data) < 4 {
+			return nil
+		}
+		data = data[4:]
+		etherType = ipEtherType(data)
+	case linkTypeRaw, linkTypeRawBSD, linkTypeRawAlt, linkTypeIPv4, linkTypeIPv6:
+		etherType = ipEtherType(data)
+	default:
+		return nil
+	}
+
+	segment := &tcpSegment{Timestamp: packet.Timestamp}
+	var payload []byte
+
+	switch etherType {
+	case 0x0800:
+		if len(data) < 20 || data[0]>>4 != 4 {
+			return nil
+		}
+		ihl := int(data[0]&0x0f) * 4
+		total := int(binary.BigEndian.Uint16(data[2:4]))
+		if data[9] != 6 || ihl < 20 || len(data) < ihl {
+			return nil
+		}
+		// 分片数据包不做重组
+		if binary.BigEndian.Uint16(data[6:8])&0x3fff != 0 {
+			return nil
+		}
+		if total >= ihl && total <= len(data) {
+			data = data[:total]
+		}
+		segment.SrcIP = net.IP(append([]byte(nil), data[12:16]...))
+		segment.DstIP = net.IP(append([]byte(nil), data[16:20]...))
+		payload = data[ihl:]
+	case 0x86dd:
+		if len(data) < 40 || data[0]>>4 != 6 {
+			return nil
+		}
+		if data[6] != 6 {
+			return nil
+		}
+		plen := int(binary.BigEndian.Uint16(data[4:6]))
+		segment.SrcIP = net.IP(append([]byte(nil), data[8:24]...))
+		segment.DstIP = net.IP(append([]byte(nil), data[24:40]...))
+		payload = data[40:]
+		if plen <= len(payload) {
+			payload = payload[:plen]
+		}
+	default:
+		return nil
+	}
+
+	if len(payload) < 20 {
+		return nil
+	}
+	offset := int(payload[12]>>4) * 4
+	if offset < 20 || offset > len(payload) {
+		return nil
+	}
+	segment.SrcPort = binary.BigEndian.Uint16(payload[0:2])
+	segment.DstPort = binary.BigEndian.Uint16(payload[2:4])
+	segment.Seq = binary.BigEndian.Uint32(payload[4:8])
+	segment.Ack = binary.BigEndian.Uint32(payload[8:12])
+	segment.Flags = payload[13]
+	segment.Payload = payload[offset:]
+	return segment
+}
+
+// ipEtherType 根据IP版本号推断以太网类型
+func ipEtherType(data []byte) uint16 {
+	if len(data) == 0 {
+		return 0
+	}
+	switch data[0] >> 4 {
+	case 4:
+		return 0x0800
+	case 6:
+		return 0x86dd
+	}
+	return 0
+}
@@ -0,0 +1,270 @@
+package har
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+// HTTP/2帧类型
+const (
+	http2FrameData         = 0x0
+	http2FrameHeaders      = 0x1
+	http2FrameContinuation = 0x9
+)
+
+// HTTP/2帧标志
+const (
+	http2FlagEndStream  = 0x1
+	http2FlagEndHeaders = 0x4
+	http2FlagPadded     = 0x8
+	http2FlagPriority   = 0x20
+)
+
+// http2ClientPreface HTTP/2连接前言
+const http2ClientPreface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// http2Message 一个HTTP/2流在单个方向上的消息
+type http2Message struct {
+	headers    []Headers
+	body       []byte
+	firstByte  time.Time
+	lastByte   time.Time
+	headerSize int
+	bodySize   int
+	complete   bool
+}
+
+// http2Stream 表示一个HTTP/2流的请求和响应
+type http2Stream struct {
+	id       uint32
+	request  http2Message
+	response http2Message
+}
+
+// looksLikeHTTP2Preface 检查数据是否以HTTP/2客户端前言开头
+func looksLikeHTTP2Preface(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(http2ClientPreface))
+}
+
+// http2Entries 解析解密后的HTTP/2连接并生成条目
+func (x *httpExchange) http2Entries() []Entries {
+	client := x.clientStream.data
+	if !looksLikeHTTP2Preface(client) {
+		return nil
+	}
+
+	streams := make(map[uint32]*http2Stream)
+	parseHTTP2Frames(x.clientStream, len(http2ClientPreface), streams, true)
+	parseHTTP2Frames(x.serverStream, 0, streams, false)
+
+	ids := make([]uint32, 0, len(streams))
+	for id, stream := range streams {
+		if len(stream.request.headers) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var entries []Entries
+	for i, id := range ids {
+		stream := streams[id]
+		req, resp := stream.request, stream.response
+
+		method := pseudoHeader(req.headers, ":method")
+		authority := pseudoHeader(req.headers, ":authority")
+		scheme := pseudoHeader(req.headers, ":scheme")
+		if scheme == "" {
+			scheme = x.scheme
+		}
+		rawURL := scheme + "://" + authority + pseudoHeader(req.headers, ":path")
+
+		entry := x.newEntry(method, rawURL, "HTTP/2.0")
+		entry.Request.Headers = req.headers
+		entry.Request.QueryString = queryStringFromURL(rawURL)
+		entry.Request.Cookies = cookiesFromHeaders(req.headers, "cookie")
+		entry.Request.HeadersSize = req.headerSize
+		entry.Request.BodySize = req.bodySize
+		if len(req.body) > 0 {
+			entry.Request.PostData = map[string]interface{}{
+				"mimeType": headerValue(req.headers, "content-type"),
+				"text":     string(req.body),
+			}
+		}
+
+		if len(resp.headers) > 0 {
+			status, _ := strconv.Atoi(pseudoHeader(resp.headers, ":status"))
+			entry.Response.Status = status
+			entry.Response.HTTPVersion = "HTTP/2.0"
+			entry.Response.Headers = resp.headers
+			entry.Response.Cookies = cookiesFromHeaders(resp.headers, "set-cookie")
+			entry.Response.RedirectURL = headerValue(resp.headers, "location")
+			entry.Response.HeadersSize = resp.headerSize
+			entry.Response.BodySize = resp.bodySize
+			entry.Response.TransferSize = resp.headerSize + resp.bodySize
+			entry.Response.Content = buildContent(resp.body, headerValue(resp.headers, "content-type"),
+				headerValue(resp.headers, "content-encoding"), x.options.MaxBodySize)
+		}
+
+		x.finishEntry(&entry, i, req.firstByte, req.lastByte, resp.firstByte, resp.lastByte)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// parseHTTP2Frames 解析单个方向的HTTP/2帧，并将头部和数据归入对应的流
+func parseHTTP2Frames(stream *reassembledStream, offset int, streams map[uint32]*http2Stream, fromClient bool) {
+	data := stream.data
+	decoder := hpack.NewDecoder(4096, nil)
+
+	var headerBlock []byte
+	var headerStream uint32
+	var headerFlags uint8
+
+	for offset+9 <= len(data) {
+		length := int(data[offset])<<16 | int(data[offset+1])<<8 | int(data[offset+2])
+		frameType := data[offset+3]
+		flags := data[offset+4]
+		streamID := binary.BigEndian.Uint32(data[offset+5:offset+9]) & 0x7fffffff
+		if offset+9+length > len(data) {
+			break
+		}
+		payload := data[offset+9 : offset+9+length]
+		frameStart := stream.timeAt(offset)
+		frameEnd := stream.timeAt(offset + 9 + length - 1)
+		offset += 9 + length
+
+		if streamID == 0 {
+			continue
+		}
+		s := streams[streamID]
+		if s == nil {
+			s = &http2Stream{id: streamID}
+			streams[streamID] = s
+		}
+		msg := &s.response
+		if fromClient {
+			msg = &s.request
+		}
+
+		switch frameType {
+		case http2FrameHeaders:
+			fragment := stripHTTP2Padding(payload, flags)
+			if flags&http2FlagPriority != 0 && len(fragment) >= 5 {
+				fragment = fragment[5:]
+			}
+			headerBlock = append(headerBlock[:0], fragment...)
+			headerStream = streamID
+			headerFlags = flags
+			if msg.firstByte.IsZero() {
+				msg.firstByte = frameStart
+			}
+			msg.headerSize += length + 9
+		case http2FrameContinuation:
+			if streamID != headerStream {
+				continue
+			}
+			headerBlock = append(headerBlock, payload...)
+			headerFlags |= flags & http2FlagEndHeaders
+			msg.headerSize += length + 9
+		case http2FrameData:
+			body := stripHTTP2Padding(payload, flags)
+			msg.body = append(msg.body, body...)
+			msg.bodySize += len(body)
+			if msg.firstByte.IsZero() {
+				msg.firstByte = frameStart
+			}
+			msg.lastByte = frameEnd
+			if flags&http2FlagEndStream != 0 {
+				msg.complete = true
+			}
+			continue
+		default:
+			continue
+		}
+
+		if headerFlags&http2FlagEndHeaders == 0 {
+			continue
+		}
+		fields, err := decoder.DecodeFull(headerBlock)
+		headerBlock = headerBlock[:0]
+		if err != nil {
+			// 头部压缩状态损坏后无法继续解析该方向
+			return
+		}
+
+		headers := make([]Headers, 0, len(fields))
+		for _, field := range fields {
+			headers = append(headers, Headers{Name: field.Name, Value: field.Value})
+		}
+		// 1xx信息性响应之后的头部替换之前的内容，尾部头部则追加
+		if !fromClient && strings.HasPrefix(pseudoHeader(msg.headers, ":status"), "1") {
+			msg.headers = nil
+		}
+		msg.headers = append(msg.headers, headers...)
+		msg.lastByte = frameEnd
+		if headerFlags&http2FlagEndStream != 0 {
+			msg.complete = true
+		}
+	}
+}
+
+// stripHTTP2Padding 去除帧的填充字节
+func stripHTTP2Padding(payload []byte, flags uint8) []byte {
+	if flags&http2FlagPadded == 0 || len(payload) == 0 {
+		return payload
+	}
+	pad := int(payload[0])
+	if 1+pad > len(payload) {
+		return nil
+	}
+	return payload[1 : len(payload)-pad]
+}
+
+// pseudoHeader 返回HTTP/2伪头部的值
+func pseudoHeader(headers []Headers, name string) string {
+	for _, header := range headers {
+		if header.Name == name {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// headerValue 不区分大小写地返回第一个匹配头部的值
+func headerValue(headers []Headers, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+// cookiesFromHeaders 从Cookie或Set-Cookie头部解析Cookie
+func cookiesFromHeaders(headers []Headers, name string) []Cookie {
+	cookies := []Cookie{}
+	for _, header := range headers {
+		if !strings.EqualFold(header.Name, name) {
+			continue
+		}
+		if strings.EqualFold(name, "set-cookie") {
+			if c := parseSetCookie(header.Value); c != nil {
+				cookies = append(cookies, *c)
+			}
+			continue
+		}
+		for _, part := range strings.Split(header.Value, ";") {
+			cname, cvalue, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if ok && cname != "" {
+				cookies = append(cookies, Cookie{Name: cname, Value: cvalue})
+			}
+		}
+	}
+	return cookies
+}
@@ -0,0 +1,719 @@
+package har
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PcapOptions pcap导入选项
+type PcapOptions struct {
+	// 仅处理这些服务端端口上的连接，为空表示不限制
+	Ports []int
+	// NSS格式的TLS密钥日志文件路径(SSLKEYLOGFILE)，为空时跳过TLS流量
+	KeyLogFile string
+	// 最大响应体大小(字节)，超过部分不写入Content.Text，0表示不限制
+	MaxBodySize int
+	// 设置后，每个因密码套件不受支持或缺少会话密钥而无法解密的TLS连接都会调用一次
+	OnWarning func(warning *HarError)
+}
+
+// DefaultPcapOptions 默认的pcap导入选项
+func DefaultPcapOptions() PcapOptions {
+	return PcapOptions{}
+}
+
+// tcpStreamKey 标识TCP连接的一个方向
+type tcpStreamKey struct {
+	srcIP, dstIP     string
+	srcPort, dstPort uint16
+}
+
+// reverse 返回相反方向的键
+func (k tcpStreamKey) reverse() tcpStreamKey {
+	return tcpStreamKey{srcIP: k.dstIP, dstIP: k.srcIP, srcPort: k.dstPort, dstPort: k.srcPort}
+}
+
+// tcpHalfStream 连接中单个方向的数据
+type tcpHalfStream struct {
+	key      tcpStreamKey
+	isn      uint32
+	synSeen  bool
+	segments []tcpSegment
+}
+
+// tcpConnection 一个完整的TCP连接
+type tcpConnection struct {
+	index      int
+	client     *tcpHalfStream
+	server     *tcpHalfStream
+	synTime    time.Time
+	synAckTime time.Time
+}
+
+// reassembledStream 重组后的单向字节流，以及每个字节区间首次出现的时间
+type reassembledStream struct {
+	data     []byte
+	offsets  []int
+	stamps   []time.Time
+	lastTime time.Time
+}
+
+// timeAt 返回字节偏移量所在数据段的抓包时间
+func (s *reassembledStream) timeAt(offset int) time.Time {
+	if len(s.offsets) == 0 {
+		return time.Time{}
+	}
+	i := sort.Search(len(s.offsets), func(i int) bool { return s.offsets[i] > offset })
+	if i == 0 {
+		return s.stamps[0]
+	}
+	return s.stamps[i-1]
+}
+
+// ImportPcapFile 从pcap或pcapng文件导入HTTP流量并生成HAR
+//
+// 该函数使用纯Go实现解析抓包文件，重组TCP流并解析其中的HTTP/1.x请求和响应。
+// 设置PcapOptions.KeyLogFile后，使用AES-GCM或ChaCha20-Poly1305套件的TLS 1.2/1.3会话会被解密，
+// 其中的HTTP/1.1和HTTP/2交换同样转换为条目，Timings.Ssl取自握手记录的时间戳。
+// 条目的计时信息来自数据包时间戳：connect取自SYN到SYN-ACK的间隔，
+// wait取自请求最后一个字节到响应第一个字节的间隔。
+//
+// 示例:
+//
+//	h, err := ImportPcapFile("capture.pcapng", DefaultPcapOptions())
+//	if err != nil {
+//	    log.Fatalf("导入抓包文件失败: %v", err)
+//	}
+func ImportPcapFile(filePath string, options PcapOptions) (*Har, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	h, err := ImportPcap(file, options)
+	if err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return nil, err
+	}
+	return h, nil
+}
+
+// ImportPcap 从pcap或pcapng数据流导入HTTP流量并生成HAR
+func ImportPcap(r io.Reader, options PcapOptions) (*Har, error) {
+	reader, err := newPacketReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyLog *tlsKeyLog
+	if options.KeyLogFile != "" {
+		keyLog, err = loadTLSKeyLogFile(options.KeyLogFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	connections, err := collectTCPConnections(reader, options)
+	if err != nil {
+		return nil, err
+	}
+
+	h := NewHar()
+	h.SetCreator("go-har pcap importer", "1.0")
+	for _, conn := range connections {
+		h.Log.Entries = append(h.Log.Entries, conn.toEntries(keyLog, options)...)
+	}
+
+	sort.SliceStable(h.Log.Entries, func(i, j int) bool {
+		return h.Log.Entries[i].StartedDateTime.Before(h.Log.Entries[j].StartedDateTime)
+	})
+	return h, nil
+}
+
+// collectTCPConnections 读取所有数据包并按连接分组
+func collectTCPConnections(reader packetReader, options PcapOptions) ([]*tcpConnection, error) {
+	allowed := make(map[uint16]bool, len(options.Ports))
+	for _, port := range options.Ports {
+		allowed[uint16(port)] = true
+	}
+
+	streams := make(map[tcpStreamKey]*tcpConnection)
+	var connections []*tcpConnection
+
+	for {
+		packet, err := reader.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		segment := decodeTCPSegment(packet)
+		if segment == nil {
+			continue
+		}
+		if len(allowed) > 0 && !allowed[segment.SrcPort] && !allowed[segment.DstPort] {
+			continue
+		}
+
+		key := tcpStreamKey{
+			srcIP:   segment.SrcIP.String(),
+			dstIP:   segment.DstIP.String(),
+			srcPort: segment.SrcPort,
+			dstPort: segment.DstPort,
+		}
+
+		conn, ok := streams[key]
+		isSyn := segment.Flags&tcpFlagSYN != 0 && segment.Flags&tcpFlagACK == 0
+		if ok && isSyn && conn.client != nil && conn.client.key == key && conn.client.synSeen &&
+			segment.Seq != conn.client.isn {
+			// 相同四元组上的新连接
+			ok = false
+		}
+		if !ok {
+			conn = &tcpConnection{index: len(connections)}
+			connections = append(connections, conn)
+			streams[key] = conn
+			streams[key.reverse()] = conn
+		}
+
+		half := conn.halfFor(key, segment)
+		switch {
+		case isSyn:
+			half.synSeen = true
+			half.isn = segment.Seq
+			if conn.synTime.IsZero() {
+				conn.synTime = segment.Timestamp
+			}
+		case segment.Flags&tcpFlagSYN != 0:
+			half.synSeen = true
+			half.isn = segment.Seq
+			if conn.synAckTime.IsZero() {
+				conn.synAckTime = segment.Timestamp
+			}
+		}
+
+		if len(segment.Payload) > 0 {
+			half.segments = append(half.segments, *segment)
+		}
+	}
+
+	return connections, nil
+}
+
+// halfFor 返回数据段所属方向的半连接，必要时根据SYN确定客户端和服务端
+func (c *tcpConnection) halfFor(key tcpStreamKey, segment *tcpSegment) *tcpHalfStream {
+	for _, half := range []*tcpHalfStream{c.client, c.server} {
+		if half != nil && half.key == key {
+			return half
+		}
+	}
+
+	half := &tcpHalfStream{key: key}
+	isSyn := segment.Flags&tcpFlagSYN != 0 && segment.Flags&tcpFlagACK == 0
+	isSynAck := segment.Flags&tcpFlagSYN != 0 && segment.Flags&tcpFlagACK != 0
+
+	switch {
+	case isSyn && c.client == nil:
+		c.client = half
+	case isSynAck && c.server == nil:
+		c.server = half
+	case c.client == nil && c.server != nil:
+		c.client = half
+	case c.server == nil && c.client != nil:
+		c.server = half
+	case looksLikeHTTPRequest(segment.Payload):
+		c.client = half
+	case key.srcPort < key.dstPort:
+		// 缺少握手信息时假设端口较小的一侧是服务端
+		c.server = half
+	default:
+		c.client = half
+	}
+	return half
+}
+
+// reassemble 按序号排列数据段并去除重传，生成连续字节流
+func (h *tcpHalfStream) reassemble() *reassembledStream {
+	stream := &reassembledStream{}
+	if h == nil || len(h.segments) == 0 {
+		return stream
+	}
+
+	base := h.isn + 1
+	if !h.synSeen {
+		base = h.segments[0].Seq
+		for _, segment := range h.segments[1:] {
+			if int32(segment.Seq-base) < 0 {
+				base = segment.Seq
+			}
+		}
+	}
+
+	segments := make([]tcpSegment, len(h.segments))
+	copy(segments, h.segments)
+	sort.SliceStable(segments, func(i, j int) bool {
+		return int32(segments[i].Seq-base) < int32(segments[j].Seq-base)
+	})
+
+	for _, segment := range segments {
+		rel := int(int32(segment.Seq - base))
+		payload := segment.Payload
+		if rel < len(stream.data) {
+			// 重传或部分重叠的数据
+			skip := len(stream.data) - rel
+			if skip >= len(payload) {
+				continue
+			}
+			payload = payload[skip:]
+		}
+		// 数据缺失时直接拼接，尽量保留后续内容
+		stream.offsets = append(stream.offsets, len(stream.data))
+		stream.stamps = append(stream.stamps, segment.Timestamp)
+		stream.data = append(stream.data, payload...)
+		if segment.Timestamp.After(stream.lastTime) {
+			stream.lastTime = segment.Timestamp
+		}
+	}
+	return stream
+}
+
+// toEntries 将连接中的HTTP交换转换为HAR条目
+func (c *tcpConnection) toEntries(keyLog *tlsKeyLog, options PcapOptions) []Entries {
+	if c.client == nil || c.server == nil {
+		return nil
+	}
+
+	clientStream := c.client.reassemble()
+	serverStream := c.server.reassemble()
+	if len(clientStream.data) == 0 {
+		return nil
+	}
+
+	exchange := &httpExchange{
+		conn:         c,
+		clientStream: clientStream,
+		serverStream: serverStream,
+		scheme:       "http",
+		sslTime:      -1,
+		options:      options,
+	}
+
+	if looksLikeTLSClientHello(clientStream.data) {
+		// 没有密钥日志或无法解密的TLS流量直接跳过
+		if keyLog == nil {
+			return nil
+		}
+		decrypted, warning := decryptTLSConnection(clientStream, serverStream, keyLog)
+		if warning != nil {
+			if options.OnWarning != nil {
+				server := c.server.key
+				options.OnWarning(warning.
+					WithMetadata("connection", strconv.Itoa(c.index)).
+					WithMetadata("server", net.JoinHostPort(server.srcIP, strconv.Itoa(int(server.srcPort)))))
+			}
+			return nil
+		}
+		exchange.clientStream = decrypted.client
+		exchange.serverStream = decrypted.server
+		exchange.scheme = "https"
+		exchange.sslTime = decrypted.handshakeTime
+		if decrypted.alpn == "h2" || looksLikeHTTP2Preface(decrypted.client.data) {
+			return exchange.http2Entries()
+		}
+	}
+
+	// 非HTTP流量直接跳过
+	if !looksLikeHTTPRequest(exchange.clientStream.data) {
+		return nil
+	}
+	return exchange.http1Entries()
+}
+
+// httpExchange 保存单个连接上解析HTTP所需的上下文
+type httpExchange struct {
+	conn         *tcpConnection
+	clientStream *reassembledStream
+	serverStream *reassembledStream
+	scheme       string
+	sslTime      float64
+	options      PcapOptions
+}
+
+// http1Entries 解析连接中的HTTP/1.x请求响应对
+func (x *httpExchange) http1Entries() []Entries {
+	var entries []Entries
+
+	reqReader := newOffsetReader(x.clientStream.data)
+	respReader := newOffsetReader(x.serverStream.data)
+
+	for i := 0; ; i++ {
+		reqStart := reqReader.offset()
+		req, err := http.ReadRequest(reqReader.br)
+		if err != nil {
+			break
+		}
+		reqHeaderEnd := reqStart + headerBlockLength(x.clientStream.data[reqStart:])
+		reqBody, _ := io.ReadAll(req.Body)
+		req.Body.Close()
+		reqEnd := reqReader.offset()
+
+		entry := x.newEntry(req.Method, x.requestURL(req), req.Proto)
+		entry.Request.Headers = rawHeaders(x.clientStream.data[reqStart:reqHeaderEnd])
+		entry.Request.Cookies = requestCookies(req)
+		entry.Request.QueryString = queryStringFromURL(entry.Request.URL)
+		entry.Request.HeadersSize = reqHeaderEnd - reqStart
+		entry.Request.BodySize = reqEnd - reqHeaderEnd
+		if len(reqBody) > 0 {
+			entry.Request.PostData = map[string]interface{}{
+				"mimeType": req.Header.Get("Content-Type"),
+				"text":     string(reqBody),
+			}
+		}
+
+		reqFirst := x.clientStream.timeAt(reqStart)
+		reqLast := x.clientStream.timeAt(reqEnd - 1)
+
+		// 读取响应，跳过1xx中间响应
+		var resp *http.Response
+		var respStart, respHeaderEnd int
+		for {
+			respStart = respReader.offset()
+			resp, err = http.ReadResponse(respReader.br, req)
+			if err != nil {
+				resp = nil
+				break
+			}
+			respHeaderEnd = respStart + headerBlockLength(x.serverStream.data[respStart:])
+			if resp.StatusCode >= 200 || resp.StatusCode == http.StatusSwitchingProtocols {
+				break
+			}
+			resp.Body.Close()
+		}
+
+		if resp == nil {
+			x.finishEntry(&entry, i, reqFirst, reqLast, time.Time{}, time.Time{})
+			entries = append(entries, entry)
+			break
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		respEnd := respReader.offset()
+
+		entry.Response.Status = resp.StatusCode
+		entry.Response.StatusText = strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode)))
+		entry.Response.HTTPVersion = resp.Proto
+		entry.Response.Headers = rawHeaders(x.serverStream.data[respStart:respHeaderEnd])
+		entry.Response.Cookies = responseCookies(resp)
+		entry.Response.RedirectURL = resp.Header.Get("Location")
+		entry.Response.HeadersSize = respHeaderEnd - respStart
+		entry.Response.BodySize = respEnd - respHeaderEnd
+		entry.Response.TransferSize = respEnd - respStart
+		entry.Response.Content = buildContent(body, resp.Header.Get("Content-Type"),
+			resp.Header.Get("Content-Encoding"), x.options.MaxBodySize)
+
+		x.finishEntry(&entry, i, reqFirst, reqLast,
+			x.serverStream.timeAt(respStart), x.serverStream.timeAt(respEnd-1))
+		entries = append(entries, entry)
+
+		if resp.StatusCode == http.StatusSwitchingProtocols {
+			// 协议升级后的数据不再是HTTP/1.x
+			break
+		}
+	}
+
+	return entries
+}
+
+// newEntry 创建带有连接信息的空条目
+func (x *httpExchange) newEntry(method, rawURL, proto string) Entries {
+	return Entries{
+		Request: Request{
+			Method:      method,
+			URL:         rawURL,
+			HTTPVersion: proto,
+			Cookies:     []Cookie{},
+			Headers:     []Headers{},
+			QueryString: []Headers{},
+		},
+		Response: Response{
+			HTTPVersion: proto,
+			Cookies:     []Cookie{},
+			Headers:     []Headers{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		ServerIPAddress: x.conn.server.key.srcIP,
+		Connection:      strconv.Itoa(x.conn.index),
+	}
+}
+
+// finishEntry 根据数据包时间戳计算条目的开始时间和各阶段计时
+func (x *httpExchange) finishEntry(entry *Entries, index int, reqFirst, reqLast, respFirst, respLast time.Time) {
+	timings := Timings{Blocked: -1, DNS: -1, Connect: -1, Ssl: -1, Send: 0, Wait: -1, Receive: -1}
+	start := reqFirst
+
+	// 只有连接上的第一个请求包含建连时间
+	if index == 0 && !x.conn.synTime.IsZero() && !x.conn.synAckTime.IsZero() {
+		timings.Connect = durationMs(x.conn.synAckTime.Sub(x.conn.synTime))
+		start = x.conn.synTime
+		if x.sslTime >= 0 {
+			timings.Ssl = x.sslTime
+			// HAR规范中connect包含ssl时间
+			timings.Connect += x.sslTime
+		}
+	}
+
+	if !reqFirst.IsZero() && !reqLast.IsZero() {
+		timings.Send = durationMs(reqLast.Sub(reqFirst))
+	}
+	if !respFirst.IsZero() && !reqLast.IsZero() {
+		timings.Wait = durationMs(respFirst.Sub(reqLast))
+		if timings.Wait < 0 {
+			timings.Wait = 0
+		}
+	}
+	if !respFirst.IsZero() && !respLast.IsZero() {
+		timings.Receive = durationMs(respLast.Sub(respFirst))
+	}
+
+	entry.StartedDateTime = start
+	entry.Timings = timings
+	entry.Time = timingsTotal(timings)
+}
+
+// timingsTotal 计算非负计时阶段之和，ssl已包含在connect中不重复计算
+func timingsTotal(t Timings) float64 {
+	var total float64
+	for _, v := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if v > 0 {
+			total += v
+		}
+	}
+	return total
+}
+
+// durationMs 将time.Duration转换为毫秒浮点数
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// requestURL 构造请求的完整URL
+func (x *httpExchange) requestURL(req *http.Request) string {
+	if req.URL != nil && req.URL.IsAbs() {
+		return req.URL.String()
+	}
+	host := req.Host
+	if host == "" {
+		host = net.JoinHostPort(x.conn.server.key.srcIP, strconv.Itoa(int(x.conn.server.key.srcPort)))
+	}
+	return x.scheme + "://" + host + req.RequestURI
+}
+
+// offsetReader 在bufio.Reader之上跟踪已消费的字节偏移量
+type offsetReader struct {
+	src *bytes.Reader
+	br  *bufio.Reader
+	n   int
+}
+
+func newOffsetReader(data []byte) *offsetReader {
+	src := bytes.NewReader(data)
+	return &offsetReader{src: src, br: bufio.NewReader(src), n: len(data)}
+}
+
+// offset 返回已被解析器消费的字节数
+func (r *offsetReader) offset() int {
+	return r.n - r.src.Len() - r.br.Buffered()
+}
+
+// headerBlockLength 返回HTTP消息起始行和头部(含空行)的长度
+func headerBlockLength(data []byte) int {
+	if i := bytes.Index(data, []byte("\r\n\r\n")); i >= 0 {
+		return i + 4
+	}
+	if i := bytes.Index(data, []byte("\n\n")); i >= 0 {
+		return i + 2
+	}
+	return len(data)
+}
+
+// rawHeaders 按原始顺序和大小写解析头部块
+func rawHeaders(block []byte) []Headers {
+	headers := []Headers{}
+	lines := strings.Split(string(block), "\n")
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		headers = append(headers, Headers{Name: strings.TrimSpace(name), Value: strings.TrimSpace(value)})
+	}
+	return headers
+}
+
+// requestCookies 提取请求Cookie
+func requestCookies(req *http.Request) []Cookie {
+	cookies := []Cookie{}
+	for _, c := range req.Cookies() {
+		cookies = append(cookies, Cookie{Name: c.Name, Value: c.Value})
+	}
+	return cookies
+}
+
+// responseCookies 提取响应Set-Cookie
+func responseCookies(resp *http.Response) []Cookie {
+	cookies := []Cookie{}
+	for _, c := range resp.Cookies() {
+		cookies = append(cookies, convertHTTPCookie(c))
+	}
+	return cookies
+}
+
+// convertHTTPCookie 将net/http的Cookie转换为HAR Cookie
+func convertHTTPCookie(c *http.Cookie) Cookie {
+	cookie := Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		Expires:  c.Expires,
+		HTTPOnly: c.HttpOnly,
+		Secure:   c.Secure,
+	}
+	switch c.SameSite {
+	case http.SameSiteLaxMode:
+		cookie.SameSite = "Lax"
+	case http.SameSiteStrictMode:
+		cookie.SameSite = "Strict"
+	case http.SameSiteNoneMode:
+		cookie.SameSite = "None"
+	}
+	return cookie
+}
+
+// parseSetCookie 解析单个Set-Cookie头部值
+func parseSetCookie(value string) *Cookie {
+	resp := &http.Response{Header: http.Header{"Set-Cookie": {value}}}
+	cookies := resp.Cookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+	cookie := convertHTTPCookie(cookies[0])
+	return &cookie
+}
+
+// queryStringFromURL 从URL解析查询参数
+func queryStringFromURL(rawURL string) []Headers {
+	params := []Headers{}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return params
+	}
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if v, err := url.QueryUnescape(value); err == nil {
+			value = v
+		}
+		params = append(params, Headers{Name: name, Value: value})
+	}
+	return params
+}
+
+// buildContent 解码Content-Encoding并构造响应内容，二进制内容使用base64编码
+func buildContent(body []byte, contentType, contentEncoding string, maxBodySize int) Content {
+	decoded := body
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "gzip", "x-gzip":
+		if zr, err := gzip.NewReader(bytes.NewReader(body)); err == nil {
+			if data, err := io.ReadAll(zr); err == nil {
+				decoded = data
+			}
+		}
+	case "deflate":
+		if data, err := inflateBody(body); err == nil {
+			decoded = data
+		}
+	}
+
+	mimeType := contentType
+	if mimeType == "" && len(decoded) > 0 {
+		mimeType = http.DetectContentType(decoded)
+	}
+	content := Content{Size: len(decoded), MimeType: mimeType}
+	if len(decoded) == 0 || (maxBodySize > 0 && len(decoded) > maxBodySize) {
+		return content
+	}
+
+	if utf8.Valid(decoded) && isTextualMimeType(mimeType) {
+		content.Text = string(decoded)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(decoded)
+		content.Encoding = "base64"
+	}
+	return content
+}
+
+// inflateBody 解码deflate内容编码。RFC 9110规定deflate为zlib格式，
+// 部分服务器发送不带zlib头的原始DEFLATE数据，zlib头无效时按原始DEFLATE解码
+func inflateBody(body []byte) ([]byte, error) {
+	if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+		defer zr.Close()
+		return io.ReadAll(zr)
+	}
+	return io.ReadAll(flate.NewReader(bytes.NewReader(body)))
+}
+
+// isTextualMimeType 判断MIME类型是否为文本
+func isTextualMimeType(mimeType string) bool {
+	m := strings.ToLower(mimeType)
+	if m == "" {
+		return true
+	}
+	for _, marker := range []string{"text/", "json", "xml", "javascript", "ecmascript", "x-www-form-urlencoded", "svg", "graphql"} {
+		if strings.Contains(m, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeHTTPRequest 检查数据是否以HTTP请求行开头
+func looksLikeHTTPRequest(data []byte) bool {
+	for _, method := range []string{"GET ", "POST ", "PUT ", "DELETE ", "HEAD ", "OPTIONS ", "PATCH ", "CONNECT ", "TRACE "} {
+		if bytes.HasPrefix(data, []byte(method)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package har
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pcapBuilder 在内存中构造经典pcap格式的以太网/IPv4/TCP抓包数据
+type pcapBuilder struct {
+	buf bytes.Buffer
+}
+
+func newPcapBuilder() *pcapBuilder {
+	b := &pcapBuilder{}
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:4], pcapMagicMicro)
+	binary.LittleEndian.PutUint16(header[4:6], 2)
+	binary.LittleEndian.PutUint16(header[6:8], 4)
+	binary.LittleEndian.PutUint32(header[16:20], 65535)
+	binary.LittleEndian.PutUint32(header[20:24], linkTypeEthernet)
+	b.buf.Write(header)
+	return b
+}
+
+func (b *pcapBuilder) add(ts time.Time, src, dst string, sport, dport uint16, seq, ack uint32, flags uint8, payload string) {
+	tcp := make([]byte, 20+len(payload))
+	binary.BigEndian.PutUint16(tcp[0:2], sport)
+	binary.BigEndian.PutUint16(tcp[2:4], dport)
+	binary.BigEndian.PutUint32(tcp[4:8], seq)
+	binary.BigEndian.PutUint32(tcp[8:12], ack)
+	tcp[12] = 5 << 4
+	tcp[13] = flags
+	copy(tcp[20:], payload)
+
+	ip := make([]byte, 20+len(tcp))
+	ip[0] = 0x45
+	binary.BigEndian.PutUint16(ip[2:4], uint16(len(ip)))
+	ip[8] = 64
+	ip[9] = 6
+	copy(ip[12:16], net.ParseIP(src).To4())
+	copy(ip[16:20], net.ParseIP(dst).To4())
+	copy(ip[20:], tcp)
+
+	frame := make([]byte, 14+len(ip))
+	binary.BigEndian.PutUint16(frame[12:14], 0x0800)
+	copy(frame[14:], ip)
+
+	record := make([]byte, 16)
+	binary.LittleEndian.PutUint32(record[0:4], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(record[8:12], uint32(len(frame)))
+	binary.LittleEndian.PutUint32(record[12:16], uint32(len(frame)))
+	b.buf.Write(record)
+	b.buf.Write(frame)
+}
+
+func TestImportPcap(t *testing.T) {
+	base := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return base.Add(time.Duration(ms) * time.Millisecond) }
+
+	client, server := "10.0.0.1", "10.0.0.2"
+	request := "POST /api/items?page=2 HTTP/1.1\r\nHost: example.com\r\nCookie: sid=abc\r\nContent-Length: 7\r\n\r\n{\"a\":1}"
+	response := "HTTP/1.1 201 Created\r\nContent-Type: application/json\r\nContent-Length: 11\r\n\r\n{\"id\":\"42\"}"
+
+	b := newPcapBuilder()
+	b.add(at(0), client, server, 50000, 80, 1000, 0, tcpFlagSYN, "")
+	b.add(at(10), server, client, 80, 50000, 5000, 1001, tcpFlagSYN|tcpFlagACK, "")
+	b.add(at(11), client, server, 50000, 80, 1001, 5001, tcpFlagACK, "")
+	b.add(at(12), client, server, 50000, 80, 1001, 5001, tcpFlagACK, request[:40])
+	b.add(at(14), client, server, 50000, 80, 1041, 5001, tcpFlagACK, request[40:])
+	// 重传的数据段应被忽略
+	b.add(at(15), client, server, 50000, 80, 1041, 5001, tcpFlagACK, request[40:])
+	b.add(at(64), server, client, 80, 50000, 5001, uint32(1001+len(request)), tcpFlagACK, response)
+
+	h, err := ImportPcap(&b.buf, DefaultPcapOptions())
+	assert.NoError(t, err)
+	if !assert.Len(t, h.Log.Entries, 1) {
+		return
+	}
+
+	entry := h.Log.Entries[0]
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, "http://example.com/api/items?page=2", entry.Request.URL)
+	assert.Equal(t, []Headers{{Name: "page", Value: "2"}}, entry.Request.QueryString)
+	assert.Equal(t, "sid", entry.Request.Cookies[0].Name)
+	assert.Equal(t, 7, entry.Request.BodySize)
+	assert.Equal(t, 201, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, `{"id":"42"}`, entry.Response.Content.Text)
+	assert.Equal(t, "10.0.0.2", entry.ServerIPAddress)
+	assert.Equal(t, "0", entry.Connection)
+	assert.Equal(t, at(0), entry.StartedDateTime)
+	assert.InDelta(t, 10, entry.Timings.Connect, 0.001)
+	assert.InDelta(t, 2, entry.Timings.Send, 0.001)
+	assert.InDelta(t, 50, entry.Timings.Wait, 0.001)
+	assert.InDelta(t, 62, entry.Time, 0.001)
+}
+
+func TestImportPcapInvalid(t *testing.T) {
+	_, err := ImportPcap(bytes.NewReader([]byte("not a capture file")), DefaultPcapOptions())
+	assert.Error(t, err)
+}
+
+// pcapngBlock 构造小端序的pcapng块，body按4字节对齐
+func pcapngBlock(blockType uint32, body []byte) []byte {
+	padded := make([]byte, (len(body)+3)&^3)
+	copy(padded, body)
+	block := make([]byte, 12+len(padded))
+	binary.LittleEndian.PutUint32(block[0:4], blockType)
+	binary.LittleEndian.PutUint32(block[4:8], uint32(len(block)))
+	copy(block[8:], padded)
+	binary.LittleEndian.PutUint32(block[len(block)-4:], uint32(len(block)))
+	return block
+}
+
+// newPcapngWithResolution 构造包含一个接口和一个数据包的pcapng文件，接口使用指定的if_tsresol
+func newPcapngWithResolution(resol byte, ts uint64) []byte {
+	var buf bytes.Buffer
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:4], pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:6], 1)
+	binary.LittleEndian.PutUint64(shb[8:16], ^uint64(0))
+	buf.Write(pcapngBlock(pcapngSectionHeader, shb))
+
+	idb := make([]byte, 16)
+	binary.LittleEndian.PutUint16(idb[0:2], linkTypeEthernet)
+	binary.LittleEndian.PutUint16(idb[8:10], pcapngOptionTsResol)
+	binary.LittleEndian.PutUint16(idb[10:12], 1)
+	idb[12] = resol
+	buf.Write(pcapngBlock(pcapngInterfaceDesc, idb))
+
+	epb := make([]byte, 20+14)
+	binary.LittleEndian.PutUint32(epb[4:8], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(epb[8:12], uint32(ts))
+	binary.LittleEndian.PutUint32(epb[12:16], 14)
+	binary.LittleEndian.PutUint32(epb[16:20], 14)
+	buf.Write(pcapngBlock(pcapngEnhancedPacket, epb))
+	return buf.Bytes()
+}
+
+func TestPcapngTimestampResolution(t *testing.T) {
+	// 纳秒精度
+	reader, err := newPacketReader(bytes.NewReader(newPcapngWithResolution(9, 1500000000)))
+	assert.NoError(t, err)
+	packet, err := reader.next()
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Unix(1, 500000000).UTC(), packet.Timestamp)
+	}
+
+	// 10^-19秒精度，rem*10^9超出uint64
+	reader, _ = newPacketReader(bytes.NewReader(newPcapngWithResolution(19, 15000000000000000000)))
+	packet, err = reader.next()
+	if assert.NoError(t, err) {
+		assert.Equal(t, time.Unix(1, 500000000).UTC(), packet.Timestamp)
+	}
+
+	// 超出uint64范围的精度返回格式错误而不是除零
+	for _, resol := range []byte{20, 0x80 | 64, 0xff} {
+		_, err := ImportPcap(bytes.NewReader(newPcapngWithResolution(resol, 1)), DefaultPcapOptions())
+		if assert.Error(t, err, "if_tsresol %#x", resol) {
+			assert.Equal(t, ErrCodeInvalidFormat, err.(*HarError).Code)
+		}
+	}
+}
@@ -0,0 +1,532 @@
+package har
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// TLS记录类型
+const (
+	tlsRecordChangeCipherSpec = 20
+	tlsRecordAlert            = 21
+	tlsRecordHandshake        = 22
+	tlsRecordApplicationData  = 23
+)
+
+// TLS握手消息类型
+const (
+	tlsHandshakeClientHello = 1
+	tlsHandshakeServerHello = 2
+)
+
+// tlsExtensionSupportedVersions supported_versions扩展编号
+const tlsExtensionSupportedVersions = 0x002b
+
+// tlsKeyLog NSS密钥日志中的密钥，按client_random索引
+type tlsKeyLog struct {
+	secrets map[string]map[string][]byte
+}
+
+// tlsCipherSuite 支持解密的AEAD密码套件
+type tlsCipherSuite struct {
+	keyLen int
+	hash   func() hash.Hash
+	aead   func(key []byte) (cipher.AEAD, error)
+	// TLS 1.2下密钥块中隐式IV的长度：AES-GCM为4字节并在记录中携带8字节显式nonce，
+	// ChaCha20-Poly1305为12字节且nonce由序列号异或得到
+	fixedIVLen int
+}
+
+// tlsCipherSuites 支持的密码套件
+var tlsCipherSuites = map[uint16]tlsCipherSuite{
+	// TLS 1.3
+	0x1301: {keyLen: 16, hash: sha256.New, aead: newAESGCM},
+	0x1302: {keyLen: 32, hash: sha512.New384, aead: newAESGCM},
+	0x1303: {keyLen: 32, hash: sha256.New, aead: chacha20poly1305.New},
+	// TLS 1.2 AES-GCM
+	0x009c: {keyLen: 16, hash: sha256.New, aead: newAESGCM, fixedIVLen: 4},
+	0x009d: {keyLen: 32, hash: sha512.New384, aead: newAESGCM, fixedIVLen: 4},
+	0xc02b: {keyLen: 16, hash: sha256.New, aead: newAESGCM, fixedIVLen: 4},
+	0xc02c: {keyLen: 32, hash: sha512.New384, aead: newAESGCM, fixedIVLen: 4},
+	0xc02f: {keyLen: 16, hash: sha256.New, aead: newAESGCM, fixedIVLen: 4},
+	0xc030: {keyLen: 32, hash: sha512.New384, aead: newAESGCM, fixedIVLen: 4},
+	// TLS 1.2 ChaCha20-Poly1305
+	0xcca8: {keyLen: 32, hash: sha256.New, aead: chacha20poly1305.New, fixedIVLen: 12},
+	0xcca9: {keyLen: 32, hash: sha256.New, aead: chacha20poly1305.New, fixedIVLen: 12},
+	0xccaa: {keyLen: 32, hash: sha256.New, aead: chacha20poly1305.New, fixedIVLen: 12},
+}
+
+// LoadTLSKeyLog 检查NSS密钥日志文件(SSLKEYLOGFILE)是否可以被解析
+//
+// 返回文件中包含的会话数量，可用于在导入前确认密钥日志可用。
+func LoadTLSKeyLog(filePath string) (int, error) {
+	keyLog, err := loadTLSKeyLogFile(filePath)
+	if err != nil {
+		return 0, err
+	}
+	return len(keyLog.secrets), nil
+}
+
+// loadTLSKeyLogFile 读取NSS密钥日志文件
+func loadTLSKeyLogFile(filePath string) (*tlsKeyLog, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取密钥日志文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+	return parseTLSKeyLog(file)
+}
+
+// parseTLSKeyLog 解析NSS密钥日志格式，每行为"<标签> <client_random> <密钥>"
+func parseTLSKeyLog(r io.Reader) (*tlsKeyLog, error) {
+	keyLog := &tlsKeyLog{secrets: make(map[string]map[string][]byte)}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 3 {
+			return nil, NewInvalidFormatError(fmt.Sprintf("密钥日志第%d行格式无效", line))
+		}
+		random, err := hex.DecodeString(fields[1])
+		if err != nil || len(random) != 32 {
+			return nil, NewInvalidFormatError(fmt.Sprintf("密钥日志第%d行client_random无效", line))
+		}
+		secret, err := hex.DecodeString(fields[2])
+		if err != nil {
+			return nil, NewInvalidFormatError(fmt.Sprintf("密钥日志第%d行密钥无效", line))
+		}
+
+		key := string(random)
+		if keyLog.secrets[key] == nil {
+			keyLog.secrets[key] = make(map[string][]byte)
+		}
+		keyLog.secrets[key][fields[0]] = secret
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, NewFileSystemError("无法读取密钥日志", err)
+	}
+	return keyLog, nil
+}
+
+// tlsRecord 表示一条TLS记录及其在原始流中的时间
+type tlsRecord struct {
+	contentType uint8
+	version     uint16
+	header      []byte
+	payload     []byte
+	timestamp   time.Time
+}
+
+// splitTLSRecords 将重组后的字节流拆分为TLS记录
+func splitTLSRecords(stream *reassembledStream) []tlsRecord {
+	var records []tlsRecord
+	data := stream.data
+	for offset := 0; offset+5 <= len(data); {
+		length := int(binary.BigEndian.Uint16(data[offset+3 : offset+5]))
+		if offset+5+length > len(data) {
+			break
+		}
+		records = append(records, tlsRecord{
+			contentType: data[offset],
+			version:     binary.BigEndian.Uint16(data[offset+1 : offset+3]),
+			header:      data[offset : offset+5],
+			payload:     data[offset+5 : offset+5+length],
+			timestamp:   stream.timeAt(offset + 5 + length - 1),
+		})
+		offset += 5 + length
+	}
+	return records
+}
+
+// tlsHelloInfo 从明文握手消息中提取的信息
+type tlsHelloInfo struct {
+	clientRandom []byte
+	serverRandom []byte
+	cipherSuite  uint16
+	tls13        bool
+	alpn         string
+}
+
+// parseHello 从客户端和服务端的明文握手记录中提取随机数和协商参数
+func parseHello(clientRecords, serverRecords []tlsRecord) (*tlsHelloInfo, bool) {
+	info := &tlsHelloInfo{}
+	for _, record := range clientRecords {
+		if record.contentType != tlsRecordHandshake {
+			continue
+		}
+		msg := record.payload
+		if len(msg) >= 38 && msg[0] == tlsHandshakeClientHello {
+			info.clientRandom = msg[6:38]
+			break
+		}
+	}
+	for _, record := range serverRecords {
+		if record.contentType != tlsRecordHandshake {
+			continue
+		}
+		msg := record.payload
+		if len(msg) < 39 || msg[0] != tlsHandshakeServerHello {
+			continue
+		}
+		info.serverRandom = msg[6:38]
+		body := msg[38:]
+		sessionLen := int(body[0])
+		if len(body) < 1+sessionLen+3 {
+			return nil, false
+		}
+		body = body[1+sessionLen:]
+		info.cipherSuite = binary.BigEndian.Uint16(body[0:2])
+		body = body[3:]
+		if len(body) >= 2 {
+			extLen := int(binary.BigEndian.Uint16(body[0:2]))
+			exts := body[2:]
+			if extLen < len(exts) {
+				exts = exts[:extLen]
+			}
+			for len(exts) >= 4 {
+				typ := binary.BigEndian.Uint16(exts[0:2])
+				size := int(binary.BigEndian.Uint16(exts[2:4]))
+				if 4+size > len(exts) {
+					break
+				}
+				value := exts[4 : 4+size]
+				switch typ {
+				case tlsExtensionSupportedVersions:
+					if size == 2 && binary.BigEndian.Uint16(value) == 0x0304 {
+						info.tls13 = true
+					}
+				case 0x0010:
+					// TLS 1.2的ALPN出现在ServerHello中
+					if size >= 4 {
+						n := int(value[2])
+						if 3+n <= len(value) {
+							info.alpn = string(value[3 : 3+n])
+						}
+					}
+				}
+				exts = exts[4+size:]
+			}
+		}
+		break
+	}
+	return info, info.clientRandom != nil && info.serverRandom != nil
+}
+
+// tlsDirectionKeys 单个方向上按阶段排列的密钥
+type tlsDirectionKeys struct {
+	aeads    []cipher.AEAD
+	ivs      [][]byte
+	tls12    bool
+	current  int
+	seq      uint64
+	started  bool
+	appIndex int
+	// TLS 1.2记录是否以8字节显式nonce开头
+	explicitNonce bool
+}
+
+// decryptedTLS 解密结果
+type decryptedTLS struct {
+	client        *reassembledStream
+	server        *reassembledStream
+	handshakeTime float64
+	alpn          string
+}
+
+// decryptTLSConnection 使用密钥日志解密连接两个方向的TLS流量，
+// 无法解密时返回说明原因的错误
+func decryptTLSConnection(clientStream, serverStream *reassembledStream, keyLog *tlsKeyLog) (*decryptedTLS, *HarError) {
+	clientRecords := splitTLSRecords(clientStream)
+	serverRecords := splitTLSRecords(serverStream)
+
+	hello, ok := parseHello(clientRecords, serverRecords)
+	if !ok {
+		return nil, NewInvalidFormatError("无法解析TLS握手")
+	}
+	suite, ok := tlsCipherSuites[hello.cipherSuite]
+	if !ok {
+		return nil, NewUnsupportedError(fmt.Sprintf("不支持的TLS密码套件 0x%04x", hello.cipherSuite)).
+			WithMetadata("cipherSuite", hello.cipherSuite)
+	}
+	secrets := keyLog.secrets[string(hello.clientRandom)]
+	if secrets == nil {
+		return nil, NewInvalidValueError("keyLogFile", hex.EncodeToString(hello.clientRandom), "密钥日志中没有该会话的密钥")
+	}
+
+	var clientKeys, serverKeys *tlsDirectionKeys
+	if hello.tls13 {
+		clientKeys = tls13Keys(suite, secrets["CLIENT_HANDSHAKE_TRAFFIC_SECRET"], secrets["CLIENT_TRAFFIC_SECRET_0"])
+		serverKeys = tls13Keys(suite, secrets["SERVER_HANDSHAKE_TRAFFIC_SECRET"], secrets["SERVER_TRAFFIC_SECRET_0"])
+	} else {
+		master := secrets["CLIENT_RANDOM"]
+		if master == nil {
+			return nil, NewInvalidValueError("keyLogFile", hex.EncodeToString(hello.clientRandom), "密钥日志中没有该会话的密钥")
+		}
+		clientKeys, serverKeys = tls12Keys(suite, master, hello.clientRandom, hello.serverRandom)
+	}
+	if clientKeys == nil || serverKeys == nil {
+		return nil, NewInvalidValueError("keyLogFile", hex.EncodeToString(hello.clientRandom), "密钥日志中缺少该会话的流量密钥")
+	}
+
+	client, clientHandshakeEnd := decryptTLSRecords(clientRecords, clientKeys)
+	server, serverHandshakeEnd := decryptTLSRecords(serverRecords, serverKeys)
+
+	result := &decryptedTLS{client: client, server: server, handshakeTime: -1, alpn: hello.alpn}
+	handshakeEnd := clientHandshakeEnd
+	if serverHandshakeEnd.After(handshakeEnd) {
+		handshakeEnd = serverHandshakeEnd
+	}
+	if len(clientRecords) > 0 && !handshakeEnd.IsZero() {
+		result.handshakeTime = durationMs(handshakeEnd.Sub(clientRecords[0].timestamp))
+	}
+	return result, nil
+}
+
+// tls13Keys 根据TLS 1.3流量密钥派生AEAD
+func tls13Keys(suite tlsCipherSuite, handshakeSecret, trafficSecret []byte) *tlsDirectionKeys {
+	if trafficSecret == nil {
+		return nil
+	}
+	keys := &tlsDirectionKeys{}
+	for _, secret := range [][]byte{handshakeSecret, trafficSecret} {
+		if secret == nil {
+			continue
+		}
+		key := hkdfExpandLabel(suite.hash, secret, "key", suite.keyLen)
+		iv := hkdfExpandLabel(suite.hash, secret, "iv", 12)
+		aead, err := suite.aead(key)
+		if err != nil {
+			return nil
+		}
+		keys.aeads = append(keys.aeads, aead)
+		keys.ivs = append(keys.ivs, iv)
+	}
+	keys.appIndex = len(keys.aeads) - 1
+	keys.started = true
+	return keys
+}
+
+// tls12Keys 根据TLS 1.2主密钥派生客户端和服务端的AEAD密钥
+func tls12Keys(suite tlsCipherSuite, master, clientRandom, serverRandom []byte) (*tlsDirectionKeys, *tlsDirectionKeys) {
+	seed := append(append([]byte{}, serverRandom...), clientRandom...)
+	keyLen, ivLen := suite.keyLen, suite.fixedIVLen
+	block := tls12PRF(suite.hash, master, "key expansion", seed, 2*keyLen+2*ivLen)
+
+	clientKey := block[:keyLen]
+	serverKey := block[keyLen : 2*keyLen]
+	clientIV := block[2*keyLen : 2*keyLen+ivLen]
+	serverIV := block[2*keyLen+ivLen : 2*keyLen+2*ivLen]
+
+	clientAEAD, err := suite.aead(clientKey)
+	if err != nil {
+		return nil, nil
+	}
+	serverAEAD, err := suite.aead(serverKey)
+	if err != nil {
+		return nil, nil
+	}
+	explicitNonce := ivLen < clientAEAD.NonceSize()
+	return &tlsDirectionKeys{aeads: []cipher.AEAD{clientAEAD}, ivs: [][]byte{clientIV}, tls12: true, explicitNonce: explicitNonce},
+		&tlsDirectionKeys{aeads: []cipher.AEAD{serverAEAD}, ivs: [][]byte{serverIV}, tls12: true, explicitNonce: explicitNonce}
+}
+
+// decryptTLSRecords 解密单个方向的记录，返回应用数据流和握手结束时间
+func decryptTLSRecords(records []tlsRecord, keys *tlsDirectionKeys) (*reassembledStream, time.Time) {
+	stream := &reassembledStream{}
+	var handshakeEnd time.Time
+	appStarted := false
+
+	for _, record := range records {
+		switch record.contentType {
+		case tlsRecordChangeCipherSpec:
+			if keys.tls12 {
+				keys.started = true
+				keys.seq = 0
+			}
+			if !appStarted {
+				handshakeEnd = record.timestamp
+			}
+			continue
+		case tlsRecordHandshake, tlsRecordAlert:
+			// TLS 1.3的加密记录外层类型总是application_data
+			if !keys.tls12 || !keys.started {
+				if !appStarted {
+					handshakeEnd = record.timestamp
+				}
+				continue
+			}
+		case tlsRecordApplicationData:
+			if !keys.started {
+				continue
+			}
+		default:
+			continue
+		}
+
+		contentType, plaintext, ok := keys.open(record)
+		if !ok {
+			continue
+		}
+
+		if contentType == tlsRecordApplicationData {
+			appStarted = true
+			stream.offsets = append(stream.offsets, len(stream.data))
+			stream.stamps = append(stream.stamps, record.timestamp)
+			stream.data = append(stream.data, plaintext...)
+			stream.lastTime = record.timestamp
+		} else if !appStarted && (keys.tls12 || keys.current < keys.appIndex) {
+			// 加密的握手消息(如Finished)仍属于握手阶段
+			handshakeEnd = record.timestamp
+		}
+	}
+
+	return stream, handshakeEnd
+}
+
+// open 解密一条记录，TLS 1.3下在握手密钥失败时切换到应用流量密钥
+func (k *tlsDirectionKeys) open(record tlsRecord) (uint8, []byte, bool) {
+	if k.tls12 {
+		var nonce, ciphertext []byte
+		if k.explicitNonce {
+			if len(record.payload) < 8+16 {
+				return 0, nil, false
+			}
+			ciphertext = record.payload[8:]
+			nonce = append(append([]byte{}, k.ivs[0]...), record.payload[:8]...)
+		} else {
+			if len(record.payload) < 16 {
+				return 0, nil, false
+			}
+			ciphertext = record.payload
+			nonce = tlsNonce(k.ivs[0], k.seq)
+		}
+
+		aad := make([]byte, 13)
+		binary.BigEndian.PutUint64(aad[0:8], k.seq)
+		aad[8] = record.contentType
+		binary.BigEndian.PutUint16(aad[9:11], record.version)
+		binary.BigEndian.PutUint16(aad[11:13], uint16(len(ciphertext)-16))
+
+		plaintext, err := k.aeads[0].Open(nil, nonce, ciphertext, aad)
+		if err != nil {
+			return 0, nil, false
+		}
+		k.seq++
+		return record.contentType, plaintext, true
+	}
+
+	for i := k.current; i < len(k.aeads); i++ {
+		seq := k.seq
+		if i != k.current {
+			seq = 0
+		}
+		plaintext, err := k.aeads[i].Open(nil, tlsNonce(k.ivs[i], seq), record.payload, record.header)
+		if err != nil {
+			continue
+		}
+		k.current = i
+		k.seq = seq + 1
+
+		// 去除填充，最后一个非零字节是真实的内容类型
+		end := len(plaintext)
+		for end > 0 && plaintext[end-1] == 0 {
+			end--
+		}
+		if end == 0 {
+			return 0, nil, false
+		}
+		return plaintext[end-1], plaintext[:end-1], true
+	}
+	return 0, nil, false
+}
+
+// tlsNonce 将序列号异或到12字节IV的末尾得到每条记录的nonce
+func tlsNonce(iv []byte, seq uint64) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, iv)
+	var seqBytes [8]byte
+	binary.BigEndian.PutUint64(seqBytes[:], seq)
+	for j := 0; j < 8; j++ {
+		nonce[4+j] ^= seqBytes[j]
+	}
+	return nonce
+}
+
+// newAESGCM 创建AES-GCM AEAD
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// hkdfExpandLabel 实现RFC 8446中的HKDF-Expand-Label(上下文为空)
+func hkdfExpandLabel(h func() hash.Hash, secret []byte, label string, length int) []byte {
+	fullLabel := "tls13 " + label
+	info := make([]byte, 0, 4+len(fullLabel))
+	info = append(info, byte(length>>8), byte(length))
+	info = append(info, byte(len(fullLabel)))
+	info = append(info, fullLabel...)
+	info = append(info, 0)
+	return hkdfExpand(h, secret, info, length)
+}
+
+// hkdfExpand 实现RFC 5869中的HKDF-Expand
+func hkdfExpand(h func() hash.Hash, prk, info []byte, length int) []byte {
+	var out, previous []byte
+	for counter := byte(1); len(out) < length; counter++ {
+		mac := hmac.New(h, prk)
+		mac.Write(previous)
+		mac.Write(info)
+		mac.Write([]byte{counter})
+		previous = mac.Sum(nil)
+		out = append(out, previous...)
+	}
+	return out[:length]
+}
+
+// tls12PRF 实现RFC 5246中的P_hash伪随机函数
+func tls12PRF(h func() hash.Hash, secret []byte, label string, seed []byte, length int) []byte {
+	labelSeed := append([]byte(label), seed...)
+	var out bytes.Buffer
+
+	mac := hmac.New(h, secret)
+	mac.Write(labelSeed)
+	a := mac.Sum(nil)
+	for out.Len() < length {
+		mac.Reset()
+		mac.Write(a)
+		mac.Write(labelSeed)
+		out.Write(mac.Sum(nil))
+
+		mac.Reset()
+		mac.Write(a)
+		a = mac.Sum(nil)
+	}
+	return out.Bytes()[:length]
+}
+
+// looksLikeTLSClientHello 检查数据是否以TLS ClientHello记录开头
+func looksLikeTLSClientHello(data []byte) bool {
+	return len(data) >= 6 && data[0] == tlsRecordHandshake && data[1] == 0x03 && data[5] == tlsHandshakeClientHello
+}
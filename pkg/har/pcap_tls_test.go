@@ -0,0 +1,275 @@
+package har
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+// recordedCapture 记录两个方向的写入，用于生成抓包文件
+type recordedCapture struct {
+	mu      sync.Mutex
+	builder *pcapBuilder
+	base    time.Time
+	tick    int
+	seq     map[bool]uint32
+}
+
+func newRecordedCapture() *recordedCapture {
+	c := &recordedCapture{
+		builder: newPcapBuilder(),
+		base:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		seq:     map[bool]uint32{true: 1001, false: 5001},
+	}
+	c.builder.add(c.base, "10.0.0.1", "10.0.0.2", 50000, 443, 1000, 0, tcpFlagSYN, "")
+	c.builder.add(c.base.Add(5*time.Millisecond), "10.0.0.2", "10.0.0.1", 443, 50000, 5000, 1001, tcpFlagSYN|tcpFlagACK, "")
+	c.tick = 10
+	return c
+}
+
+func (c *recordedCapture) record(fromClient bool, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick++
+	ts := c.base.Add(time.Duration(c.tick) * time.Millisecond)
+	if fromClient {
+		c.builder.add(ts, "10.0.0.1", "10.0.0.2", 50000, 443, c.seq[true], c.seq[false], tcpFlagACK, string(data))
+	} else {
+		c.builder.add(ts, "10.0.0.2", "10.0.0.1", 443, 50000, c.seq[false], c.seq[true], tcpFlagACK, string(data))
+	}
+	c.seq[fromClient] += uint32(len(data))
+}
+
+// recordingConn 在写入时记录数据
+type recordingConn struct {
+	net.Conn
+	capture    *recordedCapture
+	fromClient bool
+}
+
+func (r *recordingConn) Write(p []byte) (int, error) {
+	r.capture.record(r.fromClient, p)
+	return r.Conn.Write(p)
+}
+
+func testCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// runTLSExchange 在内存管道上建立TLS会话，并返回抓包数据和密钥日志路径
+func runTLSExchange(t *testing.T, maxVersion, cipherSuite uint16, nextProto string,
+	clientFn func(conn *tls.Conn), serverFn func(conn *tls.Conn)) ([]byte, string) {
+	capture := newRecordedCapture()
+	keyLog := &bytes.Buffer{}
+
+	clientPipe, serverPipe := net.Pipe()
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{testCertificate(t)},
+		MaxVersion:   maxVersion,
+		CipherSuites: []uint16{cipherSuite},
+	}
+	clientConfig := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         "example.com",
+		MaxVersion:         maxVersion,
+		KeyLogWriter:       keyLog,
+		CipherSuites:       []uint16{cipherSuite},
+	}
+	if nextProto != "" {
+		serverConfig.NextProtos = []string{nextProto}
+		clientConfig.NextProtos = []string{nextProto}
+	}
+
+	server := tls.Server(&recordingConn{Conn: serverPipe, capture: capture}, serverConfig)
+	client := tls.Client(&recordingConn{Conn: clientPipe, capture: capture, fromClient: true}, clientConfig)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		serverFn(server)
+		// 直接关闭底层管道，避免close_notify在对端不读取时阻塞
+		serverPipe.Close()
+	}()
+	clientFn(client)
+	clientPipe.Close()
+	wg.Wait()
+
+	path := filepath.Join(t.TempDir(), "keys.log")
+	assert.NoError(t, os.WriteFile(path, keyLog.Bytes(), 0600))
+	return capture.builder.buf.Bytes(), path
+}
+
+func http1Client(conn *tls.Conn) {
+	io.WriteString(conn, "GET /secure?x=1 HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	buf := make([]byte, 4096)
+	conn.Read(buf)
+}
+
+func http1Server(conn *tls.Conn) {
+	buf := make([]byte, 4096)
+	conn.Read(buf)
+	io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nContent-Length: 6\r\n\r\nsecret")
+}
+
+func TestImportPcapTLS(t *testing.T) {
+	for _, version := range []uint16{tls.VersionTLS12, tls.VersionTLS13} {
+		capture, keyLogPath := runTLSExchange(t, version, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, "", http1Client, http1Server)
+
+		// 没有密钥日志时TLS流量被跳过
+		h, err := ImportPcap(bytes.NewReader(capture), DefaultPcapOptions())
+		assert.NoError(t, err)
+		assert.Empty(t, h.Log.Entries)
+
+		options := DefaultPcapOptions()
+		options.KeyLogFile = keyLogPath
+		h, err = ImportPcap(bytes.NewReader(capture), options)
+		assert.NoError(t, err)
+		if !assert.Len(t, h.Log.Entries, 1, "TLS version %x", version) {
+			continue
+		}
+		entry := h.Log.Entries[0]
+		assert.Equal(t, "https://example.com/secure?x=1", entry.Request.URL)
+		assert.Equal(t, 200, entry.Response.Status)
+		assert.Equal(t, "secret", entry.Response.Content.Text)
+		assert.Greater(t, entry.Timings.Ssl, 0.0)
+		assert.GreaterOrEqual(t, entry.Timings.Connect, entry.Timings.Ssl)
+	}
+}
+
+func TestImportPcapTLSCipherSuites(t *testing.T) {
+	var warnings []*HarError
+	options := DefaultPcapOptions()
+	options.OnWarning = func(warning *HarError) { warnings = append(warnings, warning) }
+
+	capture, keyLogPath := runTLSExchange(t, tls.VersionTLS12, tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256, "", http1Client, http1Server)
+	options.KeyLogFile = keyLogPath
+	h, err := ImportPcap(bytes.NewReader(capture), options)
+	assert.NoError(t, err)
+	if assert.Len(t, h.Log.Entries, 1) {
+		assert.Equal(t, "secret", h.Log.Entries[0].Response.Content.Text)
+	}
+	assert.Empty(t, warnings)
+
+	// 不支持的密码套件每个连接报告一次警告
+	capture, keyLogPath = runTLSExchange(t, tls.VersionTLS12, tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA, "", http1Client, http1Server)
+	options.KeyLogFile = keyLogPath
+	h, err = ImportPcap(bytes.NewReader(capture), options)
+	assert.NoError(t, err)
+	assert.Empty(t, h.Log.Entries)
+	if assert.Len(t, warnings, 1) {
+		assert.Equal(t, ErrCodeUnsupported, warnings[0].Code)
+		assert.Contains(t, warnings[0].Message, "0xc009")
+		assert.Equal(t, "0", warnings[0].Metadata["connection"])
+		assert.Equal(t, "10.0.0.2:443", warnings[0].Metadata["server"])
+	}
+}
+
+func TestTLS13ChaCha20Keys(t *testing.T) {
+	suite := tlsCipherSuites[0x1303]
+	secret := bytes.Repeat([]byte{7}, 32)
+	keys := tls13Keys(suite, nil, secret)
+	if !assert.NotNil(t, keys) {
+		return
+	}
+
+	// 按RFC 8446的方式独立加密一条记录
+	aead, err := chacha20poly1305.New(hkdfExpandLabel(sha256.New, secret, "key", 32))
+	assert.NoError(t, err)
+	iv := hkdfExpandLabel(sha256.New, secret, "iv", 12)
+	header := []byte{tlsRecordApplicationData, 3, 3, 0, byte(len("hello") + 1 + aead.Overhead())}
+	payload := aead.Seal(nil, tlsNonce(iv, 0), []byte("hello\x17"), header)
+
+	contentType, plaintext, ok := keys.open(tlsRecord{contentType: tlsRecordApplicationData, header: header, payload: payload})
+	assert.True(t, ok)
+	assert.Equal(t, uint8(tlsRecordApplicationData), contentType)
+	assert.Equal(t, "hello", string(plaintext))
+}
+
+func TestImportPcapTLSHTTP2(t *testing.T) {
+	clientFn := func(conn *tls.Conn) {
+		io.WriteString(conn, http2.ClientPreface)
+		framer := http2.NewFramer(conn, conn)
+		framer.WriteSettings()
+		var block bytes.Buffer
+		enc := hpack.NewEncoder(&block)
+		for _, f := range [][2]string{{":method", "GET"}, {":scheme", "https"}, {":authority", "example.com"}, {":path", "/h2"}} {
+			enc.WriteField(hpack.HeaderField{Name: f[0], Value: f[1]})
+		}
+		framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: block.Bytes(), EndStream: true, EndHeaders: true})
+		for {
+			frame, err := framer.ReadFrame()
+			if err != nil {
+				return
+			}
+			if frame.Header().Flags.Has(http2.FlagDataEndStream) && frame.Header().Type == http2.FrameData {
+				return
+			}
+		}
+	}
+	serverFn := func(conn *tls.Conn) {
+		preface := make([]byte, len(http2.ClientPreface))
+		io.ReadFull(conn, preface)
+		framer := http2.NewFramer(conn, conn)
+		for {
+			frame, err := framer.ReadFrame()
+			if err != nil {
+				return
+			}
+			if frame.Header().Type == http2.FrameHeaders {
+				break
+			}
+		}
+		var block bytes.Buffer
+		enc := hpack.NewEncoder(&block)
+		enc.WriteField(hpack.HeaderField{Name: ":status", Value: "200"})
+		enc.WriteField(hpack.HeaderField{Name: "content-type", Value: "application/json"})
+		framer.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: block.Bytes(), EndHeaders: true})
+		framer.WriteData(1, true, []byte(`{"ok":true}`))
+	}
+
+	capture, keyLogPath := runTLSExchange(t, tls.VersionTLS13, tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, "h2", clientFn, serverFn)
+	options := DefaultPcapOptions()
+	options.KeyLogFile = keyLogPath
+	h, err := ImportPcap(bytes.NewReader(capture), options)
+	assert.NoError(t, err)
+	if !assert.Len(t, h.Log.Entries, 1) {
+		return
+	}
+	entry := h.Log.Entries[0]
+	assert.Equal(t, http.MethodGet, entry.Request.Method)
+	assert.Equal(t, "https://example.com/h2", entry.Request.URL)
+	assert.Equal(t, "HTTP/2.0", entry.Request.HTTPVersion)
+	assert.Equal(t, 200, entry.Response.Status)
+	assert.Equal(t, `{"ok":true}`, entry.Response.Content.Text)
+}
@@ -0,0 +1,386 @@
+package har
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// snippetRadius 代码片段中错误位置前后保留的最大字符数
+const snippetRadius = 40
+
+// WithPosition 根据原始输入中的字节偏移添加位置信息
+//
+// Metadata中记录字节偏移("offset")、从1开始的行号("line")和列号("column")，
+// 以及出错所在行的片段("snippet")和错误位置在片段中的列号("snippetColumn")。
+// 过长的行只保留错误位置前后各40个字符，被截断处以"..."表示。
+func (e *HarError) WithPosition(data []byte, offset int) *HarError {
+	return e.withPosition(newLineIndex(data), offset)
+}
+
+func (e *HarError) withPosition(lines *lineIndex, offset int) *HarError {
+	if offset > len(lines.data) {
+		offset = len(lines.data)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	line, column := lines.position(offset)
+	snippet, snippetColumn := lines.snippet(offset)
+	return e.WithMetadata("offset", offset).
+		WithMetadata("line", line).
+		WithMetadata("column", column).
+		WithMetadata("snippet", snippet).
+		WithMetadata("snippetColumn", snippetColumn)
+}
+
+// Position 返回错误在原始输入中的行号和列号，没有位置信息时ok为false
+func (e *HarError) Position() (line, column int, ok bool) {
+	line, ok = e.Metadata["line"].(int)
+	if !ok {
+		return 0, 0, false
+	}
+	column, ok = e.Metadata["column"].(int)
+	return line, column, ok
+}
+
+// FormatError 将错误格式化为适合命令行输出的多行文本
+//
+// 带位置信息的HarError(及其部分错误)会附带出错行的片段，并用 ^ 标出错误位置:
+//
+//	JSON语法错误: invalid character '}' looking for beginning of value
+//	  --> 第3行，第15列
+//	   |
+//	 3 |     "status": },
+//	   |               ^
+//
+// 被fmt.Errorf等包装的HarError同样适用，其他错误只返回Error()。
+func FormatError(err error) string {
+	if err == nil {
+		return ""
+	}
+	var harErr *HarError
+	if !errors.As(err, &harErr) {
+		return err.Error()
+	}
+
+	var b strings.Builder
+	// 保留包装时添加的前缀，如 "failed to parse HAR bytes: "
+	prefix := strings.TrimSuffix(err.Error(), harErr.Error())
+	writeFormattedError(&b, harErr, prefix, "")
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeFormattedError 写入错误信息、代码片段和所有部分错误
+func writeFormattedError(b *strings.Builder, e *HarError, prefix, indent string) {
+	headline := &HarError{Message: e.Message, Field: e.Field, Err: e.Err, lang: e.lang}
+	b.WriteString(indent + prefix + headline.Error() + "\n")
+
+	if line, column, ok := e.Position(); ok {
+		gutter := strings.Repeat(" ", len(strconv.Itoa(line)))
+		fmt.Fprintf(b, "%s%s--> %s\n", indent, gutter, Message(e.lang, "error.position", line, column))
+		if snippet, ok := e.Metadata["snippet"].(string); ok {
+			snippetColumn, _ := e.Metadata["snippetColumn"].(int)
+			fmt.Fprintf(b, "%s%s |\n", indent, gutter)
+			fmt.Fprintf(b, "%s%d | %s\n", indent, line, snippet)
+			fmt.Fprintf(b, "%s%s | %s^\n", indent, gutter, caretPadding(snippet, snippetColumn))
+		}
+	}
+
+	for _, pe := range e.PartialErrors {
+		writeFormattedError(b, pe, "- ", indent+"  ")
+	}
+}
+
+// caretPadding 返回将 ^ 对齐到片段第column个字符所需的空白，全角字符占两列
+func caretPadding(snippet string, column int) string {
+	var b strings.Builder
+	for i, r := range []rune(snippet) {
+		if i >= column-1 {
+			break
+		}
+		b.WriteByte(' ')
+		if isWideRune(r) {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+// isWideRune 判断字符在终端中是否占两列
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hangul, unicode.Hiragana, unicode.Katakana) ||
+		(r >= 0x3000 && r <= 0x303F) || (r >= 0xFF01 && r <= 0xFF60)
+}
+
+// lineIndex 记录每行起始偏移，用于多次将字节偏移转换为行列号
+type lineIndex struct {
+	data       []byte
+	lineStarts []int
+}
+
+func newLineIndex(data []byte) *lineIndex {
+	idx := &lineIndex{data: data, lineStarts: []int{0}}
+	for i, c := range data {
+		if c == '\n' {
+			idx.lineStarts = append(idx.lineStarts, i+1)
+		}
+	}
+	return idx
+}
+
+// position 返回偏移所在的行号和列号，均从1开始
+func (idx *lineIndex) position(offset int) (int, int) {
+	offset = idx.clamp(offset)
+	line := sort.Search(len(idx.lineStarts), func(i int) bool { return idx.lineStarts[i] > offset })
+	start := idx.lineStarts[line-1]
+	return line, utf8.RuneCount(idx.data[start:offset]) + 1
+}
+
+// snippet 返回偏移所在行的片段及偏移在片段中的列号，制表符替换为空格
+func (idx *lineIndex) snippet(offset int) (string, int) {
+	offset = idx.clamp(offset)
+	line := sort.Search(len(idx.lineStarts), func(i int) bool { return idx.lineStarts[i] > offset })
+	lineStart := idx.lineStarts[line-1]
+	lineEnd := len(idx.data)
+	if line < len(idx.lineStarts) {
+		lineEnd = idx.lineStarts[line] - 1
+	}
+	text := idx.data[lineStart:lineEnd]
+	if n := len(text); n > 0 && text[n-1] == '\r' {
+		text = text[:n-1]
+	}
+	caret := offset - lineStart
+	if caret > len(text) {
+		caret = len(text)
+	}
+
+	start := caret
+	for i := 0; i < snippetRadius && start > 0; i++ {
+		_, size := utf8.DecodeLastRune(text[:start])
+		start -= size
+	}
+	end := caret
+	for i := 0; i < snippetRadius && end < len(text); i++ {
+		_, size := utf8.DecodeRune(text[end:])
+		end += size
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	b.Write(text[start:caret])
+	column := utf8.RuneCountInString(b.String()) + 1
+	b.Write(text[caret:end])
+	if end < len(text) {
+		b.WriteString("...")
+	}
+	return strings.ReplaceAll(b.String(), "\t", " "), column
+}
+
+func (idx *lineIndex) clamp(offset int) int {
+	if offset > len(idx.data) {
+		return len(idx.data)
+	}
+	if offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// wrapJSONError 包装json.Unmarshal的错误并添加出错位置，data为被解码的完整输入
+func wrapJSONError(data []byte, err error) *HarError {
+	harErr := WrapJSONUnmarshalError(err)
+	if offset, ok := jsonErrorOffset(data, err); ok {
+		harErr.WithPosition(data, offset)
+	}
+	return harErr
+}
+
+// jsonErrorOffset 返回JSON错误在data中指向的字节偏移
+//
+// 语法错误指向出错的字符，类型错误指向类型不符的值的开始。
+func jsonErrorOffset(data []byte, err error) (int, bool) {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset为读取到出错字符之后的偏移
+		return int(syntaxErr.Offset) - 1, true
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return jsonValueStart(data, int(typeErr.Offset)), true
+	}
+	return 0, false
+}
+
+// jsonValueStart 根据类型错误的偏移找到值的开始
+//
+// 对于标量，偏移位于值之后；对于对象和数组，偏移位于开始的括号之后。
+func jsonValueStart(data []byte, end int) int {
+	if end > len(data) {
+		end = len(data)
+	}
+	i := end - 1
+	if i < 0 {
+		return 0
+	}
+	switch c := data[i]; {
+	case c == '{' || c == '[':
+		return i
+	case c == '"':
+		for i--; i >= 0; i-- {
+			if data[i] == '"' && !escapedAt(data, i) {
+				return i
+			}
+		}
+		return 0
+	}
+	for i > 0 && strings.IndexByte("+-0123456789.eEtruefalsn", data[i-1]) >= 0 {
+		i--
+	}
+	return i
+}
+
+// escapedAt 判断位置i的字符前是否有奇数个反斜杠
+func escapedAt(data []byte, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && data[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// positionLocator 将HarError中的字段路径映射到原始输入中的位置
+//
+// 每个span对应输入中一个完整的JSON值及其字段路径，首次查找时才解析。
+// 路径中不存在的字段(如缺失的必需字段)定位到最近的存在的父级。
+type positionLocator struct {
+	lines *lineIndex
+	spans []*positionSpan
+}
+
+type positionSpan struct {
+	path  string
+	start int
+	node  *jsonNode
+}
+
+// newPositionLocator 为完整且有效的JSON输入创建定位器
+func newPositionLocator(data []byte) *positionLocator {
+	l := &positionLocator{lines: newLineIndex(data)}
+	l.addSpan("", 0)
+	return l
+}
+
+// addSpan 记录位于start处、路径为path的有效JSON值，用于整体无效的输入
+func (l *positionLocator) addSpan(path string, start int) {
+	l.spans = append(l.spans, &positionSpan{path: path, start: start})
+}
+
+// offset 返回字段路径对应值的开始偏移
+func (l *positionLocator) offset(path string) (int, bool) {
+	var span *positionSpan
+	for _, s := range l.spans {
+		if (span == nil || len(s.path) > len(span.path)) && pathHasPrefix(path, s.path) {
+			span = s
+		}
+	}
+	if span == nil {
+		return 0, false
+	}
+	if span.node == nil {
+		p := &offsetParser{data: l.lines.data, pos: span.start}
+		span.node = p.parse()
+	}
+
+	node := span.node
+	for _, segment := range splitFieldPath(strings.TrimPrefix(path[len(span.path):], ".")) {
+		child := childNode(node, segment)
+		if child == nil {
+			break
+		}
+		node = child
+	}
+	return node.offset, true
+}
+
+// annotate 为没有位置信息的错误及其部分错误按字段路径添加位置
+func (l *positionLocator) annotate(e *HarError) *HarError {
+	if e == nil {
+		return nil
+	}
+	if _, ok := e.Metadata["line"]; !ok && e.Field != "" {
+		if offset, ok := l.offset(e.Field); ok {
+			e.withPosition(l.lines, offset)
+		}
+	}
+	for _, pe := range e.PartialErrors {
+		l.annotate(pe)
+	}
+	return e
+}
+
+// pathHasPrefix 判断字段路径是否位于prefix之下
+func pathHasPrefix(path, prefix string) bool {
+	if prefix == "" || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, prefix) &&
+		(path[len(prefix)] == '.' || path[len(prefix)] == '[')
+}
+
+// splitFieldPath 将 "entries[3].request.url" 拆分为 "entries"、"[3]"、"request"、"url"
+func splitFieldPath(path string) []string {
+	var segments []string
+	for path != "" {
+		switch i := strings.IndexAny(path, ".["); {
+		case i < 0:
+			segments = append(segments, path)
+			path = ""
+		case i > 0:
+			segments = append(segments, path[:i])
+			path = strings.TrimPrefix(path[i:], ".")
+		case path[0] == '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return append(segments, path)
+			}
+			segments = append(segments, path[:end+1])
+			path = strings.TrimPrefix(path[end+1:], ".")
+		default:
+			path = path[1:]
+		}
+	}
+	return segments
+}
+
+// childNode 返回对象字段或数组元素，字段名与encoding/json一致不区分大小写
+func childNode(node *jsonNode, segment string) *jsonNode {
+	switch value := node.value.(type) {
+	case []*jsonNode:
+		if !strings.HasPrefix(segment, "[") {
+			return nil
+		}
+		i, err := strconv.Atoi(strings.Trim(segment, "[]"))
+		if err != nil || i < 0 || i >= len(value) {
+			return nil
+		}
+		return value[i]
+	case *jsonObject:
+		if child, ok := value.values[segment]; ok {
+			return child
+		}
+		for _, key := range value.keys {
+			if strings.EqualFold(key, segment) {
+				return value.values[key]
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package har
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPositionTestData(t *testing.T) string {
+	h := NewHar()
+	h.AddPage("page_1", "首页")
+	for i := 0; i < 2; i++ {
+		e := h.AddEntry("GET", fmt.Sprintf("https://x.com/%d", i), "HTTP/1.1", "page_1")
+		e.SetResponseStatus(200, "OK")
+		e.SetTimings(-1, -1, -1, 1, 1, 1, -1)
+		e.Response.Content.MimeType = "text/plain"
+	}
+	data, err := json.MarshalIndent(h, "", "  ")
+	assert.NoError(t, err)
+	return string(data)
+}
+
+// positionOf 返回子串第n次出现的行号和列号
+func positionOf(data, substr string, n int) (int, int) {
+	offset := -1
+	for i := 0; i < n; i++ {
+		offset += strings.Index(data[offset+1:], substr) + 1
+	}
+	return newLineIndex([]byte(data)).position(offset)
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	data := newPositionTestData(t)
+
+	// 语法错误指向出错的字符
+	broken := strings.Replace(data, `"status": 200`, `"status": }`, 1)
+	_, err := ParseHar([]byte(broken))
+	harErr, ok := err.(*HarError)
+	if assert.True(t, ok, err) {
+		line, column, ok := harErr.Position()
+		assert.True(t, ok)
+		wantLine, wantColumn := positionOf(broken, `"status": }`, 1)
+		assert.Equal(t, wantLine, line)
+		assert.Equal(t, wantColumn+len(`"status": `), column)
+		assert.Contains(t, harErr.Metadata["snippet"], `"status": }`)
+	}
+
+	// 类型错误指向值的开始
+	broken = strings.Replace(data, `"status": 200`, `"status": "200"`, 1)
+	_, err = ParseHarWithOptions([]byte(broken), DefaultParseOptions())
+	harErr, ok = err.(*HarError)
+	if assert.True(t, ok, err) {
+		line, column, _ := harErr.Position()
+		wantLine, wantColumn := positionOf(broken, `"200"`, 1)
+		assert.Equal(t, wantLine, line)
+		assert.Equal(t, wantColumn, column)
+	}
+
+	// 验证错误按字段路径定位，缺失的字段定位到父级
+	broken = strings.Replace(data, `"method": "GET"`, `"method": ""`, 2)
+	_, err = ParseHar([]byte(broken))
+	harErr, ok = err.(*HarError)
+	if assert.True(t, ok, err) && assert.True(t, harErr.HasPartialErrors()) {
+		var found bool
+		for _, pe := range harErr.GetPartialErrors() {
+			if pe.Field == "log.entries[1].request.method" {
+				found = true
+				line, _, _ := pe.Position()
+				wantLine, _ := positionOf(broken, `"method": ""`, 2)
+				assert.Equal(t, wantLine, line)
+			}
+		}
+		assert.True(t, found)
+	}
+}
+
+func TestLenientErrorPosition(t *testing.T) {
+	data := newPositionTestData(t)
+	broken := strings.Replace(data, `"status": 200`, `"status": "200"`, 1)
+	second := strings.Index(broken, `"https://x.com/1"`)
+	broken = broken[:second] + strings.Replace(broken[second:], `"bodySize": -1`, `"bodySize": [1]`, 1)
+
+	options := DefaultParseOptions()
+	options.Lenient = true
+	options.CollectWarnings = true
+	h, err := ParseHarWithOptions([]byte(broken), options)
+	assert.NotNil(t, h)
+	harErr, ok := err.(*HarError)
+	if !assert.True(t, ok, err) {
+		return
+	}
+
+	byField := map[string]*HarError{}
+	for _, pe := range harErr.GetPartialErrors() {
+		byField[pe.Field] = pe
+	}
+
+	// 被转换的值
+	if coerced := byField["log.entries[0].response.status"]; assert.NotNil(t, coerced) {
+		line, column, _ := coerced.Position()
+		wantLine, wantColumn := positionOf(broken, `"200"`, 1)
+		assert.Equal(t, wantLine, line)
+		assert.Equal(t, wantColumn, column)
+	}
+
+	// 无法解析的条目定位到其中类型不符的值
+	if entryErr := byField["log.entries[1]"]; assert.NotNil(t, entryErr) {
+		line, column, _ := entryErr.Position()
+		wantLine, wantColumn := positionOf(broken, `[1]`, 1)
+		assert.Equal(t, wantLine, line)
+		assert.Equal(t, wantColumn, column)
+	}
+}
+
+func TestRecoverAndStreamingErrorPosition(t *testing.T) {
+	data := newPositionTestData(t)
+
+	truncated := data[:strings.LastIndex(data, `"request"`)]
+	result, err := RecoverHar([]byte(truncated))
+	assert.NoError(t, err)
+	if assert.True(t, result.Damaged()) && assert.NotEmpty(t, result.Warnings) {
+		damage := result.Warnings[len(result.Warnings)-1]
+		line, column, ok := damage.Position()
+		assert.True(t, ok)
+		wantLine, wantColumn := newLineIndex([]byte(truncated)).position(len(truncated))
+		assert.Equal(t, wantLine, line)
+		assert.Equal(t, wantColumn, column)
+	}
+
+	rule := NewRule(RuleInfo{ID: "test-position", Severity: SeverityError}, func(entry *Entries, path string, r *RuleReporter) {
+		if strings.HasSuffix(entry.Request.URL, "/1") {
+			r.Report(ErrCodeCustom, path+".request.url", "禁止的URL")
+		}
+	})
+	assert.NoError(t, RegisterRule(rule))
+	defer UnregisterRule("test-position")
+
+	it, err := NewStreamingParser([]byte(data), WithStrictRules())
+	assert.NoError(t, err)
+	for it.Next() {
+	}
+	harErr, ok := it.Err().(*HarError)
+	if assert.True(t, ok, it.Err()) && assert.Len(t, harErr.GetPartialErrors(), 1) {
+		line, column, _ := harErr.GetPartialErrors()[0].Position()
+		wantLine, wantColumn := positionOf(data, `"https://x.com/1"`, 1)
+		assert.Equal(t, wantLine, line)
+		assert.Equal(t, wantColumn, column)
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	data := "{\n  \"log\": {\n    \"version\": \"1.2\",,\n  }\n}"
+	_, err := ParseHarOptimized([]byte(data))
+	formatted := FormatError(err)
+
+	assert.True(t, strings.HasPrefix(formatted, "failed to parse HAR bytes: JSON语法错误"), formatted)
+	assert.Contains(t, formatted, "--> 第3行，第22列")
+	assert.Contains(t, formatted, "3 |     \"version\": \"1.2\",,\n  |                      ^")
+
+	// 过长的行只保留错误位置附近的内容
+	long := `{"log": {"comment": "` + strings.Repeat("a", 100) + `", "version": 1.2x}}`
+	_, err = ParseHar([]byte(long))
+	harErr := err.(*HarError)
+	snippet := harErr.Metadata["snippet"].(string)
+	assert.True(t, strings.HasPrefix(snippet, "..."), snippet)
+	assert.Equal(t, byte('x'), snippet[harErr.Metadata["snippetColumn"].(int)-1])
+
+	assert.Equal(t, "", FormatError(nil))
+	assert.Equal(t, "plain", FormatError(fmt.Errorf("plain")))
+}
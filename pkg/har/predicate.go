@@ -0,0 +1,270 @@
+package har
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Predicate 判断条目是否满足条件的谓词
+//
+// 谓词可以通过And、Or、Not任意组合，并可用于Har.Filter(通过FilterOptions.Predicate)、
+// OptimizedHar.Search以及FilterIterator。
+//
+// 示例:
+//
+//	p := Or(
+//	    And(StatusIn(500, 502, 503), MustURLMatches(`/api/`)),
+//	    Not(HeaderEquals("Accept", "text/html")),
+//	)
+//	result := h.Filter(FilterOptions{Predicate: p})
+type Predicate func(entry *Entries) bool
+
+// And 所有谓词都满足时为真，没有谓词时为真
+func And(predicates ...Predicate) Predicate {
+	return func(entry *Entries) bool {
+		for _, p := range predicates {
+			if !p(entry) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or 任一谓词满足时为真，没有谓词时为假
+func Or(predicates ...Predicate) Predicate {
+	return func(entry *Entries) bool {
+		for _, p := range predicates {
+			if p(entry) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not 对谓词取反
+func Not(p Predicate) Predicate {
+	return func(entry *Entries) bool {
+		return !p(entry)
+	}
+}
+
+// And 返回同时满足p和其他谓词的谓词
+func (p Predicate) And(others ...Predicate) Predicate {
+	return And(append([]Predicate{p}, others...)...)
+}
+
+// Or 返回满足p或其他任一谓词的谓词
+func (p Predicate) Or(others ...Predicate) Predicate {
+	return Or(append([]Predicate{p}, others...)...)
+}
+
+// Not 返回p的反
+func (p Predicate) Not() Predicate {
+	return Not(p)
+}
+
+// URLMatches URL匹配正则表达式，表达式无效时返回错误
+func URLMatches(pattern string) (Predicate, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, newLocalizedError(ErrCodeInvalidFormat, "predicate.regexp", nil, pattern, err.Error())
+	}
+	return URLMatchesRegexp(re), nil
+}
+
+// MustURLMatches 与URLMatches相同，但在表达式无效时panic
+func MustURLMatches(pattern string) Predicate {
+	p, err := URLMatches(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// URLMatchesRegexp URL匹配已编译的正则表达式
+func URLMatchesRegexp(re *regexp.Regexp) Predicate {
+	return func(entry *Entries) bool {
+		return re.MatchString(entry.Request.URL)
+	}
+}
+
+// URLContains URL包含指定字符串
+func URLContains(substr string) Predicate {
+	return func(entry *Entries) bool {
+		return strings.Contains(entry.Request.URL, substr)
+	}
+}
+
+// MethodIs 请求方法为指定方法之一(不区分大小写)
+func MethodIs(methods ...string) Predicate {
+	return func(entry *Entries) bool {
+		for _, m := range methods {
+			if strings.EqualFold(entry.Request.Method, m) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// StatusIn 响应状态码为指定状态码之一
+func StatusIn(codes ...int) Predicate {
+	set := make(map[int]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+	return func(entry *Entries) bool {
+		return set[entry.Response.Status]
+	}
+}
+
+// StatusBetween 响应状态码在[min, max]范围内
+func StatusBetween(min, max int) Predicate {
+	return func(entry *Entries) bool {
+		return entry.Response.Status >= min && entry.Response.Status <= max
+	}
+}
+
+// HeaderEquals 存在名称匹配(不区分大小写)且值相等的请求头
+func HeaderEquals(name, value string) Predicate {
+	return func(entry *Entries) bool {
+		return hasHeader(entry.Request.Headers, name, value)
+	}
+}
+
+// ResponseHeaderEquals 存在名称匹配(不区分大小写)且值相等的响应头
+func ResponseHeaderEquals(name, value string) Predicate {
+	return func(entry *Entries) bool {
+		return hasHeader(entry.Response.Headers, name, value)
+	}
+}
+
+// HasHeader 存在指定名称的请求头
+func HasHeader(name string) Predicate {
+	return func(entry *Entries) bool {
+		return queryHeader(entry.Request.Headers, name) != nil
+	}
+}
+
+// BodyContains 响应体包含指定字符串，base64编码的响应体会先解码
+func BodyContains(substr string) Predicate {
+	return func(entry *Entries) bool {
+		if entry.Response.Content.Text == "" {
+			return false
+		}
+		body, err := decodeContentText(entry.Response.Content)
+		if err != nil {
+			return false
+		}
+		return strings.Contains(string(body), substr)
+	}
+}
+
+// JSONPathMatches JSON响应体满足JSONPath条件，例如 $.errors[*].code == "E42"，
+// 表达式无效时返回错误，响应体不是JSON时不匹配
+func JSONPathMatches(expr string) (Predicate, error) {
+	e, err := compileJSONPath(expr, false)
+	if err != nil {
+		return nil, err
+	}
+	return func(entry *Entries) bool {
+		body, err := decodeContentText(entry.Response.Content)
+		return err == nil && e.matchBody(body)
+	}, nil
+}
+
+// MustJSONPathMatches 与JSONPathMatches相同，但在表达式无效时panic
+func MustJSONPathMatches(expr string) Predicate {
+	p, err := JSONPathMatches(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// DurationAbove 请求总耗时大于指定毫秒数
+func DurationAbove(ms float64) Predicate {
+	return func(entry *Entries) bool {
+		return entry.Time > ms
+	}
+}
+
+// DurationBelow 请求总耗时小于指定毫秒数
+func DurationBelow(ms float64) Predicate {
+	return func(entry *Entries) bool {
+		return entry.Time < ms
+	}
+}
+
+// ResourceTypeIs 资源类型为指定类型之一
+func ResourceTypeIs(types ...string) Predicate {
+	return func(entry *Entries) bool {
+		for _, t := range types {
+			if entry.ResourceType == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchOptions 将FilterOptions转换为谓词，便于与其他谓词组合，无效的正则表达式会被忽略
+func MatchOptions(options FilterOptions) Predicate {
+	return compileFilter(options).Match
+}
+
+// Predicate 将查询的条件部分转换为谓词
+func (q *Query) Predicate() Predicate {
+	return q.Match
+}
+
+func hasHeader(headers []Headers, name, value string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) && header.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Search 返回满足谓词的条目
+func (oh *OptimizedHar) Search(p Predicate) []OptimizedEntries {
+	var results []OptimizedEntries
+
+	for _, entry := range oh.Log.Entries {
+		standard := convertToStandardEntry(entry)
+		if p(&standard) {
+			results = append(results, entry)
+		}
+	}
+
+	return results
+}
+
+// filterIterator 只返回满足谓词的条目的迭代器
+type filterIterator struct {
+	EntryIterator
+	predicate Predicate
+}
+
+// FilterIterator 包装迭代器，跳过不满足谓词的条目，关闭时同时关闭底层迭代器
+func FilterIterator(it EntryIterator, p Predicate) EntryIterator {
+	return &filterIterator{EntryIterator: it, predicate: p}
+}
+
+// Next 移动到下一个满足谓词的条目
+func (it *filterIterator) Next() bool {
+	for it.EntryIterator.Next() {
+		if it.predicate(it.EntryIterator.Entry()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter 返回只包含满足谓词的条目的迭代器
+func (h *StreamingHar) Filter(p Predicate) EntryIterator {
+	return FilterIterator(h.Entries(), p)
+}
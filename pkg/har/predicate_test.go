@@ -0,0 +1,76 @@
+package har
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredicateCombinators(t *testing.T) {
+	h := newQueryTestHar()
+	h.Log.Entries[0].AddRequestHeader("Accept", "application/json")
+	h.Log.Entries[2].Response.Content.Text = `{"error":"not found"}`
+
+	urls := func(result *FilterResult) []string {
+		var out []string
+		for _, e := range result.Entries {
+			out = append(out, e.Request.URL)
+		}
+		return out
+	}
+
+	p := Or(
+		And(StatusIn(500, 404), MustURLMatches(`/users`)),
+		BodyContains("not found"),
+	)
+	assert.Equal(t, []string{"https://api.x.com/users?id=2", "https://api.x.com/orders"},
+		urls(h.Filter(FilterOptions{Predicate: p})))
+
+	// 谓词与FilterOptions中的其他条件为AND关系
+	assert.Equal(t, []string{"https://api.x.com/orders"},
+		urls(h.Filter(FilterOptions{Method: "POST", Predicate: p})))
+
+	p = HeaderEquals("accept", "application/json").Or(DurationAbove(35)).Not()
+	assert.Equal(t, []string{"https://api.x.com/orders", "https://cdn.x.com/app.js"},
+		urls(h.Filter(FilterOptions{Predicate: p})))
+
+	// 无效的正则表达式返回错误
+	_, err := URLMatches("(")
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrCodeInvalidFormat, err.(*HarError).Code)
+	}
+	assert.Panics(t, func() { MustURLMatches("(") })
+
+	q, err := ParseQuery(`resource_type = "script"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"https://cdn.x.com/app.js"}, urls(h.Filter(FilterOptions{Predicate: q.Predicate()})))
+
+	assert.Len(t, ToOptimizedHar(h).Search(StatusBetween(400, 599)), 2)
+}
+
+func TestFilterIterator(t *testing.T) {
+	h := newQueryTestHar()
+	data, err := h.ToJSON(false)
+	assert.NoError(t, err)
+
+	sh, err := NewStreamingHarFromBytes(data)
+	assert.NoError(t, err)
+	it := sh.Filter(And(MethodIs("get"), DurationBelow(35)))
+	defer it.Close()
+
+	var urls []string
+	for it.Next() {
+		urls = append(urls, it.Entry().Request.URL)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"https://api.x.com/users?id=1", "https://cdn.x.com/app.js"}, urls)
+
+	var buf bytes.Buffer
+	assert.NoError(t, h.WriteNDJSON(&buf))
+	reader, err := NewNDJSONReader(&buf)
+	assert.NoError(t, err)
+	n, err := WriteParquet(&bytes.Buffer{}, FilterIterator(reader, ResourceTypeIs("script")), DefaultParquetOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,239 @@
+package har
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tnet 将Go值编码为tnetstring，仅用于构造测试数据
+func tnet(value interface{}) string {
+	var payload, tag string
+	switch v := value.(type) {
+	case nil:
+		tag = "~"
+	case string:
+		payload, tag = v, ";"
+	case []byte:
+		payload, tag = string(v), ","
+	case int:
+		payload, tag = strconv.Itoa(v), "#"
+	case float64:
+		payload, tag = strconv.FormatFloat(v, 'f', -1, 64), "^"
+	case bool:
+		payload, tag = strconv.FormatBool(v), "!"
+	case []interface{}:
+		for _, item := range v {
+			payload += tnet(item)
+		}
+		tag = "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			payload += tnet(k) + tnet(v[k])
+		}
+		tag = "}"
+	default:
+		panic(fmt.Sprintf("unsupported type %T", value))
+	}
+	return fmt.Sprintf("%d:%s%s", len(payload), payload, tag)
+}
+
+func gzipBytes(data string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(data))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestImportMitmproxy(t *testing.T) {
+	base := 1672531200.0
+	header := func(name, value string) interface{} {
+		return []interface{}{[]byte(name), []byte(value)}
+	}
+	serverConn := map[string]interface{}{
+		"id":                  "conn-1",
+		"peername":            []interface{}{"93.184.216.34", 443},
+		"timestamp_start":     base,
+		"timestamp_tcp_setup": base + 0.010,
+		"timestamp_tls_setup": base + 0.030,
+	}
+	flow := func(path string, offset float64, body []byte) map[string]interface{} {
+		return map[string]interface{}{
+			"type": "http",
+			"request": map[string]interface{}{
+				"method":          []byte("GET"),
+				"scheme":          "https",
+				"host":            "example.com",
+				"port":            443,
+				"authority":       "",
+				"path":            path,
+				"http_version":    "HTTP/1.1",
+				"headers":         []interface{}{header("Host", "example.com"), header("Cookie", "a=1")},
+				"content":         []byte{},
+				"timestamp_start": base + offset + 0.031,
+				"timestamp_end":   base + offset + 0.032,
+			},
+			"response": map[string]interface{}{
+				"status_code":     200,
+				"reason":          []byte("OK"),
+				"http_version":    "HTTP/1.1",
+				"headers":         []interface{}{header("Content-Type", "text/plain"), header("Content-Encoding", "gzip")},
+				"content":         body,
+				"timestamp_start": base + offset + 0.082,
+				"timestamp_end":   base + offset + 0.085,
+			},
+			"server_conn": serverConn,
+		}
+	}
+
+	data := tnet(flow("/a?x=1", 0, gzipBytes("hello"))) + tnet(flow("/b", 1, gzipBytes("world")))
+	h, err := ImportMitmproxy(strings.NewReader(data))
+	assert.NoError(t, err)
+	if !assert.Len(t, h.Log.Entries, 2) {
+		return
+	}
+
+	first := h.Log.Entries[0]
+	assert.Equal(t, "https://example.com/a?x=1", first.Request.URL)
+	assert.Equal(t, "GET", first.Request.Method)
+	assert.Equal(t, "a", first.Request.Cookies[0].Name)
+	assert.Equal(t, "hello", first.Response.Content.Text)
+	assert.Equal(t, "93.184.216.34", first.ServerIPAddress)
+	assert.InDelta(t, 20, first.Timings.Ssl, 0.01)
+	assert.InDelta(t, 30, first.Timings.Connect, 0.01)
+	assert.InDelta(t, 50, first.Timings.Wait, 0.01)
+	assert.Equal(t, time.Unix(1672531200, 0).UTC(), first.StartedDateTime)
+
+	// 复用的连接不再计入建连时间
+	second := h.Log.Entries[1]
+	assert.Equal(t, "world", second.Response.Content.Text)
+	assert.Equal(t, -1.0, second.Timings.Connect)
+	assert.Equal(t, -1.0, second.Timings.Ssl)
+
+	_, err = ImportMitmproxy(strings.NewReader("5:abc"))
+	assert.Error(t, err)
+
+	// 异常的长度前缀在分配内存之前被拒绝
+	for _, data := range []string{"-1:}", "999999999999:}", "1234567890123456789:}", strings.Repeat("1", 100)} {
+		_, err = ImportMitmproxy(strings.NewReader(data))
+		assert.Error(t, err, data)
+	}
+
+	// 过深的嵌套返回格式错误而不是耗尽栈空间
+	nested := "0:]"
+	for i := 0; i < maxTNetStringDepth+1; i++ {
+		nested = fmt.Sprintf("%d:%s]", len(nested), nested)
+	}
+	_, err = ImportMitmproxy(strings.NewReader(nested))
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrCodeInvalidFormat, err.(*HarError).Code)
+		assert.Contains(t, err.Error(), "嵌套层级")
+	}
+}
+
+func TestImportCharles(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0, 1, 2}
+	data := `[{
+		"status": "COMPLETE",
+		"method": "POST",
+		"protocolVersion": "HTTP/1.1",
+		"scheme": "https",
+		"host": "api.example.com",
+		"actualPort": 8443,
+		"path": "/upload",
+		"query": "v=2",
+		"remoteAddress": "api.example.com/10.1.2.3",
+		"times": {"start": "2023-01-01T00:00:00.000+00:00"},
+		"durations": {"total": 120, "dns": 5, "connect": 10, "ssl": 15, "request": 2, "response": 8, "latency": 80},
+		"request": {
+			"mimeType": "application/json",
+			"sizes": {"headers": 100, "body": 9},
+			"header": {"firstLine": "POST /upload?v=2 HTTP/1.1", "headers": [{"name": "Cookie", "value": "sid=1"}]},
+			"body": {"text": "{\"a\":1}"}
+		},
+		"response": {
+			"status": 201,
+			"mimeType": "image/png",
+			"sizes": {"headers": 50, "body": 7},
+			"header": {"firstLine": "HTTP/1.1 201 Created", "headers": [{"name": "Content-Type", "value": "image/png"}]},
+			"body": {"encoded": "` + base64.StdEncoding.EncodeToString(png) + `"}
+		}
+	}]`
+
+	h, err := ImportCharles(strings.NewReader(data))
+	assert.NoError(t, err)
+	if !assert.Len(t, h.Log.Entries, 1) {
+		return
+	}
+	entry := h.Log.Entries[0]
+	assert.Equal(t, "https://api.example.com:8443/upload?v=2", entry.Request.URL)
+	assert.Equal(t, "10.1.2.3", entry.ServerIPAddress)
+	assert.Equal(t, "sid", entry.Request.Cookies[0].Name)
+	assert.Equal(t, 201, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, "base64", entry.Response.Content.Encoding)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(png), entry.Response.Content.Text)
+	assert.Equal(t, 25.0, entry.Timings.Connect)
+	assert.Equal(t, 15.0, entry.Timings.Ssl)
+	assert.Equal(t, 80.0, entry.Timings.Wait)
+	assert.Equal(t, 120.0, entry.Time)
+
+	_, err = ImportCharles(strings.NewReader("{"))
+	assert.Error(t, err)
+}
+
+func TestImportFiddlerSAZ(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name, content string) {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	add("raw/01_c.txt", "GET http://example.com/page?q=go HTTP/1.1\r\nHost: example.com\r\n\r\n")
+	add("raw/01_s.txt", "HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nhello\r\n0\r\n\r\n")
+	add("raw/01_m.xml", `<?xml version="1.0" encoding="utf-8"?>
+<Session SID="1" BitFlags="0">
+  <SessionTimers ClientConnected="2023-01-01T00:00:00.0000000+00:00" ClientBeginRequest="2023-01-01T00:00:00.0010000+00:00"
+    DNSTime="3" TCPConnectTime="7" HTTPSHandshakeTime="0"
+    FiddlerBeginRequest="2023-01-01T00:00:00.0100000+00:00" ServerGotRequest="2023-01-01T00:00:00.0120000+00:00"
+    ServerBeginResponse="2023-01-01T00:00:00.0620000+00:00" ServerDoneResponse="2023-01-01T00:00:00.0650000+00:00" />
+  <PipeInfo />
+  <SessionFlags><SessionFlag N="x-hostip" V="93.184.216.34" /></SessionFlags>
+</Session>`)
+	add("raw/02_c.txt", "CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n")
+	add("raw/02_s.txt", "HTTP/1.1 200 Connection Established\r\n\r\n")
+	zw.Close()
+
+	h, err := ImportFiddlerSAZ(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	if !assert.Len(t, h.Log.Entries, 1) {
+		return
+	}
+	entry := h.Log.Entries[0]
+	assert.Equal(t, "http://example.com/page?q=go", entry.Request.URL)
+	assert.Equal(t, "hello", entry.Response.Content.Text)
+	assert.Equal(t, "93.184.216.34", entry.ServerIPAddress)
+	assert.Equal(t, 3.0, entry.Timings.DNS)
+	assert.Equal(t, 7.0, entry.Timings.Connect)
+	assert.InDelta(t, 2, entry.Timings.Send, 0.001)
+	assert.InDelta(t, 50, entry.Timings.Wait, 0.001)
+	assert.InDelta(t, 3, entry.Timings.Receive, 0.001)
+
+	_, err = ImportFiddlerSAZ(bytes.NewReader([]byte("nope")), 4)
+	assert.Error(t, err)
+}
@@ -0,0 +1,945 @@
+package har
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Query 已解析的查询语句，可重复用于多个HAR或迭代器
+//
+// 与FilterOptions不同，查询支持任意的AND/OR/NOT组合、投影、分组聚合和排序。
+// 条件中字段缺失(例如不存在的头部或值为-1的计时阶段)时值为NULL。与SQL相同采用三值逻辑:
+// 与NULL的比较(包括!=、!~、LIKE和IN)结果为NULL，NOT NULL仍为NULL，AND/OR中的NULL表示未知，
+// WHERE结果为NULL的条目不匹配。"= NULL"和"!= NULL"分别用于判断字段是否缺失和是否存在，
+// 聚合函数会忽略NULL值。
+type Query struct {
+	source     string
+	selects    []querySelectItem
+	where      queryExpr
+	groupBy    []queryExpr
+	groupNames []string
+	orderBy    []queryOrder
+	limit      int
+	aggregate  bool
+	aggregates []*queryAggregate
+}
+
+// QueryResult 查询结果
+type QueryResult struct {
+	Columns []string        // 列名，来自SELECT中的别名或表达式原文
+	Rows    [][]interface{} // 每行的值，类型为float64、string、bool或nil
+	Entries []Entries       // 非聚合查询时与Rows一一对应的条目，聚合查询时为nil
+}
+
+// querySelectItem 投影列
+type querySelectItem struct {
+	expr queryExpr
+	name string
+}
+
+// queryOrder 排序键
+type queryOrder struct {
+	expr queryExpr
+	desc bool
+}
+
+// queryContext 表达式求值上下文，聚合查询时group指向当前分组
+type queryContext struct {
+	entry *Entries
+	group *queryGroup
+}
+
+// queryGroup 分组的累积状态，只保留第一个条目和聚合函数的输入值
+type queryGroup struct {
+	first  *Entries
+	counts []int
+	values [][]float64
+}
+
+// queryExpr 查询表达式节点
+type queryExpr interface {
+	eval(ctx *queryContext) interface{}
+}
+
+type queryLiteral struct {
+	value interface{}
+}
+
+func (e *queryLiteral) eval(*queryContext) interface{} {
+	return e.value
+}
+
+type queryField struct {
+	name string
+	get  func(*Entries) interface{}
+}
+
+func (e *queryField) eval(ctx *queryContext) interface{} {
+	if ctx.entry == nil {
+		return nil
+	}
+	return e.get(ctx.entry)
+}
+
+type queryCall struct {
+	fn   func(*Entries, []interface{}) interface{}
+	args []queryExpr
+}
+
+func (e *queryCall) eval(ctx *queryContext) interface{} {
+	if ctx.entry == nil {
+		return nil
+	}
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		args[i] = arg.eval(ctx)
+	}
+	return e.fn(ctx.entry, args)
+}
+
+type queryLogical struct {
+	or          bool
+	left, right queryExpr
+}
+
+// eval 按三值逻辑计算，结果可能为true、false或NULL
+func (e *queryLogical) eval(ctx *queryContext) interface{} {
+	l := e.left.eval(ctx)
+	if l != nil && queryTruthy(l) == e.or {
+		return e.or
+	}
+	r := e.right.eval(ctx)
+	if r != nil && queryTruthy(r) == e.or {
+		return e.or
+	}
+	if l == nil || r == nil {
+		return nil
+	}
+	return !e.or
+}
+
+type queryNot struct {
+	expr queryExpr
+}
+
+func (e *queryNot) eval(ctx *queryContext) interface{} {
+	v := e.expr.eval(ctx)
+	if v == nil {
+		return nil
+	}
+	return !queryTruthy(v)
+}
+
+type queryIn struct {
+	expr queryExpr
+	list []queryExpr
+}
+
+// eval 值为NULL或没有匹配且列表中包含NULL时返回NULL
+func (e *queryIn) eval(ctx *queryContext) interface{} {
+	v := e.expr.eval(ctx)
+	if v == nil {
+		return nil
+	}
+	unknown := false
+	for _, item := range e.list {
+		iv := item.eval(ctx)
+		if iv == nil {
+			unknown = true
+		} else if queryEqual(v, iv) {
+			return true
+		}
+	}
+	if unknown {
+		return nil
+	}
+	return false
+}
+
+// queryCompare 二元比较，pattern为解析时预编译的正则或LIKE模式
+type queryCompare struct {
+	op          string
+	left, right queryExpr
+	pattern     interface{ MatchString(string) bool }
+}
+
+func (e *queryCompare) eval(ctx *queryContext) interface{} {
+	l, r := e.left.eval(ctx), e.right.eval(ctx)
+	// 与NULL常量比较等同于IS NULL和IS NOT NULL
+	if (e.op == "=" || e.op == "!=") && (isQueryNull(e.left) || isQueryNull(e.right)) {
+		return (l == nil && r == nil) == (e.op == "=")
+	}
+	if l == nil || r == nil {
+		return nil
+	}
+	switch e.op {
+	case "=":
+		return queryEqual(l, r)
+	case "!=":
+		return !queryEqual(l, r)
+	case "~", "!~", "like":
+		pattern := e.pattern
+		if pattern == nil {
+			if e.op == "like" {
+				pattern = likePattern(queryString(r))
+			} else if re, err := regexp.Compile(queryString(r)); err == nil {
+				pattern = re
+			}
+		}
+		matched := pattern != nil && pattern.MatchString(queryString(l))
+		if e.op == "!~" {
+			return !matched
+		}
+		return matched
+	case "contains":
+		return strings.Contains(queryString(l), queryString(r))
+	}
+
+	c, ok := queryCompareValues(l, r)
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+// isQueryNull 判断表达式是否为NULL常量
+func isQueryNull(expr queryExpr) bool {
+	lit, ok := expr.(*queryLiteral)
+	return ok && lit.value == nil
+}
+
+// queryAggregate 聚合函数，index为其在分组状态中的槽位
+type queryAggregate struct {
+	fn         string
+	arg        queryExpr
+	percentile float64
+	index      int
+}
+
+func (e *queryAggregate) eval(ctx *queryContext) interface{} {
+	if ctx.group == nil {
+		return nil
+	}
+	count := ctx.group.counts[e.index]
+	values := ctx.group.values[e.index]
+	if e.fn == "count" {
+		return float64(count)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	switch e.fn {
+	case "sum", "avg":
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		if e.fn == "avg" {
+			return sum / float64(len(values))
+		}
+		return sum
+	case "min", "max":
+		result := values[0]
+		for _, v := range values[1:] {
+			if (e.fn == "min" && v < result) || (e.fn == "max" && v > result) {
+				result = v
+			}
+		}
+		return result
+	case "percentile":
+		return percentile(values, e.percentile)
+	}
+	return nil
+}
+
+// accumulate 将一个条目计入分组
+func (e *queryAggregate) accumulate(group *queryGroup, entry *Entries) {
+	if e.arg == nil {
+		group.counts[e.index]++
+		return
+	}
+	v := e.arg.eval(&queryContext{entry: entry})
+	if v == nil {
+		return
+	}
+	group.counts[e.index]++
+	if n, ok := queryNumber(v); ok {
+		group.values[e.index] = append(group.values[e.index], n)
+	}
+}
+
+// queryAggregates 聚合函数名
+var queryAggregates = map[string]bool{
+	"count": true, "sum": true, "avg": true, "min": true, "max": true, "percentile": true,
+}
+
+// queryPercentileAliases 百分位聚合函数的简写
+var queryPercentileAliases = map[string]float64{
+	"median": 50, "p50": 50, "p75": 75, "p90": 90, "p95": 95, "p99": 99,
+}
+
+// percentile 使用线性插值计算百分位数，values会被原地排序
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	if len(values) == 1 {
+		return values[0]
+	}
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+}
+
+// queryFields 查询中可用的字段
+var queryFields = map[string]func(*Entries) interface{}{
+	"url":                   func(e *Entries) interface{} { return e.Request.URL },
+	"host":                  func(e *Entries) interface{} { return queryURLPart(e, "host") },
+	"path":                  func(e *Entries) interface{} { return queryURLPart(e, "path") },
+	"scheme":                func(e *Entries) interface{} { return queryURLPart(e, "scheme") },
+	"query_string":          func(e *Entries) interface{} { return queryURLPart(e, "query") },
+	"method":                func(e *Entries) interface{} { return e.Request.Method },
+	"http_version":          func(e *Entries) interface{} { return e.Request.HTTPVersion },
+	"status":                func(e *Entries) interface{} { return float64(e.Response.Status) },
+	"status_text":           func(e *Entries) interface{} { return e.Response.StatusText },
+	"mime_type":             func(e *Entries) interface{} { return e.Response.Content.MimeType },
+	"redirect_url":          func(e *Entries) interface{} { return optionalQueryString(e.Response.RedirectURL) },
+	"request_mime_type":     queryRequestMimeType,
+	"request_body":          queryRequestBody,
+	"response_body":         queryResponseBody,
+	"request_headers_size":  func(e *Entries) interface{} { return querySize(e.Request.HeadersSize) },
+	"request_body_size":     func(e *Entries) interface{} { return querySize(e.Request.BodySize) },
+	"response_headers_size": func(e *Entries) interface{} { return querySize(e.Response.HeadersSize) },
+	"response_body_size":    func(e *Entries) interface{} { return querySize(e.Response.BodySize) },
+	"size":                  func(e *Entries) interface{} { return querySize(e.Response.Content.Size) },
+	"transfer_size":         func(e *Entries) interface{} { return querySize(e.Response.TransferSize) },
+	"started":               queryStarted,
+	"time":                  func(e *Entries) interface{} { return e.Time },
+	"blocked":               func(e *Entries) interface{} { return queryTiming(e.Timings.Blocked) },
+	"dns":                   func(e *Entries) interface{} { return queryTiming(e.Timings.DNS) },
+	"connect":               func(e *Entries) interface{} { return queryTiming(e.Timings.Connect) },
+	"ssl":                   func(e *Entries) interface{} { return queryTiming(e.Timings.Ssl) },
+	"send":                  func(e *Entries) interface{} { return queryTiming(e.Timings.Send) },
+	"wait":                  func(e *Entries) interface{} { return queryTiming(e.Timings.Wait) },
+	"receive":               func(e *Entries) interface{} { return queryTiming(e.Timings.Receive) },
+	"page":                  func(e *Entries) interface{} { return optionalQueryString(e.Pageref) },
+	"server_ip":             func(e *Entries) interface{} { return optionalQueryString(e.ServerIPAddress) },
+	"connection":            func(e *Entries) interface{} { return optionalQueryString(e.Connection) },
+	"resource_type":         func(e *Entries) interface{} { return optionalQueryString(e.ResourceType) },
+	"priority":              func(e *Entries) interface{} { return optionalQueryString(e.Priority) },
+	"initiator":             func(e *Entries) interface{} { return optionalQueryString(e.Initiator.Type) },
+}
+
+// queryFieldAliases 字段的别名，使用HAR中的原始命名
+var queryFieldAliases = map[string]string{
+	"status_code":       "status",
+	"pageref":           "page",
+	"server_ip_address": "server_ip",
+	"timings.blocked":   "blocked",
+	"timings.dns":       "dns",
+	"timings.connect":   "connect",
+	"timings.ssl":       "ssl",
+	"timings.send":      "send",
+	"timings.wait":      "wait",
+	"timings.receive":   "receive",
+}
+
+// queryFunctionSpec 标量函数定义
+type queryFunctionSpec struct {
+	args int
+	fn   func(*Entries, []interface{}) interface{}
+}
+
+// queryFunctions 查询中可用的标量函数
+var queryFunctions = map[string]queryFunctionSpec{
+	"header": {1, func(e *Entries, args []interface{}) interface{} {
+		return queryHeader(e.Request.Headers, queryString(args[0]))
+	}},
+	"response_header": {1, func(e *Entries, args []interface{}) interface{} {
+		return queryHeader(e.Response.Headers, queryString(args[0]))
+	}},
+	"query": {1, func(e *Entries, args []interface{}) interface{} {
+		return queryParam(e, queryString(args[0]))
+	}},
+	"cookie": {1, func(e *Entries, args []interface{}) interface{} {
+		return queryCookie(e.Request.Cookies, e.Request.Headers, "cookie", queryString(args[0]))
+	}},
+	"response_cookie": {1, func(e *Entries, args []interface{}) interface{} {
+		return queryCookie(e.Response.Cookies, e.Response.Headers, "set-cookie", queryString(args[0]))
+	}},
+	"lower": {1, func(_ *Entries, args []interface{}) interface{} {
+		if args[0] == nil {
+			return nil
+		}
+		return strings.ToLower(queryString(args[0]))
+	}},
+	"upper": {1, func(_ *Entries, args []interface{}) interface{} {
+		if args[0] == nil {
+			return nil
+		}
+		return strings.ToUpper(queryString(args[0]))
+	}},
+	"length": {1, func(_ *Entries, args []interface{}) interface{} {
+		if args[0] == nil {
+			return nil
+		}
+		return float64(len(queryString(args[0])))
+	}},
+}
+
+// QueryFields 返回查询语言支持的字段名
+func QueryFields() []string {
+	names := make([]string, 0, len(queryFields)+len(queryFieldAliases))
+	for name := range queryFields {
+		names = append(names, name)
+	}
+	for name := range queryFieldAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// QueryFunctions 返回查询语言支持的函数名，包括聚合函数
+func QueryFunctions() []string {
+	names := make([]string, 0, len(queryFunctions)+len(queryAggregates)+len(queryPercentileAliases))
+	for name := range queryFunctions {
+		names = append(names, name)
+	}
+	for name := range queryAggregates {
+		names = append(names, name)
+	}
+	for name := range queryPercentileAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupQueryField 查找字段，支持别名
+func lookupQueryField(name string) (func(*Entries) interface{}, bool) {
+	name = strings.ToLower(name)
+	if alias, ok := queryFieldAliases[name]; ok {
+		name = alias
+	}
+	get, ok := queryFields[name]
+	return get, ok
+}
+
+func queryURLPart(e *Entries, part string) interface{} {
+	u, err := url.Parse(e.Request.URL)
+	if err != nil {
+		return nil
+	}
+	switch part {
+	case "host":
+		return u.Hostname()
+	case "path":
+		return u.Path
+	case "scheme":
+		return u.Scheme
+	case "query":
+		return optionalQueryString(u.RawQuery)
+	}
+	return nil
+}
+
+func queryRequestMimeType(e *Entries) interface{} {
+	mimeType, _ := postDataText(e.Request)
+	return optionalQueryString(mimeType)
+}
+
+func queryRequestBody(e *Entries) interface{} {
+	_, text := postDataText(e.Request)
+	return optionalQueryString(text)
+}
+
+func queryResponseBody(e *Entries) interface{} {
+	if e.Response.Content.Text == "" {
+		return nil
+	}
+	body, err := decodeContentText(e.Response.Content)
+	if err != nil {
+		return e.Response.Content.Text
+	}
+	return string(body)
+}
+
+func queryStarted(e *Entries) interface{} {
+	if e.StartedDateTime.IsZero() {
+		return nil
+	}
+	return e.StartedDateTime.UTC().Format(time.RFC3339Nano)
+}
+
+func queryHeader(headers []Headers, name string) interface{} {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return nil
+}
+
+func queryParam(e *Entries, name string) interface{} {
+	for _, param := range e.Request.QueryString {
+		if param.Name == name {
+			return param.Value
+		}
+	}
+	if u, err := url.Parse(e.Request.URL); err == nil {
+		if values, ok := u.Query()[name]; ok && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return nil
+}
+
+func queryCookie(cookies []Cookie, headers []Headers, headerName, name string) interface{} {
+	if len(cookies) == 0 {
+		cookies = cookiesFromHeaders(headers, headerName)
+	}
+	for _, c := range cookies {
+		if c.Name == name {
+			return c.Value
+		}
+	}
+	return nil
+}
+
+// queryTiming 计时阶段为-1时表示不适用，返回NULL
+func queryTiming(v float64) interface{} {
+	if v < 0 {
+		return nil
+	}
+	return v
+}
+
+// querySize 大小为-1时表示未知，返回NULL
+func querySize(v int) interface{} {
+	if v < 0 {
+		return nil
+	}
+	return float64(v)
+}
+
+func optionalQueryString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// queryTruthy 判断值在布尔上下文中是否为真
+func queryTruthy(v interface{}) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case float64:
+		return val != 0
+	case string:
+		return val != ""
+	}
+	return false
+}
+
+// queryString 将值转换为字符串，NULL转换为空字符串
+func queryString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	}
+	return fmt.Sprint(v)
+}
+
+// queryNumber 将值转换为数字，字符串需能完整解析为数字
+func queryNumber(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// queryCompareValues 比较两个值，两者都可转换为数字时按数字比较，否则按字符串比较。
+// 任一值为NULL时返回false
+func queryCompareValues(a, b interface{}) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+	if an, ok := queryNumber(a); ok {
+		if bn, ok := queryNumber(b); ok {
+			switch {
+			case an < bn:
+				return -1, true
+			case an > bn:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	return strings.Compare(queryString(a), queryString(b)), true
+}
+
+// queryEqual 判断两个值是否相等，NULL只与NULL相等
+func queryEqual(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	c, _ := queryCompareValues(a, b)
+	return c == 0
+}
+
+// queryOrderCompare 排序比较，NULL排在最前
+func queryOrderCompare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	c, _ := queryCompareValues(a, b)
+	return c
+}
+
+// String 返回查询原文
+func (q *Query) String() string {
+	return q.source
+}
+
+// Match 判断条目是否满足查询的条件部分，忽略投影、分组和排序
+func (q *Query) Match(entry *Entries) bool {
+	if q.where == nil {
+		return true
+	}
+	return queryTruthy(q.where.eval(&queryContext{entry: entry}))
+}
+
+// Columns 返回结果的列名
+func (q *Query) Columns() []string {
+	var columns []string
+	for _, item := range q.selects {
+		columns = append(columns, item.name)
+	}
+	if len(columns) > 0 {
+		return columns
+	}
+	return []string{"method", "url", "status", "mime_type", "time"}
+}
+
+// projection 返回结果列对应的表达式
+func (q *Query) projection() []queryExpr {
+	var exprs []queryExpr
+	for _, item := range q.selects {
+		exprs = append(exprs, item.expr)
+	}
+	if len(exprs) > 0 {
+		return exprs
+	}
+	for _, name := range []string{"method", "url", "status", "mime_type", "time"} {
+		exprs = append(exprs, &queryField{name: name, get: queryFields[name]})
+	}
+	return exprs
+}
+
+// Execute 对HAR中的条目执行查询
+func (q *Query) Execute(h *Har) (*QueryResult, error) {
+	return q.ExecuteIterator(h.Iterator())
+}
+
+// ExecuteIterator 对迭代器中的条目执行查询
+//
+// 聚合查询只为每个分组保留一个条目和聚合所需的数值，适合流式处理大型HAR文件。
+// 非聚合查询在没有ORDER BY时达到LIMIT后即停止读取。
+func (q *Query) ExecuteIterator(it EntryIterator) (*QueryResult, error) {
+	if q.aggregate {
+		return q.executeAggregate(it)
+	}
+
+	result := &QueryResult{Columns: q.Columns()}
+	exprs := q.projection()
+	var keys [][]interface{}
+	for (q.limit < 0 || len(q.orderBy) > 0 || len(result.Entries) < q.limit) && it.Next() {
+		entry := *it.Entry()
+		if !q.Match(&entry) {
+			continue
+		}
+		ctx := &queryContext{entry: &entry}
+		result.Entries = append(result.Entries, entry)
+		result.Rows = append(result.Rows, evalQueryExprs(exprs, ctx))
+		keys = append(keys, q.orderKeys(ctx))
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(q.orderBy) > 0 {
+		index := make([]int, len(result.Rows))
+		for i := range index {
+			index[i] = i
+		}
+		sort.SliceStable(index, func(i, j int) bool {
+			return q.orderLess(keys[index[i]], keys[index[j]])
+		})
+		rows := make([][]interface{}, len(index))
+		entries := make([]Entries, len(index))
+		for i, idx := range index {
+			rows[i] = result.Rows[idx]
+			entries[i] = result.Entries[idx]
+		}
+		result.Rows, result.Entries = rows, entries
+	}
+	if q.limit >= 0 && len(result.Rows) > q.limit {
+		result.Rows = result.Rows[:q.limit]
+		result.Entries = result.Entries[:q.limit]
+	}
+	return result, nil
+}
+
+func (q *Query) executeAggregate(it EntryIterator) (*QueryResult, error) {
+	exprs := q.projection()
+	aggregates := q.aggregates
+
+	newGroup := func() *queryGroup {
+		return &queryGroup{
+			counts: make([]int, len(aggregates)),
+			values: make([][]float64, len(aggregates)),
+		}
+	}
+	groups := make(map[string]*queryGroup)
+	var order []*queryGroup
+
+	for it.Next() {
+		entry := it.Entry()
+		if !q.Match(entry) {
+			continue
+		}
+		ctx := &queryContext{entry: entry}
+		var key strings.Builder
+		for _, expr := range q.groupBy {
+			v := expr.eval(ctx)
+			fmt.Fprintf(&key, "%T:%s\x00", v, queryString(v))
+		}
+		group, ok := groups[key.String()]
+		if !ok {
+			group = newGroup()
+			first := *entry
+			group.first = &first
+			groups[key.String()] = group
+			order = append(order, group)
+		}
+		for _, agg := range aggregates {
+			agg.accumulate(group, entry)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	// 没有GROUP BY时即使没有匹配的条目也返回一行，与SQL一致
+	if len(order) == 0 && len(q.groupBy) == 0 {
+		order = append(order, newGroup())
+	}
+
+	result := &QueryResult{Columns: q.Columns()}
+	var keys [][]interface{}
+	for _, group := range order {
+		ctx := &queryContext{entry: group.first, group: group}
+		result.Rows = append(result.Rows, evalQueryExprs(exprs, ctx))
+		keys = append(keys, q.orderKeys(ctx))
+	}
+	if len(q.orderBy) > 0 {
+		index := make([]int, len(result.Rows))
+		for i := range index {
+			index[i] = i
+		}
+		sort.SliceStable(index, func(i, j int) bool {
+			return q.orderLess(keys[index[i]], keys[index[j]])
+		})
+		rows := make([][]interface{}, len(index))
+		for i, idx := range index {
+			rows[i] = result.Rows[idx]
+		}
+		result.Rows = rows
+	}
+	if q.limit >= 0 && len(result.Rows) > q.limit {
+		result.Rows = result.Rows[:q.limit]
+	}
+	return result, nil
+}
+
+// collectQueryAggregates 收集投影和排序中的聚合函数并分配槽位
+func collectQueryAggregates(selects []querySelectItem, orderBy []queryOrder) []*queryAggregate {
+	var aggregates []*queryAggregate
+	seen := make(map[*queryAggregate]bool)
+	var walk func(expr queryExpr)
+	walk = func(expr queryExpr) {
+		switch e := expr.(type) {
+		case *queryAggregate:
+			if seen[e] {
+				return
+			}
+			seen[e] = true
+			e.index = len(aggregates)
+			aggregates = append(aggregates, e)
+		case *queryLogical:
+			walk(e.left)
+			walk(e.right)
+		case *queryNot:
+			walk(e.expr)
+		case *queryCompare:
+			walk(e.left)
+			walk(e.right)
+		case *queryIn:
+			walk(e.expr)
+			for _, item := range e.list {
+				walk(item)
+			}
+		case *queryCall:
+			for _, arg := range e.args {
+				walk(arg)
+			}
+		}
+	}
+	for _, item := range selects {
+		walk(item.expr)
+	}
+	for _, order := range orderBy {
+		walk(order.expr)
+	}
+	return aggregates
+}
+
+func evalQueryExprs(exprs []queryExpr, ctx *queryContext) []interface{} {
+	row := make([]interface{}, len(exprs))
+	for i, expr := range exprs {
+		row[i] = expr.eval(ctx)
+	}
+	return row
+}
+
+func (q *Query) orderKeys(ctx *queryContext) []interface{} {
+	if len(q.orderBy) == 0 {
+		return nil
+	}
+	keys := make([]interface{}, len(q.orderBy))
+	for i, order := range q.orderBy {
+		keys[i] = order.expr.eval(ctx)
+	}
+	return keys
+}
+
+func (q *Query) orderLess(a, b []interface{}) bool {
+	for i, order := range q.orderBy {
+		c := queryOrderCompare(a[i], b[i])
+		if c == 0 {
+			continue
+		}
+		if order.desc {
+			return c > 0
+		}
+		return c < 0
+	}
+	return false
+}
+
+// Query 解析并执行查询语句，语法参见ParseQuery
+//
+// 示例:
+//
+//	result, err := h.Query(`SELECT host, count(*) AS n, avg(wait) WHERE status >= 400 GROUP BY host ORDER BY n DESC`)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	result.WriteTable(os.Stdout)
+func (h *Har) Query(query string) (*QueryResult, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return q.Execute(h)
+}
+
+// Count 返回结果行数
+func (r *QueryResult) Count() int {
+	return len(r.Rows)
+}
+
+// Records 将结果转换为以列名为键的记录，便于序列化为JSON
+func (r *QueryResult) Records() []map[string]interface{} {
+	records := make([]map[string]interface{}, len(r.Rows))
+	for i, row := range r.Rows {
+		record := make(map[string]interface{}, len(r.Columns))
+		for j, column := range r.Columns {
+			record[column] = row[j]
+		}
+		records[i] = record
+	}
+	return records
+}
+
+// WriteTable 以对齐的文本表格输出结果，NULL显示为空
+func (r *QueryResult) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(r.Columns, "\t"))
+	for _, row := range r.Rows {
+		fmt.Fprintln(tw, strings.Join(formatQueryRow(row), "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteCSV 以CSV格式输出结果，第一行为列名
+func (r *QueryResult) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(r.Columns); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		if err := cw.Write(formatQueryRow(row)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatQueryRow(row []interface{}) []string {
+	cells := make([]string, len(row))
+	for i, v := range row {
+		if n, ok := v.(float64); ok && n != math.Trunc(n) {
+			cells[i] = strconv.FormatFloat(n, 'f', 2, 64)
+			continue
+		}
+		cells[i] = queryString(v)
+	}
+	return cells
+}
@@ -0,0 +1,711 @@
+package har
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// queryTokenKind 查询词法单元类型
+type queryTokenKind int
+
+const (
+	queryTokenEOF queryTokenKind = iota
+	queryTokenIdent
+	queryTokenString
+	queryTokenNumber
+	queryTokenOperator
+	queryTokenLParen
+	queryTokenRParen
+	queryTokenComma
+	queryTokenStar
+)
+
+// queryToken 查询词法单元，pos为其在查询文本中的字节偏移
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+	end  int
+}
+
+// queryKeywords 不能作为字段名使用的保留字
+var queryKeywords = map[string]bool{
+	"SELECT": true, "WHERE": true, "AND": true, "OR": true, "NOT": true,
+	"IN": true, "LIKE": true, "CONTAINS": true, "GROUP": true, "ORDER": true,
+	"BY": true, "ASC": true, "DESC": true, "LIMIT": true, "AS": true,
+	"FROM": true, "TRUE": true, "FALSE": true, "NULL": true,
+}
+
+// queryErrorf 构造带位置信息的查询语法错误
+func queryErrorf(source string, pos int, format string, args ...interface{}) *HarError {
+	msg := fmt.Sprintf("查询语法错误(位置%d): %s", pos, fmt.Sprintf(format, args...))
+	return NewInvalidFormatError(msg).
+		WithMetadata("query", source).
+		WithMetadata("position", pos)
+}
+
+// tokenizeQuery 将查询文本切分为词法单元
+func tokenizeQuery(source string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(source)
+	// 以字节偏移报告位置，便于与原始字符串对应
+	offsets := make([]int, len(runes)+1)
+	for i, n := 0, 0; i < len(runes); i++ {
+		offsets[i] = n
+		n += len(string(runes[i]))
+		offsets[i+1] = n
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: queryTokenLParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: queryTokenRParen, text: ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, queryToken{kind: queryTokenComma, text: ","})
+			i++
+		case r == '*':
+			tokens = append(tokens, queryToken{kind: queryTokenStar, text: "*"})
+			i++
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == r {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, queryErrorf(source, offsets[start], "字符串缺少结束引号")
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenString, text: sb.String()})
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				i++
+				if i < len(runes) && (runes[i] == '+' || runes[i] == '-') {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenNumber, text: string(runes[start:i])})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, queryToken{kind: queryTokenIdent, text: string(runes[start:i])})
+		default:
+			op := ""
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<>", "<=", ">=", "!~":
+				op = two
+			default:
+				switch r {
+				case '=', '<', '>', '~', '-':
+					op = string(r)
+				}
+			}
+			if op == "" {
+				return nil, queryErrorf(source, offsets[start], "无法识别的字符 '%c'", r)
+			}
+			i += len([]rune(op))
+			tokens = append(tokens, queryToken{kind: queryTokenOperator, text: op})
+		}
+		tokens[len(tokens)-1].pos = offsets[start]
+		tokens[len(tokens)-1].end = offsets[i]
+	}
+	tokens = append(tokens, queryToken{kind: queryTokenEOF, pos: len(source), end: len(source)})
+	return tokens, nil
+}
+
+// queryParser 递归下降的查询语法分析器
+type queryParser struct {
+	source string
+	tokens []queryToken
+	pos    int
+}
+
+// ParseQuery 解析查询语句
+//
+// 语法类似SQL，所有子句都是可选的，省略SELECT时可以直接书写条件:
+//
+//	[SELECT 列 [AS 别名], ...] [FROM entries] [WHERE] 条件 [GROUP BY 表达式, ...] [ORDER BY 表达式 [ASC|DESC], ...] [LIMIT n]
+//
+// 条件支持AND、OR、NOT和括号，比较运算符有 = != <> < <= > >=，
+// ~ 和 !~ 进行正则匹配，LIKE使用%和_通配符，另外还有CONTAINS和IN (...)。
+// 可用字段和函数参见QueryFields和QueryFunctions。
+//
+// 示例:
+//
+//	status >= 400 AND host = "api.x.com" OR header("x-cache") ~ "MISS"
+//	SELECT host, count(*) AS n, p95(time) WHERE resource_type = "xhr" GROUP BY host ORDER BY n DESC LIMIT 10
+func ParseQuery(query string) (*Query, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{source: query, tokens: tokens}
+	q, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != queryTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isKeyword 判断当前词法单元是否为指定关键字(不区分大小写)
+func (p *queryParser) isKeyword(keyword string) bool {
+	tok := p.peek()
+	return tok.kind == queryTokenIdent && strings.EqualFold(tok.text, keyword)
+}
+
+// acceptKeyword 当前词法单元为指定关键字时消费它
+func (p *queryParser) acceptKeyword(keyword string) bool {
+	if p.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expectKeyword(keyword string) error {
+	if !p.acceptKeyword(keyword) {
+		return p.errorf(p.peek(), "应为 %s", keyword)
+	}
+	return nil
+}
+
+func (p *queryParser) expect(kind queryTokenKind, text string) error {
+	tok := p.peek()
+	if tok.kind != kind {
+		return p.errorf(tok, "应为 '%s'", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *queryParser) errorf(tok queryToken, format string, args ...interface{}) *HarError {
+	if tok.kind == queryTokenEOF {
+		return queryErrorf(p.source, tok.pos, format+"，但查询已结束", args...)
+	}
+	return queryErrorf(p.source, tok.pos, format+"，实际为 '%s'", append(args, tok.text)...)
+}
+
+// atClauseEnd 判断条件表达式之后是否紧跟子句关键字或查询结束
+func (p *queryParser) atClauseEnd() bool {
+	return p.peek().kind == queryTokenEOF || p.isKeyword("GROUP") || p.isKeyword("ORDER") || p.isKeyword("LIMIT")
+}
+
+func (p *queryParser) parse() (*Query, error) {
+	q := &Query{source: p.source, limit: -1}
+
+	if p.acceptKeyword("SELECT") {
+		if err := p.parseSelect(q); err != nil {
+			return nil, err
+		}
+	}
+	if p.acceptKeyword("FROM") {
+		if err := p.expectKeyword("entries"); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.acceptKeyword("WHERE") || !p.atClauseEnd() {
+		start := p.peek()
+		where, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if containsAggregate(where) {
+			return nil, queryErrorf(p.source, start.pos, "WHERE条件中不能使用聚合函数")
+		}
+		q.where = where
+	}
+
+	if p.acceptKeyword("GROUP") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			tok := p.peek()
+			expr, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if containsAggregate(expr) {
+				return nil, queryErrorf(p.source, tok.pos, "GROUP BY中不能使用聚合函数")
+			}
+			q.groupBy = append(q.groupBy, expr)
+			q.groupNames = append(q.groupNames, strings.TrimSpace(p.source[tok.pos:p.tokens[p.pos-1].end]))
+			if p.peek().kind != queryTokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.acceptKeyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			expr, err := p.parseOrderExpr(q)
+			if err != nil {
+				return nil, err
+			}
+			order := queryOrder{expr: expr}
+			if p.acceptKeyword("DESC") {
+				order.desc = true
+			} else {
+				p.acceptKeyword("ASC")
+			}
+			q.orderBy = append(q.orderBy, order)
+			if p.peek().kind != queryTokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	if p.acceptKeyword("LIMIT") {
+		tok := p.next()
+		n, err := strconv.Atoi(tok.text)
+		if tok.kind != queryTokenNumber || err != nil || n < 0 {
+			return nil, p.errorf(tok, "LIMIT后应为非负整数")
+		}
+		q.limit = n
+	}
+
+	if tok := p.peek(); tok.kind != queryTokenEOF {
+		return nil, p.errorf(tok, "多余的内容")
+	}
+
+	q.aggregate = len(q.groupBy) > 0
+	for _, item := range q.selects {
+		if containsAggregate(item.expr) {
+			q.aggregate = true
+		}
+	}
+	for _, order := range q.orderBy {
+		if containsAggregate(order.expr) {
+			q.aggregate = true
+		}
+	}
+	if q.aggregate {
+		// 聚合查询省略投影时输出分组列和每组的条目数
+		if len(q.selects) == 0 {
+			for i, expr := range q.groupBy {
+				q.selects = append(q.selects, querySelectItem{expr: expr, name: q.groupNames[i]})
+			}
+			q.selects = append(q.selects, querySelectItem{expr: &queryAggregate{fn: "count"}, name: "count(*)"})
+		}
+		q.aggregates = collectQueryAggregates(q.selects, q.orderBy)
+	}
+	return q, nil
+}
+
+// parseSelect 解析投影列表，SELECT * 等价于省略投影
+func (p *queryParser) parseSelect(q *Query) error {
+	if p.peek().kind == queryTokenStar {
+		p.next()
+		return nil
+	}
+	for {
+		start := p.peek()
+		expr, err := p.parseAdditive()
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSpace(p.source[start.pos:p.tokens[p.pos-1].end])
+		if p.acceptKeyword("AS") {
+			tok := p.next()
+			if tok.kind != queryTokenIdent && tok.kind != queryTokenString {
+				return p.errorf(tok, "AS后应为列名")
+			}
+			name = tok.text
+		}
+		q.selects = append(q.selects, querySelectItem{expr: expr, name: name})
+		if p.peek().kind != queryTokenComma {
+			return nil
+		}
+		p.next()
+	}
+}
+
+// parseOrderExpr 解析排序表达式，单独的标识符优先解析为SELECT中的列别名
+func (p *queryParser) parseOrderExpr(q *Query) (queryExpr, error) {
+	tok := p.peek()
+	if tok.kind == queryTokenIdent && p.tokens[p.pos+1].kind != queryTokenLParen {
+		for _, item := range q.selects {
+			if item.name == tok.text {
+				p.next()
+				return item.expr, nil
+			}
+		}
+	}
+	return p.parseAdditive()
+}
+
+func (p *queryParser) parseOr() (queryExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &queryLogical{or: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &queryLogical{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryExpr, error) {
+	if p.acceptKeyword("NOT") {
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNot{expr: expr}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseComparison() (queryExpr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	negate := false
+	if p.isKeyword("NOT") {
+		following := p.tokens[p.pos+1]
+		if following.kind == queryTokenIdent && (strings.EqualFold(following.text, "IN") ||
+			strings.EqualFold(following.text, "LIKE") || strings.EqualFold(following.text, "CONTAINS")) {
+			p.next()
+			negate = true
+		}
+	}
+
+	tok := p.peek()
+	var expr queryExpr
+	switch {
+	case p.acceptKeyword("IN"):
+		list, err := p.parseInList()
+		if err != nil {
+			return nil, err
+		}
+		expr = &queryIn{expr: left, list: list}
+	case p.acceptKeyword("LIKE"):
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		cmp := &queryCompare{op: "like", left: left, right: right}
+		if lit, ok := right.(*queryLiteral); ok {
+			cmp.pattern = likePattern(queryString(lit.value))
+		}
+		expr = cmp
+	case p.acceptKeyword("CONTAINS"):
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		expr = &queryCompare{op: "contains", left: left, right: right}
+	case tok.kind == queryTokenOperator && tok.text != "-":
+		p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		op := tok.text
+		switch op {
+		case "==":
+			op = "="
+		case "<>":
+			op = "!="
+		}
+		cmp := &queryCompare{op: op, left: left, right: right}
+		if op == "~" || op == "!~" {
+			if lit, ok := right.(*queryLiteral); ok {
+				re, err := regexp.Compile(queryString(lit.value))
+				if err != nil {
+					return nil, queryErrorf(p.source, tok.pos, "无效的正则表达式 '%s': %v", queryString(lit.value), err)
+				}
+				cmp.pattern = re
+			}
+		}
+		expr = cmp
+	default:
+		if negate {
+			return nil, p.errorf(tok, "NOT后应为IN、LIKE或CONTAINS")
+		}
+		return left, nil
+	}
+
+	if negate {
+		return &queryNot{expr: expr}, nil
+	}
+	return expr, nil
+}
+
+func (p *queryParser) parseInList() ([]queryExpr, error) {
+	if err := p.expect(queryTokenLParen, "("); err != nil {
+		return nil, err
+	}
+	var list []queryExpr
+	for {
+		expr, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, expr)
+		if p.peek().kind != queryTokenComma {
+			break
+		}
+		p.next()
+	}
+	if err := p.expect(queryTokenRParen, ")"); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// parseAdditive 解析取负等一元运算，查询语言不支持算术运算
+func (p *queryParser) parseAdditive() (queryExpr, error) {
+	tok := p.peek()
+	if tok.kind == queryTokenOperator && tok.text == "-" {
+		p.next()
+		num := p.next()
+		if num.kind != queryTokenNumber {
+			return nil, p.errorf(num, "'-'后应为数字")
+		}
+		v, err := strconv.ParseFloat(num.text, 64)
+		if err != nil {
+			return nil, p.errorf(num, "无效的数字")
+		}
+		return &queryLiteral{value: -v}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryExpr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case queryTokenString:
+		return &queryLiteral{value: tok.text}, nil
+	case queryTokenNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "无效的数字")
+		}
+		return &queryLiteral{value: v}, nil
+	case queryTokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(queryTokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case queryTokenIdent:
+		upper := strings.ToUpper(tok.text)
+		switch upper {
+		case "TRUE":
+			return &queryLiteral{value: true}, nil
+		case "FALSE":
+			return &queryLiteral{value: false}, nil
+		case "NULL":
+			return &queryLiteral{value: nil}, nil
+		}
+		if p.peek().kind == queryTokenLParen {
+			return p.parseCall(tok)
+		}
+		if queryKeywords[upper] {
+			return nil, p.errorf(tok, "应为字段、常量或函数")
+		}
+		getter, ok := lookupQueryField(tok.text)
+		if !ok {
+			return nil, queryErrorf(p.source, tok.pos, "未知字段 '%s'", tok.text)
+		}
+		return &queryField{name: strings.ToLower(tok.text), get: getter}, nil
+	}
+	return nil, p.errorf(tok, "应为字段、常量或函数")
+}
+
+// parseCall 解析函数调用，包括标量函数和聚合函数
+func (p *queryParser) parseCall(name queryToken) (queryExpr, error) {
+	p.next() // (
+	fn := strings.ToLower(name.text)
+
+	if pct, ok := queryPercentileAliases[fn]; ok || queryAggregates[fn] {
+		agg := &queryAggregate{fn: fn, percentile: pct}
+		if ok {
+			agg.fn = "percentile"
+		}
+		if p.peek().kind == queryTokenStar {
+			if fn != "count" {
+				return nil, p.errorf(p.peek(), "只有count可以使用 *")
+			}
+			p.next()
+		} else {
+			arg, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if containsAggregate(arg) {
+				return nil, queryErrorf(p.source, name.pos, "聚合函数不能嵌套")
+			}
+			agg.arg = arg
+		}
+		if fn == "percentile" {
+			if err := p.expect(queryTokenComma, ","); err != nil {
+				return nil, err
+			}
+			tok := p.next()
+			v, err := strconv.ParseFloat(tok.text, 64)
+			if tok.kind != queryTokenNumber || err != nil || v < 0 || v > 100 {
+				return nil, p.errorf(tok, "百分位应为0到100之间的数字")
+			}
+			agg.percentile = v
+		}
+		if agg.arg == nil && fn != "count" {
+			return nil, queryErrorf(p.source, name.pos, "%s需要一个参数", fn)
+		}
+		if err := p.expect(queryTokenRParen, ")"); err != nil {
+			return nil, err
+		}
+		return agg, nil
+	}
+
+	spec, ok := queryFunctions[fn]
+	if !ok {
+		return nil, queryErrorf(p.source, name.pos, "未知函数 '%s'", name.text)
+	}
+	var args []queryExpr
+	if p.peek().kind != queryTokenRParen {
+		for {
+			arg, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != queryTokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect(queryTokenRParen, ")"); err != nil {
+		return nil, err
+	}
+	if len(args) != spec.args {
+		return nil, queryErrorf(p.source, name.pos, "函数%s需要%d个参数，实际为%d个", fn, spec.args, len(args))
+	}
+	return &queryCall{fn: spec.fn, args: args}, nil
+}
+
+// likePattern 将SQL LIKE模式转换为正则表达式，%匹配任意字符串，_匹配单个字符
+func likePattern(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
+
+// containsAggregate 判断表达式中是否包含聚合函数
+func containsAggregate(expr queryExpr) bool {
+	switch e := expr.(type) {
+	case *queryAggregate:
+		return true
+	case *queryLogical:
+		return containsAggregate(e.left) || containsAggregate(e.right)
+	case *queryNot:
+		return containsAggregate(e.expr)
+	case *queryCompare:
+		return containsAggregate(e.left) || containsAggregate(e.right)
+	case *queryIn:
+		if containsAggregate(e.expr) {
+			return true
+		}
+		for _, item := range e.list {
+			if containsAggregate(item) {
+				return true
+			}
+		}
+	case *queryCall:
+		for _, arg := range e.args {
+			if containsAggregate(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package har
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newQueryTestHar() *Har {
+	h := NewHar()
+	add := func(method, url string, status int, wait float64, cache string) {
+		e := h.AddEntry(method, url, "HTTP/1.1", "page_1")
+		e.SetResponseStatus(status, "")
+		e.SetTimings(-1, -1, -1, 1, wait, 1, -1)
+		if cache != "" {
+			e.AddResponseHeader("X-Cache", cache)
+		}
+	}
+	add("GET", "https://api.x.com/users?id=1", 200, 10, "HIT")
+	add("GET", "https://api.x.com/users?id=2", 500, 40, "MISS")
+	add("POST", "https://api.x.com/orders", 404, 20, "")
+	add("GET", "https://cdn.x.com/app.js", 200, 30, "MISS")
+	h.Log.Entries[3].ResourceType = "script"
+	return h
+}
+
+func TestQueryWhere(t *testing.T) {
+	h := newQueryTestHar()
+
+	result, err := h.Query(`status >= 400 AND host = "api.x.com" OR response_header("x-cache") ~ "MISS"`)
+	assert.NoError(t, err)
+	var urls []string
+	for _, e := range result.Entries {
+		urls = append(urls, e.Request.URL)
+	}
+	assert.Equal(t, []string{
+		"https://api.x.com/users?id=2",
+		"https://api.x.com/orders",
+		"https://cdn.x.com/app.js",
+	}, urls)
+
+	result, err = h.Query(`method IN ("POST", "PUT") OR NOT query("id") = "1" AND resource_type = "script"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Count(), "app.js没有id参数，NOT NULL仍为NULL")
+	result, err = h.Query(`method IN ("POST", "PUT") OR query("id") = NULL AND resource_type = "script"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Count())
+
+	// 不存在的头部为NULL，与任何值比较(包括NOT和NOT IN)都不匹配
+	for where, count := range map[string]int{
+		`response_header("x-cache") != "HIT"`:                    2,
+		`response_header("x-cache") !~ "HIT"`:                    2,
+		`response_header("x-cache") LIKE "%"`:                    3,
+		`NOT response_header("x-cache") = "HIT"`:                 2,
+		`NOT response_header("x-cache") ~ "HIT"`:                 2,
+		`response_header("x-cache") NOT IN ("HIT")`:              2,
+		`NOT (response_header("x-cache") = "HIT" AND 1)`:         2,
+		`response_header("x-cache") = "MISS" OR method = "POST"`: 3,
+		`response_header("x-cache") != NULL`:                     3,
+	} {
+		result, err = h.Query(where)
+		assert.NoError(t, err)
+		assert.Equal(t, count, result.Count(), where)
+	}
+	result, err = h.Query(`response_header("x-cache") = NULL`)
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, result.Count()) {
+		assert.Equal(t, "https://api.x.com/orders", result.Entries[0].Request.URL)
+	}
+
+	// 值为-1的计时阶段为NULL
+	for _, where := range []string{`dns >= 0`, `dns != 5`, `dns !~ "5"`, `ssl < 1`} {
+		result, err = h.Query(where)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, result.Count(), where)
+	}
+	result, err = h.Query(`NOT dns = 5`)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Count())
+	result, err = h.Query(`dns = NULL`)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, result.Count())
+}
+
+func TestQueryProjectionOrderLimit(t *testing.T) {
+	h := newQueryTestHar()
+
+	result, err := h.Query(`SELECT url, wait AS w WHERE method = "GET" ORDER BY w DESC LIMIT 2`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"url", "w"}, result.Columns)
+	assert.Equal(t, [][]interface{}{
+		{"https://api.x.com/users?id=2", 40.0},
+		{"https://cdn.x.com/app.js", 30.0},
+	}, result.Rows)
+	assert.Len(t, result.Entries, 2)
+}
+
+func TestQueryGroupBy(t *testing.T) {
+	h := newQueryTestHar()
+
+	result, err := h.Query(`SELECT host, count(*) AS n, avg(wait), max(wait), p50(wait) GROUP BY host ORDER BY n DESC`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"host", "n", "avg(wait)", "max(wait)", "p50(wait)"}, result.Columns)
+	assert.Equal(t, [][]interface{}{
+		{"api.x.com", 3.0, 70.0 / 3, 40.0, 20.0},
+		{"cdn.x.com", 1.0, 30.0, 30.0, 30.0},
+	}, result.Rows)
+	assert.Nil(t, result.Entries)
+
+	// 没有GROUP BY的聚合返回单行，即使没有匹配的条目
+	result, err = h.Query(`SELECT count(*), sum(wait), percentile(wait, 75) WHERE status = 200`)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]interface{}{{2.0, 40.0, 25.0}}, result.Rows)
+
+	result, err = h.Query(`SELECT count(*), sum(wait) WHERE status = 302`)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]interface{}{{0.0, nil}}, result.Rows)
+
+	result, err = h.Query(`GROUP BY method`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"method", "count(*)"}, result.Columns)
+	assert.Equal(t, [][]interface{}{{"GET", 3.0}, {"POST", 1.0}}, result.Rows)
+
+	var buf bytes.Buffer
+	assert.NoError(t, result.WriteCSV(&buf))
+	assert.Equal(t, "method,count(*)\nGET,3\nPOST,1\n", buf.String())
+}
+
+func TestQueryIterator(t *testing.T) {
+	h := newQueryTestHar()
+	var buf bytes.Buffer
+	assert.NoError(t, h.WriteNDJSON(&buf))
+	reader, err := NewNDJSONReader(&buf)
+	assert.NoError(t, err)
+
+	q, err := ParseQuery(`SELECT status, count(*) WHERE timings.wait > 15 GROUP BY status ORDER BY status`)
+	assert.NoError(t, err)
+	result, err := q.ExecuteIterator(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]interface{}{{200.0, 1.0}, {404.0, 1.0}, {500.0, 1.0}}, result.Rows)
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		query    string
+		contains string
+	}{
+		{`status >`, "查询已结束"},
+		{`nosuchfield = 1`, "未知字段"},
+		{`url ~ "("`, "无效的正则表达式"},
+		{`count(*) > 1`, "聚合函数"},
+		{`status = 200 LIMIT x`, "LIMIT"},
+		{`url = "abc`, "结束引号"},
+		{`nosuch(url)`, "未知函数"},
+		{`header("a", "b") = 1`, "参数"},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.query)
+		if assert.Error(t, err, tt.query) {
+			harErr, ok := err.(*HarError)
+			assert.True(t, ok)
+			assert.Equal(t, ErrCodeInvalidFormat, harErr.Code)
+			assert.True(t, strings.Contains(err.Error(), tt.contains), err.Error())
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// RecoveryResult 恢复解析的结果
+type RecoveryResult struct {
+	// Har 恢复出的HAR对象，包含损坏位置之前的所有完整页面和条目
+	Har *Har
+	// Offset 损坏开始的字节偏移，输入完整时为-1
+	Offset int64
+	// Path 损坏位置所在的JSON路径，如 "log.entries[12]"
+	Path string
+	// Warnings 损坏位置以及被跳过的页面、条目
+	Warnings []*HarError
+}
+
+// Damaged 输入是否被截断或损坏
+func (r *RecoveryResult) Damaged() bool {
+	return r.Offset >= 0
+}
+
+// RecoverHar 从被截断或损坏的JSON中恢复HAR
+//
+// 逐个标记解析输入，保留损坏位置之前的所有完整页面和条目，并报告损坏开始的字节偏移。
+// 类型不符的值会像宽松模式一样被转换，无法转换的页面或条目会被跳过，两者都记录为警告。
+// 只有输入不是以JSON对象开始时返回错误。
+//
+// 示例:
+//
+//	result, err := RecoverHar(truncated)
+//	if err != nil {
+//	    return err
+//	}
+//	if result.Damaged() {
+//	    fmt.Printf("在字节%d处(%s)损坏，恢复了%d个条目\n",
+//	        result.Offset, result.Path, len(result.Har.Log.Entries))
+//	}
+func RecoverHar(data []byte) (*RecoveryResult, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, newLocalizedError(ErrCodeInvalidFormat, "input.empty", nil)
+	}
+
+	r := &harRecoverer{
+		dec:   json.NewDecoder(bytes.NewReader(data)),
+		size:  int64(len(data)),
+		lines: newLineIndex(data),
+		result: &RecoveryResult{
+			Har:    &Har{Log: Log{Entries: []Entries{}, Pages: []Pages{}}},
+			Offset: -1,
+		},
+	}
+
+	tok, err := r.dec.Token()
+	if err != nil || tok != json.Delim('{') {
+		return nil, newLocalizedError(ErrCodeInvalidFormat, "recover.not_object", nil)
+	}
+	r.parseRoot()
+	return r.result, nil
+}
+
+// RecoverHarFile 从被截断或损坏的HAR文件中恢复HAR
+func RecoverHarFile(harFilePath string) (*RecoveryResult, error) {
+	data, err := os.ReadFile(harFilePath)
+	if err != nil {
+		return nil, newLocalizedError(ErrCodeFileSystem, "file.read", err, harFilePath)
+	}
+	return RecoverHar(data)
+}
+
+// harRecoverer 基于标记流的恢复解析器，遇到第一个损坏位置即停止
+type harRecoverer struct {
+	dec    *json.Decoder
+	size   int64
+	lines  *lineIndex
+	result *RecoveryResult
+	sawLog bool
+}
+
+func (r *harRecoverer) parseRoot() {
+	for r.dec.More() {
+		key, ok := r.key("")
+		if !ok {
+			return
+		}
+		if key == "log" {
+			r.sawLog = true
+			if !r.parseLog() {
+				return
+			}
+			continue
+		}
+		var raw json.RawMessage
+		if !r.decode(&raw, key) {
+			return
+		}
+	}
+	if !r.closeDelim("") {
+		return
+	}
+	if !r.sawLog {
+		r.warn(NewMissingFieldError("log"))
+	}
+}
+
+func (r *harRecoverer) parseLog() bool {
+	if !r.openDelim('{', "log") {
+		return false
+	}
+	log := &r.result.Har.Log
+	for r.dec.More() {
+		key, ok := r.key("log")
+		if !ok {
+			return false
+		}
+		path := "log." + key
+		switch key {
+		case "entries":
+			ok = r.parseArray(path, func(raw json.RawMessage, i int) {
+				var entry Entries
+				if err := r.decodeLenient(raw, &entry, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					r.warn(newLocalizedError(ErrCodeJSONParse, "parse.entry", err, i+1).
+						WithField(fmt.Sprintf("%s[%d]", path, i)))
+					return
+				}
+				log.Entries = append(log.Entries, entry)
+			})
+		case "pages":
+			ok = r.parseArray(path, func(raw json.RawMessage, i int) {
+				var page Pages
+				if err := r.decodeLenient(raw, &page, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					r.warn(newLocalizedError(ErrCodeJSONParse, "parse.page", err, i+1).
+						WithField(fmt.Sprintf("%s[%d]", path, i)))
+					return
+				}
+				log.Pages = append(log.Pages, page)
+			})
+		default:
+			var raw json.RawMessage
+			if ok = r.decode(&raw, path); ok {
+				r.located(path, raw, func() {
+					r.setLogField(log, key, raw)
+				})
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return r.closeDelim("log")
+}
+
+// setLogField 设置log中除pages和entries之外的字段，未知字段被忽略
+func (r *harRecoverer) setLogField(log *Log, key string, raw json.RawMessage) {
+	var target interface{}
+	switch key {
+	case "version":
+		target = &log.Version
+	case "creator":
+		target = &log.Creator
+	default:
+		return
+	}
+	if err := r.decodeLenient(raw, target, "log."+key); err != nil {
+		r.warn(newLocalizedError(ErrCodeJSONParse, "parse.field", err, key).WithField("log." + key))
+	}
+}
+
+// parseArray 逐个解码数组元素，返回数组是否完整
+func (r *harRecoverer) parseArray(path string, add func(raw json.RawMessage, i int)) bool {
+	if !r.openDelim('[', path) {
+		return false
+	}
+	for i := 0; r.dec.More(); i++ {
+		var raw json.RawMessage
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if !r.decode(&raw, itemPath) {
+			return false
+		}
+		r.located(itemPath, raw, func() {
+			add(raw, i)
+		})
+	}
+	return r.closeDelim(path)
+}
+
+func (r *harRecoverer) key(path string) (string, bool) {
+	tok, err := r.dec.Token()
+	if err != nil {
+		r.damage(path, err)
+		return "", false
+	}
+	key, ok := tok.(string)
+	if !ok {
+		r.damage(path, fmt.Errorf("预期对象键，实际为 %v", tok))
+		return "", false
+	}
+	return key, true
+}
+
+func (r *harRecoverer) decode(v interface{}, path string) bool {
+	if err := r.dec.Decode(v); err != nil {
+		r.damage(path, err)
+		return false
+	}
+	return true
+}
+
+func (r *harRecoverer) openDelim(delim json.Delim, path string) bool {
+	tok, err := r.dec.Token()
+	if err != nil {
+		r.damage(path, err)
+		return false
+	}
+	if tok != delim {
+		// 结构不符合HAR格式，无法继续按标记解析，视为损坏
+		r.damage(path, fmt.Errorf("预期 '%v'，实际为 %v", delim, tok))
+		return false
+	}
+	return true
+}
+
+func (r *harRecoverer) closeDelim(path string) bool {
+	if _, err := r.dec.Token(); err != nil {
+		r.damage(path, err)
+		return false
+	}
+	return true
+}
+
+// damage 记录损坏位置，语法错误时为出错字符的偏移，截断时为输入长度
+func (r *harRecoverer) damage(path string, err error) {
+	offset := r.dec.InputOffset()
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// Offset为读取到出错字符之后的偏移
+		offset = syntaxErr.Offset - 1
+	} else if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		offset = r.size
+		err = io.ErrUnexpectedEOF
+	}
+
+	r.result.Offset = offset
+	r.result.Path = path
+	r.warn(newLocalizedError(ErrCodeJSONParse, "recover.damaged", err, offset).
+		WithField(path).
+		withPosition(r.lines, int(offset)))
+}
+
+// located 运行fn，并将其间产生的警告定位到原始输入中，raw为刚解码的值
+func (r *harRecoverer) located(path string, raw json.RawMessage, fn func()) {
+	start := int(r.dec.InputOffset()) - len(raw)
+	before := len(r.result.Warnings)
+	fn()
+	if len(r.result.Warnings) == before {
+		return
+	}
+
+	locator := &positionLocator{lines: r.lines}
+	locator.addSpan(path, start)
+	for _, warning := range r.result.Warnings[before:] {
+		if offset, ok := jsonErrorOffset(raw, warning.Err); ok {
+			warning.withPosition(r.lines, start+offset)
+		} else {
+			locator.annotate(warning)
+		}
+	}
+}
+
+// decodeLenient 转换类型不符的值后解码，每次转换记录为警告
+func (r *harRecoverer) decodeLenient(raw json.RawMessage, v interface{}, path string) error {
+	coercions, err := decodeLenient(raw, v, path)
+	r.result.Warnings = append(r.result.Warnings, coercions...)
+	return err
+}
+
+func (r *harRecoverer) warn(err *HarError) {
+	r.result.Warnings = append(r.result.Warnings, err)
+}
+
+// parseRecover 恢复模式解析，行为与宽松模式一致，损坏时返回已恢复的HAR和包含损坏信息的错误
+func parseRecover(harFileBytes []byte, options ParseOptions) (*Har, error) {
+	result, err := RecoverHar(harFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Warnings) == 0 {
+		return result.Har, nil
+	}
+
+	rootError := newLocalizedError(ErrCodeJSONParse, "recover.partial", nil)
+	if result.Damaged() {
+		rootError.WithMetadata("offset", result.Offset)
+	}
+	for _, warning := range result.Warnings {
+		rootError.AddPartialError(warning)
+	}
+
+	log := result.Har.Log
+	if options.CollectWarnings && (log.Version != "" || len(log.Entries) > 0 || len(log.Pages) > 0) {
+		return result.Har, rootError
+	}
+	return nil, rootError
+}
@@ -0,0 +1,84 @@
+package har
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newRecoverTestData(t *testing.T) []byte {
+	h := NewHar()
+	h.AddPage("page_1", "首页")
+	for _, url := range []string{"https://x.com/a", "https://x.com/b", "https://x.com/c"} {
+		e := h.AddEntry("GET", url, "HTTP/1.1", "page_1")
+		e.SetResponseStatus(200, "OK")
+		e.SetTimings(-1, -1, -1, 1, 1, 1, -1)
+		e.Response.Content.MimeType = "text/plain"
+	}
+	data, err := json.Marshal(h)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestRecoverHarTruncated(t *testing.T) {
+	data := newRecoverTestData(t)
+
+	// 在第三个条目中间截断
+	cut := bytes.Index(data, []byte(`"https://x.com/c"`))
+	truncated := data[:cut+5]
+
+	result, err := RecoverHar(truncated)
+	assert.NoError(t, err)
+	assert.True(t, result.Damaged())
+	assert.Equal(t, int64(len(truncated)), result.Offset)
+	assert.Equal(t, "log.entries[2]", result.Path)
+	assert.Equal(t, "1.2", result.Har.Log.Version)
+	assert.Len(t, result.Har.Log.Pages, 1)
+	if assert.Len(t, result.Har.Log.Entries, 2) {
+		assert.Equal(t, "https://x.com/b", result.Har.Log.Entries[1].Request.URL)
+	}
+
+	// 完整输入没有损坏
+	result, err = RecoverHar(data)
+	assert.NoError(t, err)
+	assert.False(t, result.Damaged())
+	assert.Empty(t, result.Warnings)
+	assert.Len(t, result.Har.Log.Entries, 3)
+
+	_, err = RecoverHar([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestRecoverHarCorrupted(t *testing.T) {
+	data := newRecoverTestData(t)
+
+	// 第二个条目中出现无效字节
+	pos := bytes.Index(data, []byte(`"https://x.com/b"`))
+	corrupted := append([]byte{}, data...)
+	corrupted[pos] = '#'
+
+	result, err := RecoverHar(corrupted)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(pos), result.Offset)
+	assert.Equal(t, "log.entries[1]", result.Path)
+	assert.Len(t, result.Har.Log.Entries, 1)
+
+	// 通过解析选项使用恢复模式
+	provider, err := Parse(corrupted[:pos], WithRecover())
+	assert.Error(t, err)
+	if assert.NotNil(t, provider) {
+		h := provider.(*Har)
+		assert.Len(t, h.Log.Entries, 1)
+	}
+	harErr, ok := err.(*HarError)
+	if assert.True(t, ok) {
+		assert.Equal(t, ErrCodeJSONParse, harErr.Code)
+		assert.Equal(t, int64(pos), harErr.Metadata["offset"])
+	}
+
+	result2, err := ParseHarWithWarnings(corrupted)
+	assert.Error(t, err, "默认的宽松模式不恢复损坏的输入")
+	assert.Nil(t, result2)
+}
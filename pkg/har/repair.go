@@ -0,0 +1,241 @@
+package har
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+)
+
+// RepairAction 一次修复操作
+type RepairAction struct {
+	Kind    string // 修复类型，如 "time"、"http-version"、"sentinel"
+	Path    string // 被修复字段的JSON路径
+	Message string // 修复说明
+}
+
+// String 返回可读的描述
+func (a RepairAction) String() string {
+	return fmt.Sprintf("%s %s: %s", a.Kind, a.Path, a.Message)
+}
+
+// RepairReport 修复结果
+type RepairReport struct {
+	Actions []RepairAction
+}
+
+// HasChanges 是否进行了任何修复
+func (r RepairReport) HasChanges() bool {
+	return len(r.Actions) > 0
+}
+
+// ByKind 返回指定类型的修复操作
+func (r RepairReport) ByKind(kind string) []RepairAction {
+	var actions []RepairAction
+	for _, a := range r.Actions {
+		if a.Kind == kind {
+			actions = append(actions, a)
+		}
+	}
+	return actions
+}
+
+func (r *RepairReport) add(kind, path, format string, args ...interface{}) {
+	r.Actions = append(r.Actions, RepairAction{Kind: kind, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Repair 修复HAR中可恢复的常见问题，返回修复后的副本和修复报告，不修改输入
+//
+// 修复内容:
+//   - sentinel: 不可用的计时、大小统一为-1，send/wait/receive为负时置0
+//   - time: timings包含计时数据时按各阶段之和重新计算time，timings全部缺失(0或-1)时保留原值
+//   - http-version: 补全缺失的httpVersion
+//   - query-string: 从URL生成缺失的queryString
+//   - cookies: 从Cookie/Set-Cookie头部生成缺失的cookies
+//   - content-size: 根据解码后的响应体修正content.size
+//   - missing-page: 为悬空的pageref创建页面
+//   - sort-entries: 按startedDateTime排序条目
+//
+// 排序在其他修复之前进行，报告中条目的路径均指向排序后的位置。
+func Repair(h *Har) (*Har, RepairReport) {
+	var report RepairReport
+	if h == nil {
+		return nil, report
+	}
+
+	repaired := *h
+	repaired.Log.Entries = append([]Entries(nil), h.Log.Entries...)
+	repaired.Log.Pages = append([]Pages(nil), h.Log.Pages...)
+	if h.Log.Entries != nil && repaired.Log.Entries == nil {
+		repaired.Log.Entries = []Entries{}
+	}
+	if h.Log.Pages != nil && repaired.Log.Pages == nil {
+		repaired.Log.Pages = []Pages{}
+	}
+	log := &repaired.Log
+
+	if !sort.SliceIsSorted(log.Entries, func(i, j int) bool {
+		return log.Entries[i].StartedDateTime.Before(log.Entries[j].StartedDateTime)
+	}) {
+		sort.SliceStable(log.Entries, func(i, j int) bool {
+			return log.Entries[i].StartedDateTime.Before(log.Entries[j].StartedDateTime)
+		})
+		report.add("sort-entries", "log.entries", "按startedDateTime重新排序")
+	}
+	for i := range log.Pages {
+		repairPageTimings(&log.Pages[i].PageTimings, fmt.Sprintf("log.pages[%d].pageTimings", i), &report)
+	}
+	for i := range log.Entries {
+		repairEntry(&log.Entries[i], fmt.Sprintf("log.entries[%d]", i), &report)
+	}
+	repairPages(log, &report)
+
+	return &repaired, report
+}
+
+func repairEntry(entry *Entries, path string, report *RepairReport) {
+	req, resp := &entry.Request, &entry.Response
+
+	repairTimings(&entry.Timings, path+".timings", report)
+	repairSize(&req.HeadersSize, path+".request.headersSize", report)
+	repairSize(&req.BodySize, path+".request.bodySize", report)
+	repairSize(&resp.HeadersSize, path+".response.headersSize", report)
+	repairSize(&resp.BodySize, path+".response.bodySize", report)
+
+	// 生成方没有记录计时阶段时，time是唯一的耗时数据，不能用0覆盖
+	if total := timingsTotal(entry.Timings); total > 0 && math.Abs(entry.Time-total) > 1 {
+		report.add("time", path+".time", "由%v改为各计时阶段之和%v", entry.Time, total)
+		entry.Time = total
+	}
+
+	if req.HTTPVersion == "" {
+		req.HTTPVersion = inferHTTPVersion(resp.HTTPVersion, req.Headers)
+		report.add("http-version", path+".request.httpVersion", "补全为%s", req.HTTPVersion)
+	}
+	if resp.HTTPVersion == "" {
+		resp.HTTPVersion = inferHTTPVersion(req.HTTPVersion, resp.Headers)
+		report.add("http-version", path+".response.httpVersion", "补全为%s", resp.HTTPVersion)
+	}
+
+	if len(req.QueryString) == 0 {
+		if params := queryStringFromURL(req.URL); len(params) > 0 {
+			req.QueryString = params
+			report.add("query-string", path+".request.queryString", "从URL生成%d个参数", len(params))
+		}
+	}
+
+	if len(req.Cookies) == 0 {
+		if cookies := cookiesFromHeaders(req.Headers, "cookie"); len(cookies) > 0 {
+			req.Cookies = cookies
+			report.add("cookies", path+".request.cookies", "从Cookie头部生成%d个Cookie", len(cookies))
+		}
+	}
+	if len(resp.Cookies) == 0 {
+		if cookies := cookiesFromHeaders(resp.Headers, "set-cookie"); len(cookies) > 0 {
+			resp.Cookies = cookies
+			report.add("cookies", path+".response.cookies", "从Set-Cookie头部生成%d个Cookie", len(cookies))
+		}
+	}
+
+	if resp.Content.Text != "" {
+		if body, err := decodeContentText(resp.Content); err == nil && len(body) != resp.Content.Size {
+			report.add("content-size", path+".response.content.size", "由%d改为%d", resp.Content.Size, len(body))
+			resp.Content.Size = len(body)
+		}
+	}
+}
+
+// inferHTTPVersion 推断缺失的HTTP版本，优先使用另一方的版本，HTTP/2伪头部表示HTTP/2
+func inferHTTPVersion(other string, headers []Headers) string {
+	if other != "" {
+		return other
+	}
+	for _, header := range headers {
+		if len(header.Name) > 0 && header.Name[0] == ':' {
+			return "HTTP/2.0"
+		}
+	}
+	return "HTTP/1.1"
+}
+
+// repairTimings 规范化计时的-1哨兵值
+func repairTimings(t *Timings, path string, report *RepairReport) {
+	optional := []struct {
+		name  string
+		value *float64
+	}{
+		{"blocked", &t.Blocked},
+		{"dns", &t.DNS},
+		{"connect", &t.Connect},
+		{"ssl", &t.Ssl},
+	}
+	for _, o := range optional {
+		if *o.value < 0 && *o.value != -1 {
+			report.add("sentinel", path+"."+o.name, "由%v改为-1", *o.value)
+			*o.value = -1
+		}
+	}
+
+	required := []struct {
+		name  string
+		value *float64
+	}{
+		{"send", &t.Send},
+		{"wait", &t.Wait},
+		{"receive", &t.Receive},
+	}
+	for _, r := range required {
+		if *r.value < 0 {
+			report.add("sentinel", path+"."+r.name, "由%v改为0", *r.value)
+			*r.value = 0
+		}
+	}
+}
+
+// repairPageTimings 规范化页面计时的-1哨兵值
+func repairPageTimings(t *PageTimings, path string, report *RepairReport) {
+	if t.OnContentLoad < 0 && t.OnContentLoad != -1 {
+		report.add("sentinel", path+".onContentLoad", "由%v改为-1", t.OnContentLoad)
+		t.OnContentLoad = -1
+	}
+	if t.OnLoad < 0 && t.OnLoad != -1 {
+		report.add("sentinel", path+".onLoad", "由%v改为-1", t.OnLoad)
+		t.OnLoad = -1
+	}
+}
+
+// repairSize 规范化大小的-1哨兵值
+func repairSize(size *int, path string, report *RepairReport) {
+	if *size < -1 {
+		report.add("sentinel", path, "由%d改为-1", *size)
+		*size = -1
+	}
+}
+
+// repairPages 为悬空的pageref创建页面，条目已排序，页面的开始时间和标题取自引用它的第一个条目
+func repairPages(log *Log, report *RepairReport) {
+	pages := make(map[string]bool, len(log.Pages))
+	for _, page := range log.Pages {
+		pages[page.ID] = true
+	}
+
+	for _, entry := range log.Entries {
+		ref := entry.Pageref
+		if ref == "" || pages[ref] {
+			continue
+		}
+		pages[ref] = true
+
+		title := ref
+		if u, err := url.Parse(entry.Request.URL); err == nil && u.Host != "" {
+			title = entry.Request.URL
+		}
+		log.Pages = append(log.Pages, Pages{
+			StartedDateTime: entry.StartedDateTime,
+			ID:              ref,
+			Title:           title,
+			PageTimings:     PageTimings{OnContentLoad: -1, OnLoad: -1},
+		})
+		report.add("missing-page", fmt.Sprintf("log.pages[%d]", len(log.Pages)-1), "为pageref '%s' 创建页面", ref)
+	}
+}
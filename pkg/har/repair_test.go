@@ -0,0 +1,97 @@
+package har
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepair(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := NewHar()
+	late := h.AddEntry("GET", "https://x.com/b?q=1&r=a%20b", "", "page_1")
+	late.StartedDateTime = start.Add(time.Second)
+	late.SetTimings(-3, -1, 5, 1, 10, 2, 2)
+	late.Time = 0
+	late.AddRequestHeader("Cookie", "sid=abc; theme=dark")
+	late.AddResponseHeader("Set-Cookie", "sid=def; Path=/")
+	late.Response.Content.Text = "aGVsbG8="
+	late.Response.Content.Encoding = "base64"
+	late.Response.HeadersSize = -5
+
+	early := h.AddEntry("GET", "https://x.com/a", "HTTP/2.0", "page_1")
+	early.StartedDateTime = start
+	early.SetTimings(-1, -1, -1, 1, -1, 1, -1)
+
+	snapshot := h.Log.Entries[0]
+	repaired, report := Repair(h)
+	assert.True(t, report.HasChanges())
+	assert.Equal(t, snapshot, h.Log.Entries[0], "输入不应被修改")
+	assert.Empty(t, h.Log.Pages)
+
+	entries := repaired.Log.Entries
+	assert.Equal(t, "https://x.com/a", entries[0].Request.URL)
+	assert.Len(t, report.ByKind("sort-entries"), 1)
+
+	// early: wait置0，time重新计算
+	assert.Equal(t, 0.0, entries[0].Timings.Wait)
+	assert.Equal(t, 2.0, entries[0].Time)
+
+	b := entries[1]
+	assert.Equal(t, -1.0, b.Timings.Blocked)
+	assert.Equal(t, 18.0, b.Time)
+	assert.Equal(t, "HTTP/1.1", b.Request.HTTPVersion)
+	assert.Equal(t, "HTTP/1.1", b.Response.HTTPVersion)
+	assert.Equal(t, []Headers{{Name: "q", Value: "1"}, {Name: "r", Value: "a b"}}, b.Request.QueryString)
+	assert.Len(t, b.Request.Cookies, 2)
+	if assert.Len(t, b.Response.Cookies, 1) {
+		assert.Equal(t, "def", b.Response.Cookies[0].Value)
+	}
+	assert.Equal(t, 5, b.Response.Content.Size)
+	assert.Equal(t, -1, b.Response.HeadersSize)
+
+	// 报告中的路径指向排序后的位置
+	assert.Equal(t, "log.entries", report.Actions[0].Path)
+	var paths []string
+	for _, a := range report.ByKind("http-version") {
+		paths = append(paths, a.Path)
+	}
+	assert.Equal(t, []string{"log.entries[1].request.httpVersion", "log.entries[1].response.httpVersion"}, paths)
+	if actions := report.ByKind("time"); assert.Len(t, actions, 2) {
+		assert.Equal(t, "log.entries[0].time", actions[0].Path)
+		assert.Equal(t, "log.entries[1].time", actions[1].Path)
+	}
+	assert.Equal(t, "log.entries[1].response.content.size", report.ByKind("content-size")[0].Path)
+
+	if assert.Len(t, repaired.Log.Pages, 1) {
+		page := repaired.Log.Pages[0]
+		assert.Equal(t, "page_1", page.ID)
+		assert.Equal(t, start, page.StartedDateTime)
+		assert.Equal(t, "https://x.com/a", page.Title)
+		assert.Equal(t, -1.0, page.PageTimings.OnLoad)
+	}
+
+	// 修复后的HAR不再有时间和哨兵值问题
+	findings := NewValidator().Validate(repaired)
+	assert.Empty(t, findings.ByRule("entry-time-sum"))
+	assert.Empty(t, findings.ByRule("timings-sentinel"))
+	assert.Empty(t, findings.ByRule("entry-pageref-exists"))
+
+	// 再次修复不应有变化
+	_, report = Repair(repaired)
+	assert.False(t, report.HasChanges(), report.Actions)
+}
+
+func TestRepairKeepsTimeWithoutTimings(t *testing.T) {
+	h := NewHar()
+	entry := h.AddEntry("GET", "https://x.com/a", "HTTP/1.1", "")
+	entry.Response.HTTPVersion = "HTTP/1.1"
+	entry.SetTimings(-1, -1, -1, -1, 0, 0, 0)
+	entry.Time = 250
+
+	// 没有计时数据时time是唯一的耗时，保持不变
+	repaired, report := Repair(h)
+	assert.Empty(t, report.ByKind("time"))
+	assert.Equal(t, 250.0, repaired.Log.Entries[0].Time)
+}
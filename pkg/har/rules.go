@@ -0,0 +1,151 @@
+package har
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Rule 自定义校验规则
+//
+// 通过RegisterRule注册的规则会与内置规则一起在ValidateHarFile、ParseHarWithWarnings
+// 和流式解析中运行，也可以通过Validator.AddRule只用于某个校验器。
+// 规则ID与内置规则一样可以被Validator禁用或调整严重程度。
+//
+// 示例:
+//
+//	const ErrCodeMissingRequestID = har.ErrCodeCustom + 1
+//
+//	har.RegisterRule(har.NewRule(
+//	    har.RuleInfo{ID: "house-request-id", Severity: har.SeverityError, Description: "API请求必须带X-Request-ID"},
+//	    func(entry *har.Entries, path string, r *har.RuleReporter) {
+//	        if strings.Contains(entry.Request.URL, "/api/") && !har.HasHeader("X-Request-ID")(entry) {
+//	            r.Report(ErrCodeMissingRequestID, path+".request.headers", "缺少X-Request-ID")
+//	        }
+//	    },
+//	))
+type Rule interface {
+	// Info 返回规则ID、默认严重程度和说明
+	Info() RuleInfo
+	// CheckEntry 校验单个条目，path为条目的JSON路径，如 "log.entries[3]"
+	CheckEntry(entry *Entries, path string, r *RuleReporter)
+}
+
+// LogRule 需要检查整个日志的规则(如跨条目统计)可以额外实现此接口，
+// CheckLog在所有条目检查之后调用。流式解析只调用CheckEntry。
+type LogRule interface {
+	Rule
+	CheckLog(log *Log, r *RuleReporter)
+}
+
+// RuleReporter 规则用于报告校验发现
+type RuleReporter struct {
+	c      *validationContext
+	ruleID string
+}
+
+// Report 报告一条发现，code为转换为HarError时使用的错误代码，
+// 严重程度取自规则的默认值或Validator中的覆盖值
+func (r *RuleReporter) Report(code ErrorCode, path string, format string, args ...interface{}) {
+	r.c.add(r.ruleID, code, path, format, args...)
+}
+
+// ReportMessage 与Report相同，但消息取自通过RegisterMessages注册的消息目录，
+// 生成的HarError带有MessageKey和Params，可以通过Localize转换语言
+func (r *RuleReporter) ReportMessage(code ErrorCode, path string, key string, params ...interface{}) {
+	r.c.addMessage(r.ruleID, code, path, key, params...)
+}
+
+// reporter 为规则创建报告器
+func (c *validationContext) reporter(rule Rule) *RuleReporter {
+	return &RuleReporter{c: c, ruleID: rule.Info().ID}
+}
+
+// funcRule 由函数实现的规则
+type funcRule struct {
+	info  RuleInfo
+	check func(entry *Entries, path string, r *RuleReporter)
+}
+
+// NewRule 使用函数创建只检查条目的规则
+func NewRule(info RuleInfo, check func(entry *Entries, path string, r *RuleReporter)) Rule {
+	return &funcRule{info: info, check: check}
+}
+
+// Info 返回规则信息
+func (r *funcRule) Info() RuleInfo {
+	return r.info
+}
+
+// CheckEntry 调用规则函数
+func (r *funcRule) CheckEntry(entry *Entries, path string, reporter *RuleReporter) {
+	r.check(entry, path, reporter)
+}
+
+// ruleRegistry 全局自定义规则注册表
+var ruleRegistry = struct {
+	sync.RWMutex
+	rules []Rule
+}{}
+
+// RegisterRule 注册自定义规则，规则ID为空或与内置、已注册规则重复时返回错误
+func RegisterRule(rule Rule) error {
+	if rule == nil {
+		return newInvalidValueError("rule", nil, "registry.nil_rule")
+	}
+	id := rule.Info().ID
+	if id == "" {
+		return NewMissingFieldError("rule.ID")
+	}
+	if _, ok := findRule(id); ok {
+		return newInvalidValueError("rule.ID", id, "registry.builtin_id")
+	}
+
+	ruleRegistry.Lock()
+	defer ruleRegistry.Unlock()
+	for _, existing := range ruleRegistry.rules {
+		if existing.Info().ID == id {
+			return newInvalidValueError("rule.ID", id, "registry.duplicate_id")
+		}
+	}
+	ruleRegistry.rules = append(ruleRegistry.rules, rule)
+	return nil
+}
+
+// MustRegisterRule 注册自定义规则，失败时panic，适合在init中使用
+func MustRegisterRule(rule Rule) {
+	if err := RegisterRule(rule); err != nil {
+		panic(fmt.Sprintf("注册规则失败: %v", err))
+	}
+}
+
+// UnregisterRule 移除已注册的规则，返回规则是否存在
+func UnregisterRule(id string) bool {
+	ruleRegistry.Lock()
+	defer ruleRegistry.Unlock()
+	for i, rule := range ruleRegistry.rules {
+		if rule.Info().ID == id {
+			ruleRegistry.rules = append(ruleRegistry.rules[:i:i], ruleRegistry.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RegisteredRules 返回已注册的自定义规则
+func RegisteredRules() []Rule {
+	ruleRegistry.RLock()
+	defer ruleRegistry.RUnlock()
+	rules := make([]Rule, len(ruleRegistry.rules))
+	copy(rules, ruleRegistry.rules)
+	return rules
+}
+
+// newRegisteredRuleValidator 创建只运行已注册自定义规则的校验器，
+// 用于内置规则已由其他路径处理或不适用的场景
+func newRegisteredRuleValidator() *Validator {
+	v := NewValidator()
+	for _, rule := range builtinRules {
+		v.Disable(rule.ID)
+	}
+	return v
+}
@@ -0,0 +1,120 @@
+package har
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	errCodeMissingRequestID = ErrCodeCustom + 1
+	errCodeLargeResponse    = ErrCodeCustom + 2
+)
+
+// entryCountRule 条目数量超过上限时报告
+type entryCountRule struct{ max int }
+
+func (r entryCountRule) Info() RuleInfo {
+	return RuleInfo{ID: "test-entry-count", Severity: SeverityInfo, Description: "条目数量上限"}
+}
+
+func (r entryCountRule) CheckEntry(*Entries, string, *RuleReporter) {}
+
+func (r entryCountRule) CheckLog(log *Log, reporter *RuleReporter) {
+	if len(log.Entries) > r.max {
+		reporter.Report(ErrCodeCustom, "log.entries", "条目数量%d超过上限%d", len(log.Entries), r.max)
+	}
+}
+
+func newRulesTestHar() *Har {
+	h := NewHar()
+	for _, url := range []string{"https://x.com/api/a", "https://x.com/app.js"} {
+		e := h.AddEntry("GET", url, "HTTP/1.1", "")
+		e.SetResponseStatus(200, "OK")
+		e.Response.Content.MimeType = "text/plain"
+		e.Response.Content.Size = 6 << 20
+		e.SetTimings(-1, -1, -1, 1, 1, 1, -1)
+		e.Time = 3
+	}
+	return h
+}
+
+func TestCustomRules(t *testing.T) {
+	requestID := NewRule(
+		RuleInfo{ID: "test-request-id", Severity: SeverityError, Description: "API请求必须带X-Request-ID"},
+		func(entry *Entries, path string, r *RuleReporter) {
+			if URLContains("/api/")(entry) && !HasHeader("X-Request-ID")(entry) {
+				r.Report(errCodeMissingRequestID, path+".request.headers", "缺少X-Request-ID")
+			}
+		},
+	)
+	largeResponse := NewRule(
+		RuleInfo{ID: "test-large-response", Severity: SeverityWarning, Description: "响应不超过5MB"},
+		func(entry *Entries, path string, r *RuleReporter) {
+			if entry.Response.Content.Size > 5<<20 {
+				r.Report(errCodeLargeResponse, path+".response.content.size", "响应超过5MB")
+			}
+		},
+	)
+	assert.NoError(t, RegisterRule(requestID))
+	assert.NoError(t, RegisterRule(largeResponse))
+	defer UnregisterRule("test-request-id")
+	defer UnregisterRule("test-large-response")
+
+	assert.Error(t, RegisterRule(requestID))
+	assert.Error(t, RegisterRule(NewRule(RuleInfo{ID: "page-id-unique"}, nil)))
+
+	h := newRulesTestHar()
+
+	err := ValidateHarFile(h)
+	if assert.Error(t, err) {
+		partials := err.(*HarError).GetPartialErrors()
+		if assert.Len(t, partials, 1) {
+			assert.Equal(t, errCodeMissingRequestID, partials[0].Code)
+			assert.Equal(t, "log.entries[0].request.headers", partials[0].Field)
+			assert.Equal(t, "test-request-id", partials[0].Metadata["ruleID"])
+		}
+	}
+
+	// 自定义规则可以像内置规则一样被禁用，也可以只添加到单个校验器
+	report := NewValidator().Disable("test-request-id").AddRule(entryCountRule{max: 1}).Validate(h)
+	assert.False(t, report.HasErrors())
+	assert.Len(t, report.ByRule("test-large-response"), 2)
+	assert.Len(t, report.ByRule("test-entry-count"), 1)
+
+	data, _ := json.Marshal(h)
+	result, err := ParseHarWithWarnings(data)
+	assert.NoError(t, err)
+	codes := map[ErrorCode]int{}
+	for _, w := range result.Warnings {
+		codes[w.Code]++
+	}
+	assert.Equal(t, 1, codes[errCodeMissingRequestID])
+	assert.Equal(t, 2, codes[errCodeLargeResponse])
+
+	// 流式解析中发现按条目提供，默认不终止迭代
+	it, err := NewStreamingParser(data)
+	assert.NoError(t, err)
+	var perEntry []int
+	for it.Next() {
+		perEntry = append(perEntry, len(it.(*StreamingEntryIterator).Findings()))
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []int{2, 1}, perEntry)
+
+	// 严格模式下错误级别的发现终止迭代
+	it, err = NewStreamingParser(data, WithStrictRules())
+	assert.NoError(t, err)
+	assert.False(t, it.Next())
+	if assert.Error(t, it.Err()) {
+		assert.Equal(t, errCodeMissingRequestID, it.Err().(*HarError).PartialErrors[0].Code)
+	}
+	assert.Len(t, it.(*StreamingEntryIterator).Findings(), 2)
+
+	UnregisterRule("test-request-id")
+	it, _ = NewStreamingParser(data, WithStrictRules())
+	for it.Next() {
+	}
+	assert.NoError(t, it.Err())
+}
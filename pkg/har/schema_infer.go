@@ -0,0 +1,304 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// JSONSchema 表示JSON Schema的子集，用于Schema推断和校验
+type JSONSchema struct {
+	Schema     string                 `json:"$schema,omitempty"`    // Schema方言，仅根节点设置
+	Type       interface{}            `json:"type,omitempty"`       // 类型，可为字符串或字符串数组
+	Format     string                 `json:"format,omitempty"`     // 字符串格式(date-time, uri, uuid)
+	Enum       []interface{}          `json:"enum,omitempty"`       // 枚举值
+	Properties map[string]*JSONSchema `json:"properties,omitempty"` // 对象属性
+	Required   []string               `json:"required,omitempty"`   // 必需属性
+	Items      *JSONSchema            `json:"items,omitempty"`      // 数组元素
+
+	// 以下字段用于校验，推断结果不会设置
+	Ref                  string                 `json:"$ref,omitempty"`                 // 引用，仅支持 "#/definitions/名称"
+	Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`          // 可引用的定义，仅根节点设置
+	PatternProperties    map[string]*JSONSchema `json:"patternProperties,omitempty"`    // 名称匹配正则的属性
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"` // 是否允许未声明的属性，仅支持布尔值
+	Minimum              *float64               `json:"minimum,omitempty"`              // 数值下限(含)
+}
+
+// SchemaInferenceOptions Schema推断选项
+type SchemaInferenceOptions struct {
+	// 过滤条件，仅使用匹配条目的响应体
+	Filter FilterOptions
+	// 字符串字段被识别为枚举的最大不同取值数量，0表示禁用枚举检测
+	MaxEnumValues int
+	// 启用枚举检测所需的最少样本数量
+	MinEnumSamples int
+}
+
+// DefaultSchemaInferenceOptions 默认的Schema推断选项
+func DefaultSchemaInferenceOptions() SchemaInferenceOptions {
+	return SchemaInferenceOptions{
+		MaxEnumValues:  10,
+		MinEnumSamples: 3,
+	}
+}
+
+// schemaNode 在合并样本过程中累积的类型信息
+type schemaNode struct {
+	samples    int                    // 出现次数
+	types      map[string]bool        // 观察到的类型
+	properties map[string]*schemaNode // 对象属性
+	objects    int                    // 作为对象出现的次数
+	items      *schemaNode            // 数组元素
+	strings    map[string]bool        // 观察到的字符串取值
+	stringSeen int                    // 作为字符串出现的次数
+	formats    map[string]int         // 各格式匹配次数
+}
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// InferJSONSchema 从匹配过滤条件的JSON响应体推断合并后的JSON Schema
+//
+// 仅在所有样本中都出现的字段才会被标记为必需；出现过null的字段会在类型中包含"null"。
+// 字符串字段若所有取值都符合date-time、uri或uuid格式，则会标记对应的format。
+//
+// 示例:
+//
+//	opts := DefaultSchemaInferenceOptions()
+//	opts.Filter = FilterOptions{URL: "/api/users"}
+//	schema, err := h.InferJSONSchema(opts)
+func (h *Har) InferJSONSchema(options SchemaInferenceOptions) (*JSONSchema, error) {
+	root := newSchemaNode()
+	for _, entry := range h.Filter(options.Filter).Entries {
+		body, ok := jsonResponseBody(entry.Response.Content)
+		if !ok {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			continue
+		}
+		root.add(value)
+	}
+
+	if root.samples == 0 {
+		return nil, NewHarError(ErrCodeInvalidValue, "没有找到可用于推断的JSON响应体", nil)
+	}
+
+	schema := root.toSchema(options)
+	schema.Schema = "https://json-schema.org/draft/2020-12/schema"
+	return schema, nil
+}
+
+// InferJSONSchemaForURL 按URL模式推断JSON Schema的便捷方法
+func (h *Har) InferJSONSchemaForURL(urlPattern string, useRegex bool) (*JSONSchema, error) {
+	options := DefaultSchemaInferenceOptions()
+	options.Filter = FilterOptions{URL: urlPattern, UseRegex: useRegex}
+	return h.InferJSONSchema(options)
+}
+
+// jsonResponseBody 返回JSON响应体的原始字节
+func jsonResponseBody(content Content) ([]byte, bool) {
+	if content.Text == "" {
+		return nil, false
+	}
+	body, err := decodeContentText(content)
+	if err != nil {
+		return nil, false
+	}
+
+	mimeType := strings.ToLower(content.MimeType)
+	if !strings.Contains(mimeType, "json") {
+		// MIME类型缺失时仍尝试识别JSON内容
+		trimmed := bytes.TrimSpace(body)
+		if mimeType != "" || len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+			return nil, false
+		}
+	}
+	return body, true
+}
+
+// decodeContentText 根据Content.Encoding解码响应体文本
+func decodeContentText(content Content) ([]byte, error) {
+	if strings.EqualFold(content.Encoding, "base64") {
+		data, err := base64.StdEncoding.DecodeString(content.Text)
+		if err != nil {
+			return nil, NewInvalidValueError("content.text", content.Encoding, "base64解码失败")
+		}
+		return data, nil
+	}
+	return []byte(content.Text), nil
+}
+
+func newSchemaNode() *schemaNode {
+	return &schemaNode{
+		types:   make(map[string]bool),
+		formats: make(map[string]int),
+	}
+}
+
+// add 将一个样本值合并到节点
+func (n *schemaNode) add(value interface{}) {
+	n.samples++
+
+	switch v := value.(type) {
+	case nil:
+		n.types["null"] = true
+	case bool:
+		n.types["boolean"] = true
+	case json.Number:
+		if _, err := v.Int64(); err == nil && !strings.ContainsAny(v.String(), ".eE") {
+			n.types["integer"] = true
+		} else {
+			n.types["number"] = true
+		}
+	case string:
+		n.types["string"] = true
+		n.stringSeen++
+		if n.strings == nil {
+			n.strings = make(map[string]bool)
+		}
+		n.strings[v] = true
+		for _, format := range detectStringFormats(v) {
+			n.formats[format]++
+		}
+	case []interface{}:
+		n.types["array"] = true
+		if n.items == nil {
+			n.items = newSchemaNode()
+		}
+		for _, item := range v {
+			n.items.add(item)
+		}
+	case map[string]interface{}:
+		n.types["object"] = true
+		n.objects++
+		if n.properties == nil {
+			n.properties = make(map[string]*schemaNode)
+		}
+		for key, item := range v {
+			child, ok := n.properties[key]
+			if !ok {
+				child = newSchemaNode()
+				n.properties[key] = child
+			}
+			child.add(item)
+		}
+	}
+}
+
+// detectStringFormats 检测字符串匹配的格式
+func detectStringFormats(s string) []string {
+	var formats []string
+	if _, err := time.Parse(time.RFC3339, s); err == nil {
+		formats = append(formats, "date-time")
+	}
+	if uuidPattern.MatchString(s) {
+		formats = append(formats, "uuid")
+	}
+	if u, err := url.Parse(s); err == nil && uriSchemes[strings.ToLower(u.Scheme)] && u.Host != "" {
+		formats = append(formats, "uri")
+	}
+	return formats
+}
+
+// uriSchemes 被识别为uri格式的URL协议，避免将 "Note: x" 之类的普通字符串视为URI
+var uriSchemes = map[string]bool{
+	"http": true, "https": true, "ftp": true, "ftps": true, "ws": true, "wss": true,
+}
+
+// toSchema 将累积信息转换为JSON Schema
+func (n *schemaNode) toSchema(options SchemaInferenceOptions) *JSONSchema {
+	schema := &JSONSchema{}
+
+	types := make([]string, 0, len(n.types))
+	for t := range n.types {
+		types = append(types, t)
+	}
+	// integer和number同时出现时合并为number
+	if n.types["integer"] && n.types["number"] {
+		types = removeString(types, "integer")
+	}
+	sort.Strings(types)
+	if len(types) == 1 {
+		schema.Type = types[0]
+	} else if len(types) > 1 {
+		schema.Type = types
+	}
+
+	if n.stringSeen > 0 {
+		// 格式必须被所有字符串样本满足
+		for _, format := range []string{"date-time", "uuid", "uri"} {
+			if n.formats[format] == n.stringSeen {
+				schema.Format = format
+				break
+			}
+		}
+
+		if schema.Format == "" && options.MaxEnumValues > 0 &&
+			n.stringSeen >= options.MinEnumSamples &&
+			len(n.strings) <= options.MaxEnumValues &&
+			len(n.strings) < n.stringSeen {
+			values := make([]string, 0, len(n.strings))
+			for s := range n.strings {
+				values = append(values, s)
+			}
+			sort.Strings(values)
+			for _, s := range values {
+				schema.Enum = append(schema.Enum, s)
+			}
+			if n.types["null"] {
+				schema.Enum = append(schema.Enum, nil)
+			}
+		}
+	}
+
+	if n.properties != nil {
+		schema.Properties = make(map[string]*JSONSchema, len(n.properties))
+		for key, child := range n.properties {
+			schema.Properties[key] = child.toSchema(options)
+			if child.samples == n.objects {
+				schema.Required = append(schema.Required, key)
+			}
+		}
+		sort.Strings(schema.Required)
+	}
+
+	if n.items != nil && n.items.samples > 0 {
+		schema.Items = n.items.toSchema(options)
+	}
+
+	return schema
+}
+
+// removeString 从切片中移除指定字符串
+func removeString(values []string, target string) []string {
+	result := values[:0]
+	for _, v := range values {
+		if v != target {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// WriteJSONSchema 将Schema以缩进JSON格式写入w
+func WriteJSONSchema(w io.Writer, schema *JSONSchema) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return NewHarError(ErrCodeUnknown, fmt.Sprintf("无法写入JSON Schema: %v", err), err)
+	}
+	return nil
+}
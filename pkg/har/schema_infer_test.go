@@ -0,0 +1,45 @@
+package har
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInferJSONSchema(t *testing.T) {
+	h := NewHar()
+	bodies := []string{
+		`{"id":"3f2b8c1e-4d5a-4b6c-8d7e-9f0a1b2c3d4e","status":"active","created":"2023-01-01T10:00:00Z","score":1,"note":null}`,
+		`{"id":"7a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d","status":"inactive","created":"2023-01-02T10:00:00Z","score":2.5,"note":"x"}`,
+		`{"id":"1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d5e","status":"active","created":"2023-01-03T10:00:00Z","score":3,"tags":["a"]}`,
+	}
+	for _, body := range bodies {
+		entry := h.AddEntry("GET", "https://api.example.com/users/1", "HTTP/1.1", "")
+		entry.SetResponseStatus(200, "OK").SetResponseContent(len(body), "application/json")
+		entry.Response.Content.Text = body
+	}
+	other := h.AddEntry("GET", "https://cdn.example.com/app.js", "HTTP/1.1", "")
+	other.SetResponseContent(2, "application/javascript")
+	other.Response.Content.Text = "{}"
+
+	opts := DefaultSchemaInferenceOptions()
+	opts.Filter = FilterOptions{URL: "/users/"}
+	schema, err := h.InferJSONSchema(opts)
+	assert.NoError(t, err)
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"created", "id", "score", "status"}, schema.Required)
+	assert.Equal(t, "uuid", schema.Properties["id"].Format)
+	assert.Equal(t, "date-time", schema.Properties["created"].Format)
+	assert.Equal(t, "number", schema.Properties["score"].Type)
+	assert.Equal(t, []interface{}{"active", "inactive"}, schema.Properties["status"].Enum)
+	assert.Equal(t, []string{"null", "string"}, schema.Properties["note"].Type)
+	assert.Equal(t, "string", schema.Properties["tags"].Items.Type)
+
+	assert.Equal(t, []string{"uri"}, detectStringFormats("https://example.com/a?b=1"))
+	assert.Empty(t, detectStringFormats("Note: x"))
+	assert.Empty(t, detectStringFormats("a:b"))
+	assert.Empty(t, detectStringFormats("urn:isbn:0451450523"))
+
+	_, err = h.InferJSONSchemaForURL("/nothing", false)
+	assert.Error(t, err)
+}
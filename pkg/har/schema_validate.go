@@ -0,0 +1,409 @@
+package har
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// harSchemaJSON 内置的HAR 1.2 JSON Schema，包含浏览器常见的扩展字段
+//
+//go:embed schema/har-1.2.schema.json
+var harSchemaJSON []byte
+
+var (
+	harSchemaOnce   sync.Once
+	harSchemaParsed *JSONSchema
+)
+
+// HarSchemaJSON 返回内置的HAR 1.2 JSON Schema原文，可用于发布或交给其他工具使用
+func HarSchemaJSON() []byte {
+	data := make([]byte, len(harSchemaJSON))
+	copy(data, harSchemaJSON)
+	return data
+}
+
+// HarSchema 返回解析后的内置HAR 1.2 JSON Schema，每次调用返回新的副本
+func HarSchema() *JSONSchema {
+	schema := new(JSONSchema)
+	if err := json.Unmarshal(harSchemaJSON, schema); err != nil {
+		panic(fmt.Sprintf("内置HAR Schema无效: %v", err))
+	}
+	return schema
+}
+
+// ValidateAgainstSchema 在反序列化之前使用内置的HAR 1.2 JSON Schema校验原始字节
+//
+// 能发现json.Unmarshal会静默置零的问题，例如 "status": "200" 或 "time": null。
+// 每个问题作为部分错误返回，Field为点号路径，Metadata中包含JSON Pointer("pointer")、
+// 行号("line")、列号("column")、字节偏移("offset")、出错行片段("snippet")和触发的Schema关键字("keyword")。
+//
+// 示例:
+//
+//	if err := ValidateAgainstSchema(data); err != nil {
+//	    for _, e := range err.(*HarError).GetPartialErrors() {
+//	        fmt.Printf("%v:%v %s\n", e.Metadata["line"], e.Metadata["column"], e.Message)
+//	    }
+//	}
+func ValidateAgainstSchema(data []byte) error {
+	harSchemaOnce.Do(func() {
+		harSchemaParsed = HarSchema()
+	})
+	return harSchemaParsed.Validate(data)
+}
+
+// Validate 使用该Schema校验JSON文本，支持type、enum、format(date-time)、minimum、
+// properties、required、patternProperties、additionalProperties、items和 $ref
+//
+// $ref只支持指向#/definitions/下的定义。Schema本身的引用无效(不支持的形式、定义不存在或循环引用)时
+// 停止校验并返回ErrCodeUnsupported错误，Metadata中的"ref"为出错的引用。
+func (s *JSONSchema) Validate(data []byte) error {
+	var syntaxCheck interface{}
+	if err := json.Unmarshal(data, &syntaxCheck); err != nil {
+		return wrapJSONError(data, err)
+	}
+
+	p := &offsetParser{data: data}
+	root := p.parse()
+
+	v := &schemaValidator{
+		root:      s,
+		lines:     newLineIndex(data),
+		patterns:  make(map[string]*regexp.Regexp),
+		rootError: newLocalizedError(ErrCodeValidation, "schema.invalid", nil),
+	}
+	v.validate(s, root, "", "")
+	if v.schemaError != nil {
+		return v.schemaError
+	}
+	if v.rootError.HasPartialErrors() {
+		return v.rootError
+	}
+	return nil
+}
+
+// jsonNode 带字节偏移的JSON值
+type jsonNode struct {
+	offset int
+	value  interface{} // nil、bool、json.Number、string、[]*jsonNode 或 *jsonObject
+}
+
+// jsonObject 保持键顺序的JSON对象
+type jsonObject struct {
+	keys   []string
+	values map[string]*jsonNode
+}
+
+// offsetParser 记录每个值起始偏移的JSON解析器，输入需已通过语法检查
+type offsetParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *offsetParser) parse() *jsonNode {
+	p.skipSpace()
+	node := &jsonNode{offset: p.pos}
+	switch c := p.data[p.pos]; {
+	case c == '{':
+		p.pos++
+		obj := &jsonObject{values: make(map[string]*jsonNode)}
+		for {
+			p.skipSpace()
+			if p.data[p.pos] == '}' {
+				p.pos++
+				break
+			}
+			if p.data[p.pos] == ',' {
+				p.pos++
+				p.skipSpace()
+			}
+			key := p.parseString()
+			p.skipSpace()
+			p.pos++ // ':'
+			if _, exists := obj.values[key]; !exists {
+				obj.keys = append(obj.keys, key)
+			}
+			obj.values[key] = p.parse()
+		}
+		node.value = obj
+	case c == '[':
+		p.pos++
+		items := []*jsonNode{}
+		for {
+			p.skipSpace()
+			if p.data[p.pos] == ']' {
+				p.pos++
+				break
+			}
+			if p.data[p.pos] == ',' {
+				p.pos++
+			}
+			items = append(items, p.parse())
+		}
+		node.value = items
+	case c == '"':
+		node.value = p.parseString()
+	case c == 't':
+		p.pos += 4
+		node.value = true
+	case c == 'f':
+		p.pos += 5
+		node.value = false
+	case c == 'n':
+		p.pos += 4
+	default:
+		start := p.pos
+		for p.pos < len(p.data) && strings.IndexByte("+-0123456789.eE", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+		node.value = json.Number(p.data[start:p.pos])
+	}
+	return node
+}
+
+func (p *offsetParser) parseString() string {
+	start := p.pos
+	p.pos++
+	for p.data[p.pos] != '"' {
+		if p.data[p.pos] == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	p.pos++
+	var s string
+	_ = json.Unmarshal(p.data[start:p.pos], &s)
+	return s
+}
+
+func (p *offsetParser) skipSpace() {
+	for p.pos < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// schemaValidator 一次Schema校验的状态
+type schemaValidator struct {
+	root      *JSONSchema
+	lines     *lineIndex
+	patterns  map[string]*regexp.Regexp
+	rootError *HarError
+	// schemaError Schema本身无效时的错误，设置后停止校验
+	schemaError *HarError
+}
+
+// fail 记录一条校验失败，key为消息目录中的键
+func (v *schemaValidator) fail(node *jsonNode, pointer, path, keyword, key string, params ...interface{}) {
+	v.rootError.AddPartialError(
+		newLocalizedError(ErrCodeValidation, key, nil, params...).
+			WithField(path).
+			withPosition(v.lines, node.offset).
+			WithMetadata("pointer", pointer).
+			WithMetadata("keyword", keyword))
+}
+
+// resolve 沿$ref找到实际的定义，引用无效或形成循环时返回错误
+func (v *schemaValidator) resolve(s *JSONSchema) (*JSONSchema, *HarError) {
+	visited := make(map[string]bool)
+	for s != nil && s.Ref != "" {
+		ref := s.Ref
+		if !strings.HasPrefix(ref, "#/definitions/") {
+			return nil, newLocalizedError(ErrCodeUnsupported, "schema.ref_unsupported", nil, ref).WithMetadata("ref", ref)
+		}
+		if visited[ref] {
+			return nil, newLocalizedError(ErrCodeUnsupported, "schema.ref_cycle", nil, ref).WithMetadata("ref", ref)
+		}
+		visited[ref] = true
+		next, ok := v.root.Definitions[strings.TrimPrefix(ref, "#/definitions/")]
+		if !ok {
+			return nil, newLocalizedError(ErrCodeUnsupported, "schema.ref_missing", nil, ref).WithMetadata("ref", ref)
+		}
+		s = next
+	}
+	return s, nil
+}
+
+func (v *schemaValidator) validate(s *JSONSchema, node *jsonNode, pointer, path string) {
+	if v.schemaError != nil {
+		return
+	}
+	s, err := v.resolve(s)
+	if err != nil {
+		v.schemaError = err.WithField(path).WithMetadata("pointer", pointer)
+		return
+	}
+	if s == nil {
+		return
+	}
+
+	actual := jsonNodeType(node)
+	if types := schemaTypes(s.Type); len(types) > 0 && !schemaTypeAllowed(types, actual, node) {
+		v.fail(node, pointer, path, "type", "schema.type", strings.Join(types, "/"), actual)
+		return
+	}
+
+	if len(s.Enum) > 0 && !schemaEnumContains(s.Enum, node.value) {
+		v.fail(node, pointer, path, "enum", "schema.enum")
+	}
+
+	switch value := node.value.(type) {
+	case json.Number:
+		if s.Minimum != nil {
+			if f, err := value.Float64(); err == nil && f < *s.Minimum {
+				v.fail(node, pointer, path, "minimum", "schema.minimum", value, *s.Minimum)
+			}
+		}
+	case string:
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				v.fail(node, pointer, path, "format", "schema.format", value)
+			}
+		}
+	case *jsonObject:
+		v.validateObject(s, node, value, pointer, path)
+	case []*jsonNode:
+		if s.Items != nil {
+			for i, item := range value {
+				v.validate(s.Items, item, fmt.Sprintf("%s/%d", pointer, i), fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s *JSONSchema, node *jsonNode, obj *jsonObject, pointer, path string) {
+	for _, name := range s.Required {
+		if _, ok := obj.values[name]; !ok {
+			v.fail(node, pointer+"/"+escapeJSONPointer(name), joinFieldPath(path, name), "required", "schema.required", name)
+		}
+	}
+
+	for _, key := range obj.keys {
+		child := obj.values[key]
+		childPointer := pointer + "/" + escapeJSONPointer(key)
+		childPath := joinFieldPath(path, key)
+
+		matched := false
+		if prop, ok := s.Properties[key]; ok {
+			v.validate(prop, child, childPointer, childPath)
+			matched = true
+		}
+		for _, pattern := range sortedSchemaKeys(s.PatternProperties) {
+			if re := v.pattern(pattern); re != nil && re.MatchString(key) {
+				if !matched {
+					v.validate(s.PatternProperties[pattern], child, childPointer, childPath)
+				}
+				matched = true
+			}
+		}
+		if !matched && s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			v.fail(child, childPointer, childPath, "additionalProperties", "schema.additional_property", key)
+		}
+	}
+}
+
+// pattern 返回编译后的正则，无效的正则返回nil，不匹配任何属性
+func (v *schemaValidator) pattern(pattern string) *regexp.Regexp {
+	re, ok := v.patterns[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		v.patterns[pattern] = re
+	}
+	return re
+}
+
+// jsonNodeType 返回节点的JSON Schema类型名称，数字统一为number
+func jsonNodeType(node *jsonNode) string {
+	switch node.value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []*jsonNode:
+		return "array"
+	}
+	return "object"
+}
+
+// schemaTypes 将type关键字转换为类型列表
+func schemaTypes(t interface{}) []string {
+	switch value := t.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		types := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	case []string:
+		return value
+	}
+	return nil
+}
+
+func schemaTypeAllowed(types []string, actual string, node *jsonNode) bool {
+	for _, t := range types {
+		if t == actual {
+			return true
+		}
+		if t == "integer" && actual == "number" {
+			if f, err := node.value.(json.Number).Float64(); err == nil && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func schemaEnumContains(enum []interface{}, value interface{}) bool {
+	for _, item := range enum {
+		switch e := item.(type) {
+		case float64:
+			if n, ok := value.(json.Number); ok {
+				if f, err := n.Float64(); err == nil && f == e {
+					return true
+				}
+			}
+		default:
+			if item == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// escapeJSONPointer 按RFC 6901转义JSON Pointer中的片段
+func escapeJSONPointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// joinFieldPath 拼接点号分隔的字段路径
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// sortedSchemaKeys 返回按字母排序的键，保证错误顺序稳定
+func sortedSchemaKeys(m map[string]*JSONSchema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package har
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAgainstSchema(t *testing.T) {
+	h := NewHar()
+	h.AddPage("page_1", "首页")
+	e := h.AddEntry("GET", "https://x.com/", "HTTP/1.1", "page_1")
+	e.SetResponseStatus(200, "OK")
+	e.SetTimings(-1, -1, -1, 1, 1, 1, -1)
+	e.AddRequestHeader("Accept", "*/*")
+	data, err := json.MarshalIndent(h, "", "  ")
+	assert.NoError(t, err)
+	assert.NoError(t, ValidateAgainstSchema(data))
+
+	// json.Unmarshal会静默接受null，但Schema会报告
+	broken := strings.Replace(string(data), `"status": 200`, `"status": "200"`, 1)
+	broken = strings.Replace(broken, `"time": 0`, `"time": null`, 1)
+	broken = strings.Replace(broken, `"name": "Accept"`, `"name": "Accept", "extra": 1`, 1)
+	broken = strings.Replace(broken, `"mimeType": ""`, `"mime": ""`, 1)
+
+	err = ValidateAgainstSchema([]byte(broken))
+	harErr, ok := err.(*HarError)
+	if !assert.True(t, ok, err) {
+		return
+	}
+	byPointer := map[string]*HarError{}
+	for _, pe := range harErr.GetPartialErrors() {
+		byPointer[pe.Metadata["pointer"].(string)] = pe
+	}
+
+	status := byPointer["/log/entries/0/response/status"]
+	if assert.NotNil(t, status) {
+		assert.Equal(t, "type", status.Metadata["keyword"])
+		assert.Equal(t, "log.entries[0].response.status", status.Field)
+		line := strings.Count(broken[:strings.Index(broken, `"200"`)], "\n") + 1
+		assert.Equal(t, line, status.Metadata["line"])
+		lineStart := strings.LastIndex(broken[:strings.Index(broken, `"200"`)], "\n")
+		assert.Equal(t, strings.Index(broken, `"200"`)-lineStart, status.Metadata["column"])
+	}
+	assert.NotNil(t, byPointer["/log/entries/0/time"])
+	assert.Equal(t, "additionalProperties", byPointer["/log/entries/0/request/headers/0/extra"].Metadata["keyword"])
+	assert.Equal(t, "required", byPointer["/log/entries/0/response/content/mimeType"].Metadata["keyword"])
+	assert.Len(t, harErr.GetPartialErrors(), 5, harErr.Error()) // 另有未声明的mime属性
+
+	// 语法错误带有行列号
+	err = ValidateAgainstSchema([]byte("{\n  \"log\": [1,,]\n}"))
+	if harErr, ok := err.(*HarError); assert.True(t, ok) {
+		assert.Equal(t, ErrCodeJSONParse, harErr.Code)
+		assert.Equal(t, 2, harErr.Metadata["line"])
+		assert.Equal(t, 13, harErr.Metadata["column"])
+	}
+}
+
+func TestHarSchemaPublished(t *testing.T) {
+	schema := HarSchema()
+	assert.Contains(t, schema.Definitions, "entry")
+	assert.NotNil(t, schema.Definitions["entry"].Properties["_initiator"])
+	assert.True(t, json.Valid(HarSchemaJSON()))
+}
+
+func TestSchemaValidateInvalidRef(t *testing.T) {
+	tests := []struct {
+		schema string
+		key    string
+		ref    string
+	}{
+		// 自引用和互相引用不会无限循环
+		{`{"$ref":"#/definitions/a","definitions":{"a":{"$ref":"#/definitions/a"}}}`, "schema.ref_cycle", "#/definitions/a"},
+		{`{"$ref":"#/definitions/a","definitions":{"a":{"$ref":"#/definitions/b"},"b":{"$ref":"#/definitions/a"}}}`, "schema.ref_cycle", "#/definitions/a"},
+		{`{"$ref":"#/properties/a"}`, "schema.ref_unsupported", "#/properties/a"},
+		{`{"$ref":"#/definitions/missing"}`, "schema.ref_missing", "#/definitions/missing"},
+	}
+	for _, tt := range tests {
+		var schema JSONSchema
+		assert.NoError(t, json.Unmarshal([]byte(tt.schema), &schema))
+		err := schema.Validate([]byte(`{"x":1}`))
+		if harErr, ok := err.(*HarError); assert.True(t, ok, tt.schema) {
+			assert.Equal(t, ErrCodeUnsupported, harErr.Code)
+			assert.Equal(t, tt.key, harErr.MessageKey)
+			assert.Equal(t, tt.ref, harErr.Metadata["ref"])
+		}
+	}
+
+	// 嵌套属性中的无效引用带有字段路径
+	var schema JSONSchema
+	assert.NoError(t, json.Unmarshal([]byte(`{"properties":{"x":{"$ref":"http://example.com/x.json"}}}`), &schema))
+	err := schema.Validate([]byte(`{"x":1}`))
+	if harErr, ok := err.(*HarError); assert.True(t, ok) {
+		assert.Equal(t, "x", harErr.Field)
+		assert.Equal(t, "/x", harErr.Metadata["pointer"])
+	}
+}
@@ -0,0 +1,309 @@
+package har
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// sqliteSchema 规范化的表结构和索引
+var sqliteSchema = []string{
+	`CREATE TABLE IF NOT EXISTS pages (
+		id TEXT PRIMARY KEY,
+		title TEXT,
+		started_date_time TEXT,
+		on_content_load REAL,
+		on_load REAL
+	)`,
+	`CREATE TABLE IF NOT EXISTS entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		page_id TEXT REFERENCES pages(id),
+		started_date_time TEXT,
+		time REAL NOT NULL,
+		method TEXT NOT NULL,
+		url TEXT NOT NULL,
+		host TEXT,
+		http_version TEXT,
+		status INTEGER,
+		status_text TEXT,
+		mime_type TEXT,
+		request_headers_size INTEGER,
+		request_body_size INTEGER,
+		request_body TEXT,
+		response_headers_size INTEGER,
+		response_body_size INTEGER,
+		content_size INTEGER,
+		content_encoding TEXT,
+		response_body TEXT,
+		redirect_url TEXT,
+		server_ip_address TEXT,
+		connection TEXT,
+		resource_type TEXT,
+		blocked REAL,
+		dns REAL,
+		connect REAL,
+		ssl REAL,
+		send REAL,
+		wait REAL,
+		receive REAL
+	)`,
+	`CREATE TABLE IF NOT EXISTS headers (
+		entry_id INTEGER NOT NULL REFERENCES entries(id),
+		direction TEXT NOT NULL CHECK (direction IN ('request', 'response')),
+		position INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		value TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS cookies (
+		entry_id INTEGER NOT NULL REFERENCES entries(id),
+		direction TEXT NOT NULL CHECK (direction IN ('request', 'response')),
+		name TEXT NOT NULL,
+		value TEXT,
+		path TEXT,
+		domain TEXT,
+		expires TEXT,
+		http_only INTEGER,
+		secure INTEGER,
+		same_site TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS query_params (
+		entry_id INTEGER NOT NULL REFERENCES entries(id),
+		position INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		value TEXT
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_entries_page ON entries(page_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_entries_host ON entries(host)`,
+	`CREATE INDEX IF NOT EXISTS idx_entries_status ON entries(status)`,
+	`CREATE INDEX IF NOT EXISTS idx_entries_started ON entries(started_date_time)`,
+	`CREATE INDEX IF NOT EXISTS idx_headers_entry ON headers(entry_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_headers_name ON headers(name COLLATE NOCASE)`,
+	`CREATE INDEX IF NOT EXISTS idx_cookies_entry ON cookies(entry_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_cookies_name ON cookies(name)`,
+	`CREATE INDEX IF NOT EXISTS idx_query_params_entry ON query_params(entry_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_query_params_name ON query_params(name)`,
+}
+
+// DefaultSQLiteDriverName ExportSQLite和ExportSQLiteIterator使用的database/sql驱动名称，
+// 对应github.com/mattn/go-sqlite3
+const DefaultSQLiteDriverName = "sqlite3"
+
+// ExportSQLite 将HAR写入SQLite数据库文件
+//
+// 数据被规范化为pages、entries、headers、cookies和query_params五张表，
+// 并为常用的查询列建立索引。Timings中值为-1的阶段写为NULL。
+// 如果数据库中已存在这些表，条目会被追加，已存在的页面会被跳过。
+//
+// 本包不直接依赖任何SQLite驱动，调用方需要导入注册为DefaultSQLiteDriverName的驱动:
+//
+//	import _ "github.com/mattn/go-sqlite3"
+//
+// 使用其他驱动(例如注册为"sqlite"的modernc.org/sqlite)时，自行打开数据库并调用ExportSQLiteDB。
+//
+// 示例:
+//
+//	if err := ExportSQLite(h, "capture.db"); err != nil {
+//	    log.Fatal(err)
+//	}
+//	// sqlite3 capture.db "SELECT host, count(*) FROM entries GROUP BY host"
+func ExportSQLite(h *Har, filePath string) error {
+	_, err := exportSQLiteFile(h.Iterator(), h.Log.Pages, DefaultSQLiteDriverName, filePath)
+	return err
+}
+
+// ExportSQLiteIterator 从迭代器流式读取条目并写入SQLite数据库文件，返回写入的条目数
+//
+// 适合处理无法一次性加载到内存的大型HAR文件，pages可以来自StreamingHar.GetPages()。
+// 驱动的要求与ExportSQLite相同。
+func ExportSQLiteIterator(it EntryIterator, pages []Pages, filePath string) (int, error) {
+	return exportSQLiteFile(it, pages, DefaultSQLiteDriverName, filePath)
+}
+
+// exportSQLiteFile 使用指定的驱动打开数据库文件并导出条目
+func exportSQLiteFile(it EntryIterator, pages []Pages, driverName, filePath string) (int, error) {
+	db, err := sql.Open(driverName, filePath)
+	if err != nil {
+		return 0, NewUnsupportedError(fmt.Sprintf("无法打开SQLite数据库，请确认已导入驱动 '%s': %v", driverName, err)).
+			WithMetadata("filePath", filePath)
+	}
+	defer db.Close()
+
+	count, err := ExportSQLiteDB(db, it, pages)
+	if err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return count, err
+	}
+	return count, nil
+}
+
+// ExportSQLiteDB 将条目写入已打开的数据库连接，整个导出在一个事务中完成
+//
+// 示例:
+//
+//	db, err := sql.Open("sqlite", "capture.db") // modernc.org/sqlite
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	defer db.Close()
+//	count, err := ExportSQLiteDB(db, h.Iterator(), h.Log.Pages)
+func ExportSQLiteDB(db *sql.DB, it EntryIterator, pages []Pages) (int, error) {
+	for _, stmt := range sqliteSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			return 0, NewHarError(ErrCodeUnknown, "无法创建数据表", err)
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, NewHarError(ErrCodeUnknown, "无法开始事务", err)
+	}
+	exporter, err := newSQLExporter(tx)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	defer exporter.close()
+
+	for _, page := range pages {
+		if err := exporter.insertPage(page); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	count := 0
+	for it.Next() {
+		if err := exporter.insertEntry(it.Entry()); err != nil {
+			tx.Rollback()
+			return count, err.WithField(fmt.Sprintf("log.entries[%d]", count))
+		}
+		count++
+	}
+	if err := it.Err(); err != nil {
+		tx.Rollback()
+		return count, err
+	}
+	if err := tx.Commit(); err != nil {
+		return count, NewHarError(ErrCodeUnknown, "无法提交事务", err)
+	}
+	return count, nil
+}
+
+// sqlExporter 持有导出所用的预编译语句
+type sqlExporter struct {
+	page, entry, header, cookie, param *sql.Stmt
+}
+
+func newSQLExporter(tx *sql.Tx) (*sqlExporter, error) {
+	e := &sqlExporter{}
+	statements := []struct {
+		target **sql.Stmt
+		query  string
+	}{
+		{&e.page, `INSERT OR IGNORE INTO pages (id, title, started_date_time, on_content_load, on_load) VALUES (?, ?, ?, ?, ?)`},
+		{&e.entry, `INSERT INTO entries (page_id, started_date_time, time, method, url, host, http_version, status, status_text,
+			mime_type, request_headers_size, request_body_size, request_body, response_headers_size, response_body_size,
+			content_size, content_encoding, response_body, redirect_url, server_ip_address, connection, resource_type,
+			blocked, dns, connect, ssl, send, wait, receive)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`},
+		{&e.header, `INSERT INTO headers (entry_id, direction, position, name, value) VALUES (?, ?, ?, ?, ?)`},
+		{&e.cookie, `INSERT INTO cookies (entry_id, direction, name, value, path, domain, expires, http_only, secure, same_site)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`},
+		{&e.param, `INSERT INTO query_params (entry_id, position, name, value) VALUES (?, ?, ?, ?)`},
+	}
+
+	for _, s := range statements {
+		stmt, err := tx.Prepare(s.query)
+		if err != nil {
+			e.close()
+			return nil, NewHarError(ErrCodeUnknown, "无法预编译插入语句", err)
+		}
+		*s.target = stmt
+	}
+	return e, nil
+}
+
+func (e *sqlExporter) close() {
+	for _, stmt := range []*sql.Stmt{e.page, e.entry, e.header, e.cookie, e.param} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+func (e *sqlExporter) insertPage(page Pages) error {
+	_, err := e.page.Exec(page.ID, page.Title, sqlTime(page.StartedDateTime),
+		page.PageTimings.OnContentLoad, page.PageTimings.OnLoad)
+	if err != nil {
+		return NewHarError(ErrCodeUnknown, fmt.Sprintf("无法写入页面 '%s'", page.ID), err)
+	}
+	return nil
+}
+
+func (e *sqlExporter) insertEntry(entry *Entries) *HarError {
+	host := ""
+	if u, err := url.Parse(entry.Request.URL); err == nil {
+		host = u.Hostname()
+	}
+	_, requestBody := postDataText(entry.Request)
+	t := entry.Timings
+
+	result, err := e.entry.Exec(
+		optionalString(entry.Pageref), sqlTime(entry.StartedDateTime), entry.Time,
+		entry.Request.Method, entry.Request.URL, host, entry.Request.HTTPVersion,
+		entry.Response.Status, entry.Response.StatusText, entry.Response.Content.MimeType,
+		entry.Request.HeadersSize, entry.Request.BodySize, optionalString(requestBody),
+		entry.Response.HeadersSize, entry.Response.BodySize, entry.Response.Content.Size,
+		optionalString(entry.Response.Content.Encoding), optionalString(entry.Response.Content.Text),
+		optionalString(entry.Response.RedirectURL), optionalString(entry.ServerIPAddress),
+		optionalString(entry.Connection), optionalString(entry.ResourceType),
+		optionalTiming(t.Blocked), optionalTiming(t.DNS), optionalTiming(t.Connect), optionalTiming(t.Ssl),
+		optionalTiming(t.Send), optionalTiming(t.Wait), optionalTiming(t.Receive),
+	)
+	if err != nil {
+		return NewHarError(ErrCodeUnknown, "无法写入条目", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return NewHarError(ErrCodeUnknown, "无法获取条目ID", err)
+	}
+
+	directions := []struct {
+		name    string
+		headers []Headers
+		cookies []Cookie
+	}{
+		{"request", entry.Request.Headers, entry.Request.Cookies},
+		{"response", entry.Response.Headers, entry.Response.Cookies},
+	}
+	for _, d := range directions {
+		for i, header := range d.headers {
+			if _, err := e.header.Exec(id, d.name, i, header.Name, header.Value); err != nil {
+				return NewHarError(ErrCodeUnknown, "无法写入头部", err)
+			}
+		}
+		for _, c := range d.cookies {
+			if _, err := e.cookie.Exec(id, d.name, c.Name, c.Value, optionalString(c.Path), optionalString(c.Domain),
+				sqlTime(c.Expires), c.HTTPOnly, c.Secure, optionalString(c.SameSite)); err != nil {
+				return NewHarError(ErrCodeUnknown, "无法写入Cookie", err)
+			}
+		}
+	}
+	for i, param := range entry.Request.QueryString {
+		if _, err := e.param.Exec(id, i, param.Name, param.Value); err != nil {
+			return NewHarError(ErrCodeUnknown, "无法写入查询参数", err)
+		}
+	}
+	return nil
+}
+
+// sqlTime 将时间格式化为ISO 8601文本，零值写为NULL
+func sqlTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
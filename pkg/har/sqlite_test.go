@@ -0,0 +1,211 @@
+package har
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSQLDriverName 测试用的database/sql驱动，按DSN记录执行的语句
+const fakeSQLDriverName = "har-fake-sql"
+
+func init() {
+	sql.Register(fakeSQLDriverName, fakeSQLDriver{})
+}
+
+// fakeSQLRecorders DSN到记录器的映射
+var fakeSQLRecorders sync.Map
+
+// fakeSQLRecorder 记录一个DSN上执行的语句和事务状态
+type fakeSQLRecorder struct {
+	mu         sync.Mutex
+	execs      []fakeSQLExec
+	committed  bool
+	rolledBack bool
+	// failOn 语句包含该文本时返回错误
+	failOn string
+	lastID int64
+}
+
+type fakeSQLExec struct {
+	query string
+	args  []driver.Value
+}
+
+// inserts 返回插入指定表的所有参数
+func (r *fakeSQLRecorder) inserts(table string) [][]driver.Value {
+	var rows [][]driver.Value
+	for _, e := range r.execs {
+		if strings.HasPrefix(e.query, "INSERT INTO "+table+" ") || strings.HasPrefix(e.query, "INSERT OR IGNORE INTO "+table+" ") {
+			rows = append(rows, e.args)
+		}
+	}
+	return rows
+}
+
+func newFakeSQLRecorder(t *testing.T) (*fakeSQLRecorder, string) {
+	recorder := &fakeSQLRecorder{}
+	fakeSQLRecorders.Store(t.Name(), recorder)
+	t.Cleanup(func() { fakeSQLRecorders.Delete(t.Name()) })
+	return recorder, t.Name()
+}
+
+type fakeSQLDriver struct{}
+
+func (fakeSQLDriver) Open(dsn string) (driver.Conn, error) {
+	recorder, ok := fakeSQLRecorders.Load(dsn)
+	if !ok {
+		return nil, errors.New("unknown dsn")
+	}
+	return &fakeSQLConn{recorder: recorder.(*fakeSQLRecorder)}, nil
+}
+
+type fakeSQLConn struct {
+	recorder *fakeSQLRecorder
+}
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeSQLStmt{recorder: c.recorder, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+
+func (c *fakeSQLConn) Close() error { return nil }
+
+func (c *fakeSQLConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeSQLConn) Commit() error {
+	c.recorder.committed = true
+	return nil
+}
+
+func (c *fakeSQLConn) Rollback() error {
+	c.recorder.rolledBack = true
+	return nil
+}
+
+type fakeSQLStmt struct {
+	recorder *fakeSQLRecorder
+	query    string
+}
+
+func (s *fakeSQLStmt) Close() error { return nil }
+
+func (s *fakeSQLStmt) NumInput() int { return -1 }
+
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	r := s.recorder
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failOn != "" && strings.Contains(s.query, r.failOn) {
+		return nil, errors.New("exec failed")
+	}
+	r.execs = append(r.execs, fakeSQLExec{query: s.query, args: args})
+	if strings.HasPrefix(s.query, "INSERT INTO entries ") {
+		r.lastID++
+	}
+	return fakeSQLResult{id: r.lastID}, nil
+}
+
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+// fakeSQLResult 返回最近插入的条目ID
+type fakeSQLResult struct {
+	id int64
+}
+
+func (r fakeSQLResult) LastInsertId() (int64, error) { return r.id, nil }
+
+func (r fakeSQLResult) RowsAffected() (int64, error) { return 1, nil }
+
+func TestExportSQLite(t *testing.T) {
+	recorder, dsn := newFakeSQLRecorder(t)
+
+	h := NewHar()
+	page := h.AddPage("page_1", "Home")
+	page.StartedDateTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	first := h.AddEntry("GET", "https://x.com/a?q=1&r=2", "HTTP/1.1", "page_1")
+	first.SetResponseStatus(200, "OK")
+	first.SetTimings(-1, 5, 10, 1, 20, 3, -1)
+	first.AddRequestHeader("Accept", "*/*")
+	first.AddResponseHeader("Content-Type", "text/html")
+	first.Request.QueryString = []Headers{{Name: "q", Value: "1"}, {Name: "r", Value: "2"}}
+	second := h.AddEntry("POST", "https://y.com/b", "HTTP/1.1", "")
+	second.AddRequestHeader("Host", "y.com")
+
+	db, err := sql.Open(fakeSQLDriverName, dsn)
+	assert.NoError(t, err)
+	defer db.Close()
+	count, err := ExportSQLiteDB(db, h.Iterator(), h.Log.Pages)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.True(t, recorder.committed)
+	assert.False(t, recorder.rolledBack)
+
+	// 先按顺序创建表和索引
+	if !assert.Greater(t, len(recorder.execs), len(sqliteSchema)) {
+		return
+	}
+	for i, stmt := range sqliteSchema {
+		assert.Equal(t, strings.Join(strings.Fields(stmt), " "), recorder.execs[i].query)
+	}
+
+	pages := recorder.inserts("pages")
+	if assert.Len(t, pages, 1) {
+		assert.Equal(t, []driver.Value{"page_1", "Home", "2024-01-01T00:00:00Z", -1.0, -1.0}, pages[0])
+	}
+
+	entries := recorder.inserts("entries")
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "page_1", entries[0][0])
+		assert.Equal(t, "x.com", entries[0][5])
+		assert.Equal(t, int64(200), entries[0][7])
+		// blocked和ssl为-1时写为NULL
+		assert.Equal(t, []driver.Value{nil, 5.0, 10.0, nil, 1.0, 20.0, 3.0}, entries[0][22:29])
+		assert.Nil(t, entries[1][0], "没有页面的条目page_id为NULL")
+		assert.Equal(t, "POST", entries[1][3])
+	}
+
+	// 头部和查询参数通过entry_id关联到对应的条目
+	assert.Equal(t, [][]driver.Value{
+		{int64(1), "request", int64(0), "Accept", "*/*"},
+		{int64(1), "response", int64(0), "Content-Type", "text/html"},
+		{int64(2), "request", int64(0), "Host", "y.com"},
+	}, recorder.inserts("headers"))
+	assert.Equal(t, [][]driver.Value{
+		{int64(1), int64(0), "q", "1"},
+		{int64(1), int64(1), "r", "2"},
+	}, recorder.inserts("query_params"))
+}
+
+func TestExportSQLiteErrors(t *testing.T) {
+	h := NewHar()
+	h.AddEntry("GET", "https://x.com/a", "HTTP/1.1", "").AddRequestHeader("Accept", "*/*")
+
+	// 测试环境没有注册默认驱动
+	err := ExportSQLite(h, "capture.db")
+	if assert.Error(t, err) {
+		assert.Equal(t, ErrCodeUnsupported, err.(*HarError).Code)
+		assert.Contains(t, err.Error(), DefaultSQLiteDriverName)
+		assert.Equal(t, "capture.db", err.(*HarError).Metadata["filePath"])
+	}
+
+	// 写入失败时回滚事务，错误指向对应的条目
+	recorder, dsn := newFakeSQLRecorder(t)
+	recorder.failOn = "INSERT INTO headers"
+	count, err := exportSQLiteFile(h.Iterator(), nil, fakeSQLDriverName, dsn)
+	assert.Equal(t, 0, count)
+	if assert.Error(t, err) {
+		harErr := err.(*HarError)
+		assert.Equal(t, "log.entries[0]", harErr.Field)
+		assert.Equal(t, dsn, harErr.Metadata["filePath"])
+	}
+	assert.True(t, recorder.rolledBack)
+	assert.False(t, recorder.committed)
+}
@@ -51,6 +51,10 @@ type StreamingEntryIterator struct {
 	currentPos int
 	entry      Entries
 	closed     bool
+	validator  *Validator // 运行已注册的自定义规则，没有规则时为nil
+	findings   []Finding  // 当前条目上的发现，每次Next时重置
+	strict     bool       // 错误级别的发现是否终止迭代
+	lines      *lineIndex // 出错时用于计算行列号
 }
 
 // NewStreamingHarFromFile 从文件路径创建一个流式HAR对象
@@ -97,7 +101,7 @@ func NewStreamingHarFromBytes(data []byte) (*StreamingHar, error) {
 	tempHar := &Har{}
 	err := json.Unmarshal(data, tempHar)
 	if err != nil {
-		return nil, fmt.Errorf("无法解析HAR数据: %w", err)
+		return nil, fmt.Errorf("无法解析HAR数据: %w", wrapJSONError(data, err))
 	}
 
 	// 创建HAR对象并填充基本信息
@@ -245,11 +249,15 @@ func (h *StreamingHar) Entries() *StreamingEntryIterator {
 	// 通过直接解码JSON创建迭代器
 	decoder := json.NewDecoder(bytes.NewReader(h.data))
 
-	return &StreamingEntryIterator{
+	it := &StreamingEntryIterator{
 		har:     h,
 		decoder: decoder,
 		entry:   Entries{},
 	}
+	if len(RegisteredRules()) > 0 {
+		it.validator = newRegisteredRuleValidator()
+	}
+	return it
 }
 
 // Next 获取下一个条目
@@ -294,18 +302,72 @@ func (it *StreamingEntryIterator) Next() bool {
 		return false
 	}
 
-	// 解析下一个条目
+	// 解析下一个条目，先读取原始JSON以便将错误定位到输入中
+	path := fmt.Sprintf("log.entries[%d]", it.currentPos)
+	var raw json.RawMessage
+	if err := it.decoder.Decode(&raw); err != nil {
+		it.err = it.positionError(path, nil, 0, err)
+		return false
+	}
+	start := int(it.decoder.InputOffset()) - len(raw)
+
 	var entry Entries
-	if err := it.decoder.Decode(&entry); err != nil {
-		it.err = err
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		it.err = it.positionError(path, raw, start, err)
 		return false
 	}
 
 	it.entry = entry
 	it.currentPos++
+
+	// 运行自定义规则，发现只保留当前条目的，严格模式下错误级别的发现会终止迭代
+	it.findings = nil
+	if it.validator != nil {
+		report := it.validator.ValidateEntry(&it.entry, it.currentPos-1)
+		it.findings = report.Findings
+		if err := report.Err(); err != nil && it.strict {
+			if harErr, ok := err.(*HarError); ok {
+				locator := &positionLocator{lines: it.lineIndex()}
+				locator.addSpan(path, start)
+				locator.annotate(harErr)
+			}
+			it.err = err
+			return false
+		}
+	}
 	return true
 }
 
+// positionError 创建条目解析错误并添加位置，raw为nil时err中的偏移相对于整个输入
+func (it *StreamingEntryIterator) positionError(path string, raw []byte, start int, err error) error {
+	if err == io.EOF {
+		return err
+	}
+	harErr := newLocalizedError(ErrCodeJSONParse, "parse.entry", err, it.currentPos+1).WithField(path)
+	if raw == nil {
+		raw = it.har.data
+	}
+	if offset, ok := jsonErrorOffset(raw, err); ok {
+		harErr.withPosition(it.lineIndex(), start+offset)
+	} else if errors.Is(err, io.ErrUnexpectedEOF) {
+		harErr.withPosition(it.lineIndex(), len(it.har.data))
+	}
+	return harErr
+}
+
+// lineIndex 返回输入的行索引，首次出错时创建
+func (it *StreamingEntryIterator) lineIndex() *lineIndex {
+	if it.lines == nil {
+		it.lines = newLineIndex(it.har.data)
+	}
+	return it.lines
+}
+
+// Findings 返回自定义规则在当前条目上产生的发现，调用Next后会被下一个条目的发现替换
+func (it *StreamingEntryIterator) Findings() []Finding {
+	return it.findings
+}
+
 // Entry 返回当前条目
 func (it *StreamingEntryIterator) Entry() *Entries {
 	return &it.entry
@@ -357,6 +419,46 @@ func (sh *StreamingHar) GetAllEntries() ([]Entries, error) {
 	return entries, nil
 }
 
+// sliceEntryIterator 基于内存中条目切片的迭代器
+type sliceEntryIterator struct {
+	entries []Entries
+	pos     int
+}
+
+// Iterator 返回遍历内存中条目的EntryIterator，
+// 使接受迭代器的流式导出函数同样可以处理已加载的HAR
+func (h *Har) Iterator() EntryIterator {
+	return &sliceEntryIterator{entries: h.Log.Entries, pos: -1}
+}
+
+// Next 移动到下一个条目
+func (it *sliceEntryIterator) Next() bool {
+	if it.pos+1 >= len(it.entries) {
+		it.pos = len(it.entries)
+		return false
+	}
+	it.pos++
+	return true
+}
+
+// Entry 返回当前条目
+func (it *sliceEntryIterator) Entry() *Entries {
+	if it.pos < 0 || it.pos >= len(it.entries) {
+		return nil
+	}
+	return &it.entries[it.pos]
+}
+
+// Err 内存迭代不会产生错误
+func (it *sliceEntryIterator) Err() error {
+	return nil
+}
+
+// Close 无需释放资源
+func (it *sliceEntryIterator) Close() error {
+	return nil
+}
+
 // 使用示例：
 //
 // har, err := har.NewStreamingHarFromFile("large.har")
@@ -2,6 +2,7 @@ package har
 
 import (
 	"fmt"
+	"math"
 	"net/url"
 	"strings"
 	"time"
@@ -17,294 +18,558 @@ const (
 	HarSpecVersion13 = "1.3"
 )
 
-// ValidateHarFile 验证HAR对象内容的有效性
-// 支持不同版本的HAR规范
-func ValidateHarFile(har *Har) error {
-	if har == nil {
-		return NewInvalidFormatError("HAR对象为空")
+// Severity 校验发现的严重程度
+type Severity int
+
+const (
+	// SeverityInfo 提示，通常表示不常见但合法的数据
+	SeverityInfo Severity = iota
+	// SeverityWarning 警告，数据可能不准确但仍可使用
+	SeverityWarning
+	// SeverityError 错误，违反HAR规范
+	SeverityError
+)
+
+// String 返回严重程度的名称
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
 	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
 
-	// 创建根错误
-	rootError := &HarError{
-		Code:    ErrCodeValidation,
-		Message: "HAR验证失败",
+// RuleInfo 描述一条校验规则
+type RuleInfo struct {
+	ID          string   // 稳定的规则ID，用于启用、禁用和过滤
+	Severity    Severity // 默认严重程度
+	Description string   // 规则说明
+}
+
+// builtinRules 内置的HAR 1.2规范规则
+var builtinRules = []RuleInfo{
+	{ID: "log-version-required", Severity: SeverityError},
+	{ID: "log-version-supported", Severity: SeverityError},
+	{ID: "log-creator-required", Severity: SeverityError},
+	{ID: "log-entries-required", Severity: SeverityError},
+	{ID: "page-id-required", Severity: SeverityError},
+	{ID: "page-id-unique", Severity: SeverityError},
+	{ID: "page-started-required", Severity: SeverityError},
+	{ID: "page-title-required", Severity: SeverityWarning},
+	{ID: "page-timings-sentinel", Severity: SeverityWarning},
+	{ID: "entry-started-required", Severity: SeverityError},
+	{ID: "entry-pageref-exists", Severity: SeverityWarning},
+	{ID: "entry-time-sum", Severity: SeverityWarning},
+	{ID: "date-plausible", Severity: SeverityWarning},
+	{ID: "request-method-required", Severity: SeverityError},
+	{ID: "request-url-required", Severity: SeverityError},
+	{ID: "request-url-valid", Severity: SeverityError},
+	{ID: "request-url-absolute", Severity: SeverityWarning},
+	{ID: "request-http-version-required", Severity: SeverityError},
+	{ID: "request-body-size", Severity: SeverityWarning},
+	{ID: "response-status-valid", Severity: SeverityError},
+	{ID: "response-http-version-required", Severity: SeverityError},
+	{ID: "response-body-size", Severity: SeverityInfo},
+	{ID: "content-mime-type-required", Severity: SeverityError},
+	{ID: "content-size-valid", Severity: SeverityWarning},
+	{ID: "header-name-required", Severity: SeverityError},
+	{ID: "headers-size-consistency", Severity: SeverityInfo},
+	{ID: "cookie-name-required", Severity: SeverityError},
+	{ID: "size-sentinel", Severity: SeverityError},
+	{ID: "timings-required", Severity: SeverityError},
+	{ID: "timings-sentinel", Severity: SeverityError},
+	{ID: "timings-ssl-connect", Severity: SeverityWarning},
+}
+
+// ValidationRules 返回所有内置校验规则和已注册的自定义规则，内置规则的说明使用当前默认语言
+func ValidationRules() []RuleInfo {
+	rules := make([]RuleInfo, len(builtinRules))
+	for i, rule := range builtinRules {
+		rules[i] = describeRule(rule)
 	}
+	for _, rule := range RegisteredRules() {
+		rules = append(rules, rule.Info())
+	}
+	return rules
+}
 
-	// 验证基本结构
-	if err := validateBasicStructure(har, rootError); err != nil {
-		return err
+// findRule 按ID查找内置规则
+func findRule(id string) (RuleInfo, bool) {
+	for _, rule := range builtinRules {
+		if rule.ID == id {
+			return describeRule(rule), true
+		}
 	}
+	return RuleInfo{}, false
+}
+
+// describeRule 从消息目录中填充内置规则的说明
+func describeRule(rule RuleInfo) RuleInfo {
+	rule.Description = localize("rule." + rule.ID)
+	return rule
+}
+
+// Finding 一条校验发现
+type Finding struct {
+	RuleID   string    // 规则ID
+	Severity Severity  // 严重程度
+	Path     string    // JSON路径，如 "log.entries[0].request.url"
+	Message  string    // 说明
+	Code     ErrorCode // 转换为HarError时使用的错误代码
+
+	MessageKey string        // 消息目录中的键，自由格式的消息为空
+	Params     []interface{} // 格式化消息时使用的参数
+}
+
+// HarError 将校验发现转换为HarError，规则ID和严重程度保存在Metadata中
+func (f Finding) HarError() *HarError {
+	e := NewHarError(f.Code, f.Message, nil)
+	e.MessageKey = f.MessageKey
+	e.Params = f.Params
+	return e.WithField(f.Path).
+		WithMetadata("ruleID", f.RuleID).
+		WithMetadata("severity", f.Severity.String())
+}
+
+// String 返回可读的描述
+func (f Finding) String() string {
+	return fmt.Sprintf("[%s] %s %s: %s", f.Severity, f.RuleID, f.Path, f.Message)
+}
+
+// ValidationReport 校验结果
+type ValidationReport struct {
+	Findings []Finding
+}
+
+// BySeverity 返回指定严重程度的校验发现
+func (r *ValidationReport) BySeverity(severity Severity) []Finding {
+	var findings []Finding
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
 
-	// 根据版本进行特定验证
-	switch har.Log.Version {
-	case HarSpecVersion11:
-		validateHarV11(har, rootError)
-	case HarSpecVersion12:
-		validateHarV12(har, rootError)
-	case HarSpecVersion13:
-		validateHarV13(har, rootError)
-	default:
-		rootError.AddPartialError(NewValidationError(
-			fmt.Sprintf("不支持的HAR版本: %s", har.Log.Version),
-			"log.version",
-		))
+// ByRule 返回指定规则的校验发现
+func (r *ValidationReport) ByRule(ruleID string) []Finding {
+	var findings []Finding
+	for _, f := range r.Findings {
+		if f.RuleID == ruleID {
+			findings = append(findings, f)
+		}
 	}
+	return findings
+}
 
-	// 验证条目的通用部分
-	validateEntries(har.Log.Entries, rootError)
+// HasErrors 是否存在错误级别的发现
+func (r *ValidationReport) HasErrors() bool {
+	for _, f := range r.Findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
 
-	// 验证页面
-	validatePages(har.Log.Pages, rootError)
+// HarErrors 将不低于minSeverity的发现转换为HarError列表
+func (r *ValidationReport) HarErrors(minSeverity Severity) []*HarError {
+	var errs []*HarError
+	for _, f := range r.Findings {
+		if f.Severity >= minSeverity {
+			errs = append(errs, f.HarError())
+		}
+	}
+	return errs
+}
 
-	// 有部分错误时返回
-	if rootError.HasPartialErrors() {
-		return rootError
+// Err 存在错误级别的发现时返回包含这些发现的HarError，否则返回nil
+func (r *ValidationReport) Err() error {
+	if !r.HasErrors() {
+		return nil
 	}
+	rootError := newLocalizedError(ErrCodeValidation, "validation.failed", nil)
+	for _, harErr := range r.HarErrors(SeverityError) {
+		rootError.AddPartialError(harErr)
+	}
+	return rootError
+}
 
-	return nil
+// Validator 可配置的HAR校验器
+//
+// 示例:
+//
+//	report := NewValidator().
+//	    Disable("page-title-required").
+//	    SetSeverity("entry-time-sum", SeverityError).
+//	    Validate(h)
+//	for _, f := range report.Findings {
+//	    fmt.Println(f)
+//	}
+type Validator struct {
+	disabled   map[string]bool
+	severities map[string]Severity
+	rules      []Rule
 }
 
-// validateBasicStructure 验证HAR的基本结构
-func validateBasicStructure(har *Har, rootError *HarError) error {
-	// 验证Log字段
-	if har.Log.Version == "" {
-		rootError.AddPartialError(NewMissingFieldError("log.version"))
+// NewValidator 创建启用所有内置规则和已注册自定义规则的校验器
+func NewValidator() *Validator {
+	return &Validator{
+		disabled:   make(map[string]bool),
+		severities: make(map[string]Severity),
+		rules:      RegisteredRules(),
 	}
+}
+
+// AddRule 只为当前校验器添加自定义规则，不影响全局注册表
+func (v *Validator) AddRule(rules ...Rule) *Validator {
+	v.rules = append(v.rules, rules...)
+	return v
+}
 
-	// 验证Creator字段
-	if har.Log.Creator.Name == "" {
-		rootError.AddPartialError(NewMissingFieldError("log.creator.name"))
+// Disable 禁用指定规则
+func (v *Validator) Disable(ruleIDs ...string) *Validator {
+	for _, id := range ruleIDs {
+		v.disabled[id] = true
 	}
+	return v
+}
 
-	if har.Log.Creator.Version == "" {
-		rootError.AddPartialError(NewMissingFieldError("log.creator.version"))
+// Enable 重新启用指定规则
+func (v *Validator) Enable(ruleIDs ...string) *Validator {
+	for _, id := range ruleIDs {
+		delete(v.disabled, id)
 	}
+	return v
+}
 
-	// 验证Entries数组
-	// HAR文件可以没有条目，但必须有数组
-	if har.Log.Entries == nil {
-		rootError.AddPartialError(NewMissingFieldError("log.entries"))
+// SetSeverity 覆盖规则的严重程度
+func (v *Validator) SetSeverity(ruleID string, severity Severity) *Validator {
+	v.severities[ruleID] = severity
+	return v
+}
+
+// IsEnabled 判断规则是否启用
+func (v *Validator) IsEnabled(ruleID string) bool {
+	return !v.disabled[ruleID]
+}
+
+// severity 返回规则当前的严重程度
+func (v *Validator) severity(ruleID string) Severity {
+	if s, ok := v.severities[ruleID]; ok {
+		return s
 	}
+	if rule, ok := findRule(ruleID); ok {
+		return rule.Severity
+	}
+	for _, rule := range v.rules {
+		if info := rule.Info(); info.ID == ruleID {
+			return info.Severity
+		}
+	}
+	return SeverityError
+}
 
-	// 有部分错误时返回
-	if rootError.HasPartialErrors() {
-		return rootError
+// Validate 校验HAR对象并返回所有发现，nil对象返回空结果
+func (v *Validator) Validate(har *Har) *ValidationReport {
+	c := &validationContext{validator: v, report: &ValidationReport{}}
+	if har != nil {
+		c.validateLog(&har.Log)
 	}
+	return c.report
+}
 
-	return nil
+// ValidateEntry 单独校验一个条目，index用于生成JSON路径
+//
+// 没有页面信息，因此不检查pageref是否存在，适用于流式处理。
+func (v *Validator) ValidateEntry(entry *Entries, index int) *ValidationReport {
+	c := &validationContext{validator: v, report: &ValidationReport{}}
+	if entry != nil {
+		c.validateEntry(entry, fmt.Sprintf("log.entries[%d]", index))
+	}
+	return c.report
 }
 
-// validateHarV11 验证HAR 1.1版本的特定要求
-func validateHarV11(har *Har, rootError *HarError) {
-	// 1.1版本特定验证
-	// 目前没有特别严格的验证，仅支持基本结构验证
+// ValidateHarFile 验证HAR对象内容的有效性
+//
+// 使用所有内置规则校验，只有错误级别的发现会导致返回错误，
+// 需要警告、提示或调整规则时请使用Validator。
+func ValidateHarFile(har *Har) error {
+	if har == nil {
+		return newLocalizedError(ErrCodeInvalidFormat, "har.nil", nil)
+	}
+	return NewValidator().Validate(har).Err()
 }
 
-// validateHarV12 验证HAR 1.2版本的特定要求
-func validateHarV12(har *Har, rootError *HarError) {
-	// 1.2版本特定验证
-	// 根据规范验证
+// validationContext 一次校验的状态
+type validationContext struct {
+	validator *Validator
+	report    *ValidationReport
+	pages     map[string]bool
 }
 
-// validateHarV13 验证HAR 1.3版本的特定要求
-func validateHarV13(har *Har, rootError *HarError) {
-	// 1.3版本特定验证
-	// 非官方但有一些工具使用此版本
+// add 记录一条使用格式字符串的校验发现，规则被禁用时忽略
+func (c *validationContext) add(ruleID string, code ErrorCode, path string, format string, args ...interface{}) {
+	c.record(Finding{RuleID: ruleID, Path: path, Code: code, Message: fmt.Sprintf(format, args...)})
 }
 
-// validateEntries 验证HAR条目
-func validateEntries(entries []Entries, rootError *HarError) {
-	for i, entry := range entries {
-		entryPrefix := fmt.Sprintf("log.entries[%d]", i)
+// addMessage 记录一条使用消息目录的校验发现
+func (c *validationContext) addMessage(ruleID string, code ErrorCode, path string, key string, params ...interface{}) {
+	c.record(Finding{
+		RuleID:     ruleID,
+		Path:       path,
+		Code:       code,
+		Message:    localize(key, params...),
+		MessageKey: key,
+		Params:     params,
+	})
+}
 
-		// 验证必要的时间字段
-		if entry.StartedDateTime.IsZero() {
-			rootError.AddPartialError(NewValidationError(
-				"条目必须有开始时间",
-				fmt.Sprintf("%s.startedDateTime", entryPrefix),
-			))
+// record 填充严重程度后保存校验发现，规则被禁用时忽略
+func (c *validationContext) record(finding Finding) {
+	if !c.validator.IsEnabled(finding.RuleID) {
+		return
+	}
+	finding.Severity = c.validator.severity(finding.RuleID)
+	c.report.Findings = append(c.report.Findings, finding)
+}
+
+// missing 记录缺少必需字段
+func (c *validationContext) missing(ruleID, path string) {
+	c.addMessage(ruleID, ErrCodeMissingField, path, "field.missing")
+}
+
+// invalid 记录不符合规范的字段值，key为消息目录中的键
+func (c *validationContext) invalid(ruleID, path string, key string, params ...interface{}) {
+	c.addMessage(ruleID, ErrCodeValidation, path, key, params...)
+}
+
+func (c *validationContext) validateLog(log *Log) {
+	if log.Version == "" {
+		c.missing("log-version-required", "log.version")
+	} else if !IsValidHarVersion(log.Version) {
+		c.invalid("log-version-supported", "log.version", "validation.version", log.Version)
+	}
+	if log.Creator.Name == "" {
+		c.missing("log-creator-required", "log.creator.name")
+	}
+	if log.Creator.Version == "" {
+		c.missing("log-creator-required", "log.creator.version")
+	}
+	// HAR文件可以没有条目，但必须有数组
+	if log.Entries == nil {
+		c.missing("log-entries-required", "log.entries")
+	}
+
+	c.pages = make(map[string]bool, len(log.Pages))
+	for i := range log.Pages {
+		c.validatePage(&log.Pages[i], fmt.Sprintf("log.pages[%d]", i))
+	}
+	for i := range log.Entries {
+		c.validateEntry(&log.Entries[i], fmt.Sprintf("log.entries[%d]", i))
+	}
+
+	for _, rule := range c.validator.rules {
+		if logRule, ok := rule.(LogRule); ok {
+			logRule.CheckLog(log, c.reporter(rule))
 		}
+	}
+}
 
-		// 验证请求
-		validateRequest(entry.Request, fmt.Sprintf("%s.request", entryPrefix), rootError)
+func (c *validationContext) validatePage(page *Pages, path string) {
+	if page.ID == "" {
+		c.invalid("page-id-required", path+".id", "validation.page_id")
+	} else if c.pages[page.ID] {
+		c.invalid("page-id-unique", path+".id", "validation.page_dup", page.ID)
+	}
+	c.pages[page.ID] = true
 
-		// 验证响应
-		validateResponse(entry.Response, fmt.Sprintf("%s.response", entryPrefix), rootError)
+	if page.StartedDateTime.IsZero() {
+		c.invalid("page-started-required", path+".startedDateTime", "validation.page_start")
+	} else {
+		c.validateDate(page.StartedDateTime, path+".startedDateTime")
+	}
+	if page.Title == "" {
+		c.invalid("page-title-required", path+".title", "validation.page_title")
+	}
 
-		// 验证时间字段
-		validateTimings(entry.Timings, fmt.Sprintf("%s.timings", entryPrefix), rootError)
+	// 页面加载时间不可用时为-1
+	for _, t := range []struct {
+		name  string
+		value float64
+	}{
+		{"onContentLoad", page.PageTimings.OnContentLoad},
+		{"onLoad", page.PageTimings.OnLoad},
+	} {
+		if t.value < 0 && t.value != -1 {
+			c.invalid("page-timings-sentinel", path+".pageTimings."+t.name, "validation.time_sentinel", t.value)
+		}
 	}
 }
 
-// validateRequest 验证HTTP请求
-func validateRequest(req Request, fieldPath string, rootError *HarError) {
-	// 验证方法
+func (c *validationContext) validateEntry(entry *Entries, path string) {
+	if entry.StartedDateTime.IsZero() {
+		c.invalid("entry-started-required", path+".startedDateTime", "validation.entry_start")
+	} else {
+		c.validateDate(entry.StartedDateTime, path+".startedDateTime")
+	}
+
+	if entry.Pageref != "" && c.pages != nil && !c.pages[entry.Pageref] {
+		c.invalid("entry-pageref-exists", path+".pageref", "validation.pageref", entry.Pageref)
+	}
+
+	c.validateRequest(&entry.Request, path+".request")
+	c.validateResponse(&entry.Response, path+".response")
+	c.validateTimings(entry, path)
+
+	for _, rule := range c.validator.rules {
+		rule.CheckEntry(entry, path, c.reporter(rule))
+	}
+}
+
+// validateDate 检查日期是否在合理范围内，格式已由JSON解码按ISO 8601保证
+func (c *validationContext) validateDate(date time.Time, path string) {
+	if date.Year() < 1990 || date.After(time.Now().Add(24*time.Hour)) {
+		c.invalid("date-plausible", path, "validation.date", date.Format(time.RFC3339))
+	}
+}
+
+func (c *validationContext) validateRequest(req *Request, path string) {
 	if req.Method == "" {
-		rootError.AddPartialError(NewValidationError(
-			"HTTP请求必须有方法",
-			fmt.Sprintf("%s.method", fieldPath),
-		))
+		c.invalid("request-method-required", path+".method", "validation.method")
 	}
 
-	// 验证URL
 	if req.URL == "" {
-		rootError.AddPartialError(NewValidationError(
-			"HTTP请求必须有URL",
-			fmt.Sprintf("%s.url", fieldPath),
-		))
-	} else {
-		// 验证URL格式
-		_, err := url.Parse(req.URL)
-		if err != nil {
-			rootError.AddPartialError(NewValidationError(
-				fmt.Sprintf("无效的URL格式: %s", err.Error()),
-				fmt.Sprintf("%s.url", fieldPath),
-			))
-		}
+		c.invalid("request-url-required", path+".url", "validation.url")
+	} else if u, err := url.Parse(req.URL); err != nil {
+		c.invalid("request-url-valid", path+".url", "url.invalid", err.Error())
+	} else if u.Scheme == "" || u.Host == "" {
+		c.invalid("request-url-absolute", path+".url", "url.not_absolute", req.URL)
 	}
 
-	// 验证HTTP版本
 	if req.HTTPVersion == "" {
-		rootError.AddPartialError(NewValidationError(
-			"HTTP请求必须有版本",
-			fmt.Sprintf("%s.httpVersion", fieldPath),
-		))
+		c.invalid("request-http-version-required", path+".httpVersion", "validation.req_version")
 	}
 
-	// 验证headers
-	validateHeaders(req.Headers, fmt.Sprintf("%s.headers", fieldPath), rootError)
+	c.validateSizes(req.HeadersSize, req.BodySize, len(req.Headers), path)
+
+	_, text := postDataText(*req)
+	if text != "" && req.BodySize == 0 {
+		c.invalid("request-body-size", path+".bodySize", "validation.body_zero")
+	} else if req.BodySize > 0 && req.PostData == nil {
+		c.invalid("request-body-size", path+".bodySize", "validation.body_extra", req.BodySize)
+	}
 
-	// 验证cookies
-	validateCookies(req.Cookies, fmt.Sprintf("%s.cookies", fieldPath), rootError)
+	c.validateHeaders(req.Headers, path+".headers")
+	c.validateCookies(req.Cookies, path+".cookies")
 }
 
-// validateResponse 验证HTTP响应
-func validateResponse(resp Response, fieldPath string, rootError *HarError) {
-	// 验证状态码
+func (c *validationContext) validateResponse(resp *Response, path string) {
 	if resp.Status <= 0 {
-		rootError.AddPartialError(NewValidationError(
-			"HTTP响应必须有有效的状态码",
-			fmt.Sprintf("%s.status", fieldPath),
-		))
+		c.invalid("response-status-valid", path+".status", "validation.status")
 	}
-
-	// 验证HTTP版本
 	if resp.HTTPVersion == "" {
-		rootError.AddPartialError(NewValidationError(
-			"HTTP响应必须有版本",
-			fmt.Sprintf("%s.httpVersion", fieldPath),
-		))
+		c.invalid("response-http-version-required", path+".httpVersion", "validation.resp_version")
 	}
 
-	// 验证content
-	validateContent(resp.Content, fmt.Sprintf("%s.content", fieldPath), rootError)
+	c.validateSizes(resp.HeadersSize, resp.BodySize, len(resp.Headers), path)
 
-	// 验证headers
-	validateHeaders(resp.Headers, fmt.Sprintf("%s.headers", fieldPath), rootError)
+	content := resp.Content
+	if content.MimeType == "" {
+		c.invalid("content-mime-type-required", path+".content.mimeType", "validation.mime_type")
+	}
+	if content.Size < 0 {
+		c.invalid("content-size-valid", path+".content.size", "validation.content_size", content.Size)
+	}
+	if resp.BodySize > 0 && content.Size >= 0 && resp.BodySize > content.Size &&
+		headerValue(resp.Headers, "Content-Encoding") == "" {
+		c.invalid("response-body-size", path+".bodySize",
+			"validation.body_exceeds", resp.BodySize, content.Size)
+	}
 
-	// 验证cookies
-	validateCookies(resp.Cookies, fmt.Sprintf("%s.cookies", fieldPath), rootError)
+	c.validateHeaders(resp.Headers, path+".headers")
+	c.validateCookies(resp.Cookies, path+".cookies")
 }
 
-// validateContent 验证内容
-func validateContent(content Content, fieldPath string, rootError *HarError) {
-	// 验证MIME类型
-	if content.MimeType == "" {
-		rootError.AddPartialError(NewValidationError(
-			"内容必须有MIME类型",
-			fmt.Sprintf("%s.mimeType", fieldPath),
-		))
+// validateSizes 检查headersSize和bodySize的-1哨兵值以及与头部的一致性
+func (c *validationContext) validateSizes(headersSize, bodySize, headerCount int, path string) {
+	if headersSize < -1 {
+		c.invalid("size-sentinel", path+".headersSize", "validation.size_sentinel", headersSize)
+	}
+	if bodySize < -1 {
+		c.invalid("size-sentinel", path+".bodySize", "validation.size_sentinel", bodySize)
+	}
+	if headersSize > 0 && headerCount == 0 {
+		c.invalid("headers-size-consistency", path+".headersSize", "validation.headers_size", headersSize)
 	}
 }
 
-// validateHeaders 验证HTTP头
-func validateHeaders(headers []Headers, fieldPath string, rootError *HarError) {
+func (c *validationContext) validateHeaders(headers []Headers, path string) {
 	for i, header := range headers {
-		headerPath := fmt.Sprintf("%s[%d]", fieldPath, i)
-
 		if header.Name == "" {
-			rootError.AddPartialError(NewValidationError(
-				"HTTP头必须有名称",
-				fmt.Sprintf("%s.name", headerPath),
-			))
+			c.invalid("header-name-required", fmt.Sprintf("%s[%d].name", path, i), "validation.header_name")
 		}
 	}
 }
 
-// validateCookies 验证Cookies
-func validateCookies(cookies []Cookie, fieldPath string, rootError *HarError) {
+func (c *validationContext) validateCookies(cookies []Cookie, path string) {
 	for i, cookie := range cookies {
-		cookiePath := fmt.Sprintf("%s[%d]", fieldPath, i)
-
 		if cookie.Name == "" {
-			rootError.AddPartialError(NewValidationError(
-				"Cookie必须有名称",
-				fmt.Sprintf("%s.name", cookiePath),
-			))
+			c.invalid("cookie-name-required", fmt.Sprintf("%s[%d].name", path, i), "validation.cookie_name")
 		}
 	}
 }
 
-// validateTimings 验证时间
-func validateTimings(timings Timings, fieldPath string, rootError *HarError) {
-	// 验证必要的时间字段
-	if timings.Wait < 0 {
-		rootError.AddPartialError(NewValidationError(
-			"等待时间不能为负",
-			fmt.Sprintf("%s.wait", fieldPath),
-		))
-	}
-
-	if timings.Receive < 0 {
-		rootError.AddPartialError(NewValidationError(
-			"接收时间不能为负",
-			fmt.Sprintf("%s.receive", fieldPath),
-		))
+func (c *validationContext) validateTimings(entry *Entries, entryPath string) {
+	timings := entry.Timings
+	path := entryPath + ".timings"
+
+	required := []struct {
+		name  string
+		value float64
+	}{
+		{"send", timings.Send},
+		{"wait", timings.Wait},
+		{"receive", timings.Receive},
 	}
-}
-
-// validatePages 验证页面
-func validatePages(pages []Pages, rootError *HarError) {
-	for i, page := range pages {
-		pagePath := fmt.Sprintf("log.pages[%d]", i)
-
-		// 验证ID
-		if page.ID == "" {
-			rootError.AddPartialError(NewValidationError(
-				"页面必须有ID",
-				fmt.Sprintf("%s.id", pagePath),
-			))
+	complete := true
+	for _, t := range required {
+		if t.value < 0 {
+			c.invalid("timings-required", path+"."+t.name, "validation."+t.name)
+			complete = false
 		}
+	}
 
-		// 验证开始时间
-		if page.StartedDateTime.IsZero() {
-			rootError.AddPartialError(NewValidationError(
-				"页面必须有开始时间",
-				fmt.Sprintf("%s.startedDateTime", pagePath),
-			))
+	optional := []struct {
+		name  string
+		value float64
+	}{
+		{"blocked", timings.Blocked},
+		{"dns", timings.DNS},
+		{"connect", timings.Connect},
+		{"ssl", timings.Ssl},
+	}
+	for _, t := range optional {
+		if t.value < 0 && t.value != -1 {
+			c.invalid("timings-sentinel", path+"."+t.name, "validation.time_sentinel", t.value)
 		}
-
-		// 验证页面加载时间
-		validatePageTimings(page.PageTimings, fmt.Sprintf("%s.pageTimings", pagePath), rootError)
 	}
-}
 
-// validatePageTimings 验证页面加载时间
-func validatePageTimings(timings PageTimings, fieldPath string, rootError *HarError) {
-	// 页面加载时间可以为负，值-1表示不可用
-	// 因此这里不做特殊验证
-}
+	if timings.Ssl > 0 && timings.Connect >= 0 && timings.Ssl > timings.Connect {
+		c.invalid("timings-ssl-connect", path+".ssl", "validation.ssl_connect", timings.Ssl, timings.Connect)
+	}
 
-// validateDate 验证日期格式
-func validateDate(date time.Time, fieldPath string, rootError *HarError) bool {
-	if date.IsZero() {
-		rootError.AddPartialError(NewValidationError(
-			"日期不能为空",
-			fieldPath,
-		))
-		return false
+	if complete {
+		sum := timingsTotal(timings)
+		if math.Abs(entry.Time-sum) > 1 {
+			c.invalid("entry-time-sum", entryPath+".time", "validation.time_sum", entry.Time, sum)
+		}
 	}
-	return true
 }
 
 // IsValidHarVersion 检查是否为支持的HAR版本
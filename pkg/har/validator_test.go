@@ -238,3 +238,60 @@ func TestHarVersionOptions(t *testing.T) {
 		assert.False(t, opts.autoDetectVersion)
 	})
 }
+
+func TestValidatorRules(t *testing.T) {
+	h := NewHar()
+	h.AddPage("page_1", "首页")
+	h.Log.Pages = append(h.Log.Pages, h.Log.Pages[0])
+	e := h.AddEntry("GET", "/relative", "HTTP/1.1", "page_2")
+	e.SetResponseStatus(200, "OK")
+	e.Response.Content.MimeType = "text/html"
+	e.SetTimings(-1, -1, 5, 1, 10, 1, 8)
+	e.Time = 100
+
+	report := NewValidator().Validate(h)
+	assert.True(t, report.HasErrors())
+
+	rules := map[string]Finding{}
+	for _, f := range report.Findings {
+		rules[f.RuleID] = f
+	}
+	assert.Equal(t, SeverityError, rules["page-id-unique"].Severity)
+	assert.Equal(t, "log.pages[1].id", rules["page-id-unique"].Path)
+	assert.Equal(t, SeverityWarning, rules["entry-pageref-exists"].Severity)
+	assert.Equal(t, "log.entries[0].pageref", rules["entry-pageref-exists"].Path)
+	assert.Equal(t, "log.entries[0].request.url", rules["request-url-absolute"].Path)
+	assert.Equal(t, "log.entries[0].timings.ssl", rules["timings-ssl-connect"].Path)
+	assert.Equal(t, "log.entries[0].time", rules["entry-time-sum"].Path)
+
+	harErr := rules["page-id-unique"].HarError()
+	assert.Equal(t, "page-id-unique", harErr.Metadata["ruleID"])
+	assert.Equal(t, "error", harErr.Metadata["severity"])
+
+	// 禁用规则并调整严重程度
+	report = NewValidator().
+		Disable("page-id-unique", "entry-time-sum").
+		SetSeverity("request-url-absolute", SeverityError).
+		Validate(h)
+	assert.Empty(t, report.ByRule("page-id-unique"))
+	assert.Empty(t, report.ByRule("entry-time-sum"))
+	errs := report.BySeverity(SeverityError)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "request-url-absolute", errs[0].RuleID)
+	}
+
+	// ValidateHarFile只因错误级别的发现失败
+	report = NewValidator().Disable("page-id-unique").Validate(h)
+	assert.False(t, report.HasErrors())
+	assert.NoError(t, report.Err())
+	assert.NotEmpty(t, report.HarErrors(SeverityWarning))
+}
+
+func TestValidationRulesUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, rule := range ValidationRules() {
+		assert.False(t, seen[rule.ID], rule.ID)
+		assert.NotEmpty(t, rule.Description)
+		seen[rule.ID] = true
+	}
+}
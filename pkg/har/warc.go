@@ -0,0 +1,488 @@
+package har
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// warcVersion 写入的WARC版本
+const warcVersion = "WARC/1.1"
+
+// maxWARCRecordSize 单条记录内容的最大长度，防止损坏文件导致过量内存分配
+const maxWARCRecordSize = 256 * 1024 * 1024
+
+// warcRecord 一条WARC记录
+type warcRecord struct {
+	header textproto.MIMEHeader
+	block  []byte
+}
+
+// get 返回记录头部字段的值
+func (r *warcRecord) get(name string) string {
+	return r.header.Get(name)
+}
+
+// WriteWARC 将HAR条目写为ISO 28500 WARC记录
+//
+// 首先写入一条warcinfo记录，随后每个条目对应一条request记录和一条response记录，
+// 两者通过WARC-Concurrent-To互相关联。WARC-Date取自条目的StartedDateTime，
+// WARC-IP-Address取自ServerIPAddress。响应体由Content.Text还原(base64内容会被解码)；
+// 声明为gzip或deflate的响应体会重新压缩，其他无法还原的Content-Encoding
+// 和Transfer-Encoding头部会被移除，Content-Length按实际写入的长度更新。
+//
+// 示例:
+//
+//	if err := h.WriteWARC(file); err != nil {
+//	    log.Fatalf("写入WARC失败: %v", err)
+//	}
+func (h *Har) WriteWARC(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	info := fmt.Sprintf("software: %s %s\r\nformat: WARC File Format 1.1\r\n",
+		h.Log.Creator.Name, h.Log.Creator.Version)
+	infoHeader := textproto.MIMEHeader{}
+	infoHeader.Set("WARC-Type", "warcinfo")
+	infoHeader.Set("WARC-Record-ID", newWARCRecordID())
+	infoHeader.Set("WARC-Date", warcDate(time.Now()))
+	infoHeader.Set("Content-Type", "application/warc-fields")
+	if err := writeWARCRecord(bw, infoHeader, []byte(info)); err != nil {
+		return err
+	}
+
+	for i, entry := range h.Log.Entries {
+		reqBlock, err := warcRequestBlock(entry)
+		if err != nil {
+			return err.WithField(fmt.Sprintf("log.entries[%d].request", i))
+		}
+		respBlock, err := warcResponseBlock(entry)
+		if err != nil {
+			return err.WithField(fmt.Sprintf("log.entries[%d].response", i))
+		}
+
+		requestID := newWARCRecordID()
+		responseID := newWARCRecordID()
+		date := warcDate(entry.StartedDateTime)
+
+		respHeader := textproto.MIMEHeader{}
+		respHeader.Set("WARC-Type", "response")
+		respHeader.Set("WARC-Record-ID", responseID)
+		respHeader.Set("WARC-Date", date)
+		respHeader.Set("WARC-Target-URI", entry.Request.URL)
+		respHeader.Set("WARC-Concurrent-To", requestID)
+		if entry.ServerIPAddress != "" {
+			respHeader.Set("WARC-IP-Address", entry.ServerIPAddress)
+		}
+		respHeader.Set("Content-Type", "application/http;msgtype=response")
+		if err := writeWARCRecord(bw, respHeader, respBlock); err != nil {
+			return err
+		}
+
+		reqHeader := textproto.MIMEHeader{}
+		reqHeader.Set("WARC-Type", "request")
+		reqHeader.Set("WARC-Record-ID", requestID)
+		reqHeader.Set("WARC-Date", date)
+		reqHeader.Set("WARC-Target-URI", entry.Request.URL)
+		reqHeader.Set("WARC-Concurrent-To", responseID)
+		if entry.ServerIPAddress != "" {
+			reqHeader.Set("WARC-IP-Address", entry.ServerIPAddress)
+		}
+		reqHeader.Set("Content-Type", "application/http;msgtype=request")
+		if err := writeWARCRecord(bw, reqHeader, reqBlock); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return NewFileSystemError("无法写入WARC数据", err)
+	}
+	return nil
+}
+
+// SaveWARCToFile 将HAR保存为WARC文件，文件名以.gz结尾时使用gzip压缩
+func (h *Har) SaveWARCToFile(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return NewFileSystemError(fmt.Sprintf("无法创建文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	var w io.Writer = file
+	var zw *gzip.Writer
+	if strings.HasSuffix(filePath, ".gz") {
+		zw = gzip.NewWriter(file)
+		w = zw
+	}
+	if err := h.WriteWARC(w); err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return err
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return NewFileSystemError(fmt.Sprintf("无法写入文件 '%s'", filePath), err)
+		}
+	}
+	return nil
+}
+
+// ImportWARCFile 从WARC文件(支持.warc.gz)导入HTTP请求和响应记录
+func ImportWARCFile(filePath string) (*Har, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, NewFileSystemError(fmt.Sprintf("无法读取文件 '%s'", filePath), err)
+	}
+	defer file.Close()
+
+	h, err := ImportWARC(file)
+	if err != nil {
+		if harErr, ok := err.(*HarError); ok {
+			harErr.WithMetadata("filePath", filePath)
+		}
+		return nil, err
+	}
+	return h, nil
+}
+
+// ImportWARC 从WARC数据导入HTTP请求和响应记录
+//
+// response记录与request记录通过WARC-Concurrent-To配对，缺少该字段时按
+// WARC-Target-URI顺序配对；没有对应request记录的响应会生成一个GET请求。
+// 其他类型的记录(warcinfo、metadata、revisit等)会被忽略。
+// gzip压缩的WARC数据会被自动识别。
+func ImportWARC(r io.Reader) (*Har, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, NewInvalidFormatError(fmt.Sprintf("gzip数据无效: %v", err))
+		}
+		defer zr.Close()
+		br = bufio.NewReader(zr)
+	}
+
+	var records []*warcRecord
+	for {
+		record, err := readWARCRecord(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, NewInvalidFormatError(fmt.Sprintf("第%d条WARC记录解析失败: %v", len(records)+1, err))
+		}
+		records = append(records, record)
+	}
+
+	requests := make(map[string]*warcRecord)
+	var pending []*warcRecord
+	for _, record := range records {
+		if strings.EqualFold(record.get("WARC-Type"), "request") {
+			requests[record.get("WARC-Record-ID")] = record
+			pending = append(pending, record)
+		}
+	}
+	used := make(map[*warcRecord]bool)
+
+	h := NewHar()
+	h.SetCreator("go-har warc importer", "1.0")
+	for _, record := range records {
+		if !strings.EqualFold(record.get("WARC-Type"), "response") {
+			continue
+		}
+		request := matchWARCRequest(record, requests, pending, used)
+		entry, ok := warcEntry(request, record)
+		if ok {
+			h.Log.Entries = append(h.Log.Entries, entry)
+		}
+	}
+	for _, request := range pending {
+		if used[request] {
+			continue
+		}
+		if entry, ok := warcEntry(request, nil); ok {
+			h.Log.Entries = append(h.Log.Entries, entry)
+		}
+	}
+	return h, nil
+}
+
+// matchWARCRequest 查找与响应记录对应的请求记录
+func matchWARCRequest(response *warcRecord, requests map[string]*warcRecord, pending []*warcRecord, used map[*warcRecord]bool) *warcRecord {
+	responseID := response.get("WARC-Record-ID")
+	for _, id := range response.header.Values("WARC-Concurrent-To") {
+		if request, ok := requests[id]; ok && !used[request] {
+			used[request] = true
+			return request
+		}
+	}
+	for _, request := range pending {
+		if !used[request] && request.get("WARC-Concurrent-To") == responseID {
+			used[request] = true
+			return request
+		}
+	}
+	target := response.get("WARC-Target-URI")
+	for _, request := range pending {
+		if !used[request] && request.get("WARC-Concurrent-To") == "" && request.get("WARC-Target-URI") == target {
+			used[request] = true
+			return request
+		}
+	}
+	return nil
+}
+
+// warcEntry 根据一对请求/响应记录生成条目，request或response可以为nil
+func warcEntry(request, response *warcRecord) (Entries, bool) {
+	primary := request
+	if primary == nil {
+		primary = response
+	}
+	target := strings.Trim(primary.get("WARC-Target-URI"), "<>")
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return Entries{}, false
+	}
+
+	var reqData []byte
+	if request != nil {
+		reqData = request.block
+	} else {
+		reqData = []byte(fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\n\r\n", u.RequestURI(), u.Host))
+	}
+	var respData []byte
+	if response != nil {
+		respData = response.block
+	}
+
+	entry, ok := rawHTTPEntry(reqData, respData, u.Scheme, 0)
+	if !ok {
+		return Entries{}, false
+	}
+	entry.Request.URL = target
+	entry.Request.QueryString = queryStringFromURL(target)
+	entry.ServerIPAddress = primary.get("WARC-IP-Address")
+	if entry.ServerIPAddress == "" && response != nil {
+		entry.ServerIPAddress = response.get("WARC-IP-Address")
+	}
+	if started, err := time.Parse(time.RFC3339Nano, primary.get("WARC-Date")); err == nil {
+		entry.StartedDateTime = started
+	}
+	// WARC不记录各阶段耗时
+	entry.Timings = Timings{Blocked: -1, DNS: -1, Connect: -1, Ssl: -1, Send: 0, Wait: 0, Receive: 0}
+	return entry, true
+}
+
+// readWARCRecord 读取一条WARC记录
+func readWARCRecord(br *bufio.Reader) (*warcRecord, error) {
+	// 跳过记录之间的空行
+	var versionLine string
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && strings.TrimSpace(line) == "" {
+				return nil, io.EOF
+			}
+			if err != io.EOF {
+				return nil, err
+			}
+		}
+		if strings.TrimSpace(line) != "" {
+			versionLine = strings.TrimSpace(line)
+			break
+		}
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+	}
+	if !strings.HasPrefix(versionLine, "WARC/") {
+		return nil, fmt.Errorf("缺少WARC版本行: %q", versionLine)
+	}
+
+	header, err := textproto.NewReader(br).ReadMIMEHeader()
+	if err != nil {
+		return nil, fmt.Errorf("记录头部无效: %w", err)
+	}
+	length, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("Content-Length无效: %q", header.Get("Content-Length"))
+	}
+	if length > maxWARCRecordSize {
+		return nil, fmt.Errorf("记录长度异常: %d", length)
+	}
+	// 按实际读到的数据增长缓冲区，截断的文件不会按声明的长度分配内存
+	var block bytes.Buffer
+	if _, err := io.CopyN(&block, br, length); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("记录内容不完整: %w", err)
+	}
+	return &warcRecord{header: header, block: block.Bytes()}, nil
+}
+
+// writeWARCRecord 写入一条WARC记录，自动补充Content-Length和WARC-Block-Digest
+func writeWARCRecord(w io.Writer, header textproto.MIMEHeader, block []byte) *HarError {
+	digest := sha1.Sum(block)
+	header.Set("WARC-Block-Digest", "sha1:"+base32.StdEncoding.EncodeToString(digest[:]))
+	header.Set("Content-Length", strconv.Itoa(len(block)))
+
+	var buf bytes.Buffer
+	buf.WriteString(warcVersion + "\r\n")
+	// 按固定顺序输出常用字段，便于阅读
+	order := []string{"WARC-Type", "WARC-Record-ID", "WARC-Date", "WARC-Target-URI", "WARC-Concurrent-To",
+		"WARC-IP-Address", "WARC-Block-Digest", "Content-Type", "Content-Length"}
+	for _, name := range order {
+		if value := header.Get(name); value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(block)
+	buf.WriteString("\r\n\r\n")
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return NewFileSystemError("无法写入WARC记录", err)
+	}
+	return nil
+}
+
+// warcRequestBlock 将请求还原为HTTP/1.1报文
+func warcRequestBlock(entry Entries) ([]byte, *HarError) {
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return nil, NewInvalidValueError("url", entry.Request.URL, "无法解析URL")
+	}
+	mimeType, body := postDataText(entry.Request)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s %s\r\n", entry.Request.Method, u.RequestURI(), warcHTTPVersion(entry.Request.HTTPVersion))
+	hasHost := false
+	for _, header := range entry.Request.Headers {
+		if skipWARCHeader(header.Name) {
+			continue
+		}
+		if strings.EqualFold(header.Name, "Host") {
+			hasHost = true
+		}
+		fmt.Fprintf(&buf, "%s: %s\r\n", header.Name, header.Value)
+	}
+	if !hasHost {
+		fmt.Fprintf(&buf, "Host: %s\r\n", u.Host)
+	}
+	if body != "" {
+		if headerValue(entry.Request.Headers, "Content-Type") == "" && mimeType != "" {
+			fmt.Fprintf(&buf, "Content-Type: %s\r\n", mimeType)
+		}
+		fmt.Fprintf(&buf, "Content-Length: %d\r\n", len(body))
+	}
+	buf.WriteString("\r\n")
+	buf.WriteString(body)
+	return buf.Bytes(), nil
+}
+
+// warcResponseBlock 将响应还原为HTTP/1.1报文
+func warcResponseBlock(entry Entries) ([]byte, *HarError) {
+	body, err := decodeContentText(entry.Response.Content)
+	if err != nil {
+		return nil, err.(*HarError)
+	}
+
+	contentEncoding := strings.ToLower(strings.TrimSpace(headerValue(entry.Response.Headers, "Content-Encoding")))
+	keepEncoding := false
+	if len(body) > 0 {
+		if encoded, ok := encodeContent(body, contentEncoding); ok {
+			body = encoded
+			keepEncoding = contentEncoding != ""
+		}
+	}
+
+	statusText := entry.Response.StatusText
+	if statusText == "" {
+		statusText = http.StatusText(entry.Response.Status)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %d %s\r\n", warcHTTPVersion(entry.Response.HTTPVersion), entry.Response.Status, statusText)
+	for _, header := range entry.Response.Headers {
+		if skipWARCHeader(header.Name) {
+			continue
+		}
+		if strings.EqualFold(header.Name, "Content-Encoding") && !keepEncoding {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s: %s\r\n", header.Name, header.Value)
+	}
+	if headerValue(entry.Response.Headers, "Content-Type") == "" && entry.Response.Content.MimeType != "" {
+		fmt.Fprintf(&buf, "Content-Type: %s\r\n", entry.Response.Content.MimeType)
+	}
+	fmt.Fprintf(&buf, "Content-Length: %d\r\n\r\n", len(body))
+	buf.Write(body)
+	return buf.Bytes(), nil
+}
+
+// encodeContent 按Content-Encoding重新压缩内容，不支持的编码返回false
+func encodeContent(body []byte, encoding string) ([]byte, bool) {
+	var buf bytes.Buffer
+	switch encoding {
+	case "", "identity":
+		return body, true
+	case "gzip", "x-gzip":
+		zw := gzip.NewWriter(&buf)
+		zw.Write(body)
+		zw.Close()
+	case "deflate":
+		// HTTP的deflate内容编码为zlib格式(RFC 9110)
+		zw := zlib.NewWriter(&buf)
+		zw.Write(body)
+		zw.Close()
+	default:
+		return body, false
+	}
+	return buf.Bytes(), true
+}
+
+// skipWARCHeader 判断重建报文时需要丢弃的头部
+func skipWARCHeader(name string) bool {
+	// HTTP/2伪头部和由重建报文重新计算的头部
+	return strings.HasPrefix(name, ":") ||
+		strings.EqualFold(name, "Content-Length") ||
+		strings.EqualFold(name, "Transfer-Encoding")
+}
+
+// warcHTTPVersion 将HAR中的协议版本映射为HTTP/1.x报文可用的版本
+func warcHTTPVersion(version string) string {
+	if strings.EqualFold(version, "HTTP/1.0") {
+		return "HTTP/1.0"
+	}
+	return "HTTP/1.1"
+}
+
+// warcDate 格式化WARC-Date
+func warcDate(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// newWARCRecordID 生成urn:uuid形式的记录ID
+func newWARCRecordID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("<urn:uuid:%x-%x-%x-%x-%x>", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,114 @@
+package har
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWARCRoundTrip(t *testing.T) {
+	h := NewHar()
+	entry := h.AddEntry("GET", "https://x.com/a?b=1", "HTTP/1.1", "")
+	entry.SetResponseStatus(200, "OK")
+	entry.SetResponseContent(5, "text/plain")
+	entry.Response.Content.Text = "hello"
+
+	var buf bytes.Buffer
+	assert.NoError(t, h.WriteWARC(&buf))
+
+	imported, err := ImportWARC(&buf)
+	assert.NoError(t, err)
+	if assert.Len(t, imported.Log.Entries, 1) {
+		assert.Equal(t, "https://x.com/a?b=1", imported.Log.Entries[0].Request.URL)
+		assert.Equal(t, 200, imported.Log.Entries[0].Response.Status)
+		assert.Equal(t, "hello", imported.Log.Entries[0].Response.Content.Text)
+	}
+}
+
+func TestWARCRoundTripContentEncoding(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0xff}
+	tests := []struct {
+		name            string
+		mimeType        string
+		text            string
+		encoding        string
+		contentEncoding string
+		// 写出的响应体以该前缀开始
+		bodyPrefix []byte
+	}{
+		{"base64", "image/png", base64.StdEncoding.EncodeToString(png), "base64", "", png[:4]},
+		{"gzip", "text/plain", "hello gzip", "", "gzip", []byte{0x1f, 0x8b}},
+		// deflate为zlib格式，CMF字节为0x78
+		{"deflate", "text/plain", "hello deflate", "", "deflate", []byte{0x78}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHar()
+			entry := h.AddEntry("GET", "https://x.com/a", "HTTP/1.1", "")
+			entry.SetResponseStatus(200, "OK")
+			entry.SetResponseContent(0, tt.mimeType)
+			entry.Response.Content.Text = tt.text
+			entry.Response.Content.Encoding = tt.encoding
+			entry.AddResponseHeader("Content-Type", tt.mimeType)
+			if tt.contentEncoding != "" {
+				entry.AddResponseHeader("Content-Encoding", tt.contentEncoding)
+			}
+
+			var buf bytes.Buffer
+			assert.NoError(t, h.WriteWARC(&buf))
+			block := buf.String()
+			_, body, _ := strings.Cut(block[strings.Index(block, "HTTP/1.1 200"):], "\r\n\r\n")
+			assert.True(t, strings.HasPrefix(body, string(tt.bodyPrefix)), "响应体应按Content-Encoding编码")
+
+			imported, err := ImportWARC(&buf)
+			assert.NoError(t, err)
+			if assert.Len(t, imported.Log.Entries, 1) {
+				content := imported.Log.Entries[0].Response.Content
+				assert.Equal(t, tt.text, content.Text)
+				assert.Equal(t, tt.encoding, content.Encoding)
+			}
+		})
+	}
+}
+
+func TestInflateBody(t *testing.T) {
+	var zlibBuf, rawBuf bytes.Buffer
+	zw := zlib.NewWriter(&zlibBuf)
+	io.WriteString(zw, "zlib")
+	zw.Close()
+	fw, _ := flate.NewWriter(&rawBuf, flate.DefaultCompression)
+	io.WriteString(fw, "raw")
+	fw.Close()
+
+	// zlib格式为标准的deflate编码，没有zlib头时按原始DEFLATE解码
+	assert.Equal(t, "zlib", buildContent(zlibBuf.Bytes(), "text/plain", "deflate", 0).Text)
+	assert.Equal(t, "raw", buildContent(rawBuf.Bytes(), "text/plain", "deflate", 0).Text)
+}
+
+func TestImportWARCRecordLength(t *testing.T) {
+	record := func(length string, block string) string {
+		return "WARC/1.1\r\nWARC-Type: resource\r\nContent-Length: " + length + "\r\n\r\n" + block
+	}
+
+	// 超过上限的长度在分配内存之前被拒绝
+	_, err := ImportWARC(strings.NewReader(record("999999999999", "abc")))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "999999999999")
+	}
+
+	for _, length := range []string{"-1", "x"} {
+		_, err = ImportWARC(strings.NewReader(record(length, "abc")))
+		assert.Error(t, err, length)
+	}
+
+	// 内容比声明的长度短
+	_, err = ImportWARC(strings.NewReader(record("10", "abc")))
+	assert.Error(t, err)
+}